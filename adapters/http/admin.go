@@ -0,0 +1,24 @@
+package httpadp
+
+import (
+    "crypto/subtle"
+    "net/http"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+)
+
+// AdminAuth guards admin routes with a shared bearer token. An empty token disables them: every
+// request gets 503 rather than the routes being open.
+func AdminAuth(token string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        if token == "" { c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "admin API is disabled; set ADMIN_TOKEN to enable it"}); return }
+        got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+        if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+            c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "admin token required"})
+            return
+        }
+        c.Next()
+    }
+}
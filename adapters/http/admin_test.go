@@ -0,0 +1,31 @@
+package httpadp_test
+
+import (
+    "net/http"
+    "testing"
+
+    "github.com/gin-gonic/gin"
+    httpadp "github.com/robjsliwa/pulse/adapters/http"
+)
+
+func TestAdminAuth(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    for _, tc := range []struct {
+        name, token, header string
+        want                int
+    }{
+        {"disabled without a token", "", "Bearer ", http.StatusServiceUnavailable},
+        {"missing header", "s3cret", "", http.StatusUnauthorized},
+        {"wrong token", "s3cret", "Bearer guess", http.StatusUnauthorized},
+        {"not a bearer", "s3cret", "s3cret", http.StatusUnauthorized},
+        {"right token", "s3cret", "Bearer s3cret", http.StatusOK},
+    } {
+        t.Run(tc.name, func(t *testing.T) {
+            r := gin.New()
+            r.POST("/admin/consistency/repair", httpadp.AdminAuth(tc.token), func(c *gin.Context) { c.Status(http.StatusOK) })
+            req := serveRequest(http.MethodPost, "/admin/consistency/repair", "")
+            if tc.header != "" { req.Header.Set("Authorization", tc.header) }
+            if w := record(r, req); w.Code != tc.want { t.Fatalf("status %d, want %d", w.Code, tc.want) }
+        })
+    }
+}
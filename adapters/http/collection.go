@@ -0,0 +1,218 @@
+package httpadp
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// CreateCollection godoc
+// @Summary Create a poll collection
+// @Description Groups polls under one schedule, access mode and webhook scope. A collection with opens_at in the future starts scheduled and its polls take no votes until it opens; at closes_at its polls close. A poll belongs to at most one collection; survey and quiz polls cannot join one.
+// @Tags collections
+// @Accept json
+// @Produce json
+// @Param payload body CreateCollectionRequest true "Collection"
+// @Param Idempotency-Key header string false "Replays the original response for retried requests"
+// @Success 201 {object} domain.Collection
+// @Failure 400 {object} gin.H
+// @Failure 404 {object} gin.H "A poll in poll_ids does not exist"
+// @Router /collections [post]
+func (h *Handler) CreateCollection(c *gin.Context) {
+    var req CreateCollectionRequest
+    if err := c.ShouldBindJSON(&req); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    in := domain.Collection{Name: req.Name, Description: req.Description, OpensAt: req.OpensAt, ClosesAt: req.ClosesAt, Access: domain.AccessMode(req.Access), WebhookScope: domain.WebhookScope(req.WebhookScope)}
+    col, err := h.svc.CreateCollection(c.Request.Context(), in, req.PollIDs)
+    if errors.Is(err, domain.ErrPollNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusCreated, col)
+}
+
+// ListCollections godoc
+// @Summary List poll collections
+// @Tags collections
+// @Produce json
+// @Param offset query int false "Offset"
+// @Param limit query int false "Limit"
+// @Success 200 {array} domain.Collection
+// @Router /collections [get]
+func (h *Handler) ListCollections(c *gin.Context) {
+    offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+    limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+    res, err := h.svc.ListCollections(c.Request.Context(), offset, limit)
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, res)
+}
+
+// GetCollection godoc
+// @Summary Get a poll collection
+// @Tags collections
+// @Produce json
+// @Param id path int true "Collection ID"
+// @Success 200 {object} domain.Collection
+// @Failure 404 {object} gin.H
+// @Router /collections/{id} [get]
+func (h *Handler) GetCollection(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    col, err := h.svc.GetCollection(c.Request.Context(), uint(id))
+    if errors.Is(err, domain.ErrCollectionNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, col)
+}
+
+// UpdateCollection godoc
+// @Summary Update a poll collection's settings
+// @Description Moving opens_at or closes_at reschedules the next transition; null clears closes_at, or opens_at of a collection that is not scheduled. Use the open and close endpoints to move a collection at once.
+// @Tags collections
+// @Accept json
+// @Produce json
+// @Param id path int true "Collection ID"
+// @Param payload body UpdateCollectionRequest true "Settings to change"
+// @Success 200 {object} domain.Collection
+// @Failure 400 {object} gin.H
+// @Failure 404 {object} gin.H
+// @Router /collections/{id} [patch]
+func (h *Handler) UpdateCollection(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    var req UpdateCollectionRequest
+    if err := c.ShouldBindJSON(&req); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    in := domain.CollectionPatch{ID: uint(id), Name: req.Name, Description: req.Description, OpensAt: req.OpensAt.patch(), ClosesAt: req.ClosesAt.patch()}
+    if req.Access != nil { a := domain.AccessMode(*req.Access); in.Access = &a }
+    if req.WebhookScope != nil { w := domain.WebhookScope(*req.WebhookScope); in.WebhookScope = &w }
+    col, err := h.svc.UpdateCollection(c.Request.Context(), in)
+    if errors.Is(err, domain.ErrCollectionNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, col)
+}
+
+// DeleteCollection godoc
+// @Summary Delete a poll collection
+// @Description Its polls are kept, each on its own again.
+// @Tags collections
+// @Param id path int true "Collection ID"
+// @Success 204
+// @Failure 404 {object} gin.H
+// @Router /collections/{id} [delete]
+func (h *Handler) DeleteCollection(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    err := h.svc.DeleteCollection(c.Request.Context(), uint(id))
+    if errors.Is(err, domain.ErrCollectionNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()}); return }
+    c.Status(http.StatusNoContent)
+}
+
+// AddCollectionPoll godoc
+// @Summary Add a poll to a collection
+// @Tags collections
+// @Accept json
+// @Produce json
+// @Param id path int true "Collection ID"
+// @Param payload body CollectionPollRequest true "Poll"
+// @Success 200 {object} domain.Collection
+// @Failure 400 {object} gin.H "The poll is in another collection, a survey or a quiz"
+// @Failure 404 {object} gin.H
+// @Router /collections/{id}/polls [post]
+func (h *Handler) AddCollectionPoll(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    var req CollectionPollRequest
+    if err := c.ShouldBindJSON(&req); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    col, err := h.svc.AddCollectionPoll(c.Request.Context(), uint(id), req.PollID)
+    if errors.Is(err, domain.ErrCollectionNotFound) || errors.Is(err, domain.ErrPollNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, col)
+}
+
+// RemoveCollectionPoll godoc
+// @Summary Remove a poll from a collection
+// @Description The poll keeps its status and votes.
+// @Tags collections
+// @Produce json
+// @Param id path int true "Collection ID"
+// @Param pollId path int true "Poll ID"
+// @Success 200 {object} domain.Collection
+// @Failure 400 {object} gin.H
+// @Failure 404 {object} gin.H
+// @Router /collections/{id}/polls/{pollId} [delete]
+func (h *Handler) RemoveCollectionPoll(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    pollID, _ := strconv.Atoi(c.Param("pollId"))
+    col, err := h.svc.RemoveCollectionPoll(c.Request.Context(), uint(id), uint(pollID))
+    if errors.Is(err, domain.ErrCollectionNotFound) || errors.Is(err, domain.ErrPollNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, col)
+}
+
+// OpenCollection godoc
+// @Summary Open a collection now
+// @Description Reopens the polls that closing the collection closed; polls closed on their own stay closed. Safe to retry when some polls failed to open.
+// @Tags collections
+// @Produce json
+// @Param id path int true "Collection ID"
+// @Success 200 {object} domain.Collection
+// @Failure 404 {object} gin.H
+// @Failure 500 {object} gin.H
+// @Router /collections/{id}/open [post]
+func (h *Handler) OpenCollection(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    col, err := h.svc.OpenCollection(c.Request.Context(), uint(id))
+    if errors.Is(err, domain.ErrCollectionNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, col)
+}
+
+// CloseCollection godoc
+// @Summary Close a collection now
+// @Description Closes every open poll in the collection. Safe to retry when some polls failed to close.
+// @Tags collections
+// @Produce json
+// @Param id path int true "Collection ID"
+// @Success 200 {object} domain.Collection
+// @Failure 404 {object} gin.H
+// @Failure 500 {object} gin.H
+// @Router /collections/{id}/close [post]
+func (h *Handler) CloseCollection(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    col, err := h.svc.CloseCollection(c.Request.Context(), uint(id))
+    if errors.Is(err, domain.ErrCollectionNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, col)
+}
+
+// CollectionStats godoc
+// @Summary Participation across a collection's polls
+// @Description Voters counts distinct user IDs; ballots in anonymous polls and votes without user_id add to votes only.
+// @Tags collections
+// @Produce json
+// @Param id path int true "Collection ID"
+// @Success 200 {object} domain.CollectionStats
+// @Failure 404 {object} gin.H
+// @Router /collections/{id}/stats [get]
+func (h *Handler) CollectionStats(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    stats, err := h.svc.CollectionStats(c.Request.Context(), uint(id))
+    if errors.Is(err, domain.ErrCollectionNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, stats)
+}
+
+// CollectionStream godoc
+// @Summary Stream a collection via SSE
+// @Description Sends the collection's status on connect, then an update whenever a member poll's results or the collection's status change.
+// @Tags collections
+// @Produce text/event-stream
+// @Param id path int true "Collection ID"
+// @Router /collections/{id}/stream [get]
+func (h *Handler) CollectionStream(c *gin.Context) {
+    if h.collections == nil { c.JSON(http.StatusNotImplemented, gin.H{"error": "collection streaming is not configured"}); return }
+    id, _ := strconv.Atoi(c.Param("id"))
+    col, err := h.svc.GetCollection(c.Request.Context(), uint(id))
+    if errors.Is(err, domain.ErrCollectionNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    ch, cancel := h.collections.SubscribeCollection(uint(id))
+    defer cancel()
+    first := domain.CollectionUpdate{CollectionID: uint(id)}
+    if err == nil { first.Status = col.Status }
+    serveSSE(c, first, err == nil, ch, nil)
+}
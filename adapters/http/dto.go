@@ -1,26 +1,227 @@
 package httpadp
 
+import (
+    "encoding/json"
+    "time"
+
+    "github.com/robjsliwa/pulse/domain"
+)
+
 // Request/Response DTOs for binding/validation layer.
 
 type CreatePollRequest struct {
-    Title       string         `json:"title" binding:"required,min=1,max=200"`
-    Description string         `json:"description"`
-    Threshold   int            `json:"threshold"`
-    Options     []CreateOption `json:"options" binding:"required,dive"`
+    Title               string         `json:"title" binding:"required,min=1,max=200"`
+    Description         string         `json:"description"`
+    ImageURL            string         `json:"image_url"`
+    // Kind is choice (default), text, nps or likert. Text polls take free-text votes instead of
+    // options; nps and likert polls generate theirs.
+    Kind                string         `json:"kind" binding:"omitempty,oneof=choice text nps likert"`
+    Language            string         `json:"language"` // text polls: stopword language for results terms
+    Scale               int            `json:"scale"` // likert polls: 5 (default) or 7 points
+    Threshold           int            `json:"threshold"`
+    ThresholdBasis      string         `json:"threshold_basis" binding:"omitempty,oneof=votes weight"` // votes (default) or weight
+    // Weighted restricts voting to the poll's electorate (PUT /polls/{id}/electorate) and weighs each vote.
+    Weighted            bool           `json:"weighted"`
+    // CreditBudget turns on quadratic voting: each voter spends up to this many credits, n votes for an option costing n².
+    CreditBudget        int            `json:"credit_budget" binding:"min=0"`
+    Anonymous           bool           `json:"anonymous"`
+    VoteRatePerMinute   int            `json:"vote_rate_per_minute"`
+    VoteBurst           int            `json:"vote_burst"`
+    ChallengeDifficulty int            `json:"challenge_difficulty"`
+    Options             []CreateOption `json:"options" binding:"dive"`
 }
 
 type CreateOption struct {
-    Text string `json:"text" binding:"required,min=1,max=200"`
+    Text        string          `json:"text" binding:"required,min=1,max=200"`
+    Description string          `json:"description" binding:"max=2000"`
+    ImageURL    string          `json:"image_url"` // http(s) URL or /media/... path; checked by the service
+    Color       string          `json:"color" binding:"omitempty,hexcolor"`
+    Metadata    json.RawMessage `json:"metadata" swaggertype:"object"`
+}
+
+type UpdateOptionRequest struct {
+    Text        *string         `json:"text" binding:"omitempty,min=1,max=200"`
+    Description *string         `json:"description" binding:"omitempty,max=2000"`
+    ImageURL    *string         `json:"image_url"`
+    Color       *string         `json:"color" binding:"omitempty,hexcolor"`
+    Metadata    json.RawMessage `json:"metadata" swaggertype:"object"`
+}
+
+type ReorderOptionsRequest struct {
+    OptionIDs []uint `json:"option_ids" binding:"required,min=1"`
 }
 
 type UpdatePollRequest struct {
-    Title       *string `json:"title"`
-    Description *string `json:"description"`
-    Threshold   *int    `json:"threshold"`
+    Title               *string `json:"title"`
+    Description         *string `json:"description"`
+    ImageURL            *string `json:"image_url"`
+    Threshold           *int    `json:"threshold"`
+    ThresholdBasis      *string `json:"threshold_basis" binding:"omitempty,oneof=votes weight"`
+    VoteRatePerMinute   *int    `json:"vote_rate_per_minute"` // 0 restores the server default
+    VoteBurst           *int    `json:"vote_burst"`
+    ChallengeDifficulty *int    `json:"challenge_difficulty"`
 }
 
 type VoteRequest struct {
-    OptionID uint   `json:"option_id" binding:"required"`
-    UserID   string `json:"user_id"`
+    OptionID  uint   `json:"option_id" binding:"required_without=Text"`
+    Text      string `json:"text"` // text polls only
+    // Votes is what a quadratic poll's voter allocates to the option, replacing their earlier
+    // allocation; 0 withdraws it. Defaults to 1.
+    Votes     *int   `json:"votes" binding:"omitempty,min=0"`
+    UserID    string `json:"user_id"`
+    // Challenge and Solution carry a solved proof-of-work challenge for polls that require one.
+    Challenge string `json:"challenge"`
+    Solution  string `json:"solution"`
+}
+
+
+type ElectorateRequest struct {
+    Voters []VoterRequest `json:"voters" binding:"dive"`
+}
+
+type VoterRequest struct {
+    UserID string `json:"user_id" binding:"required"`
+    Weight int    `json:"weight" binding:"required,min=1"`
+}
+
+// CreateTemplateRequest describes a poll template. The poll's title, description and option texts
+// may use {{variables}}: date, time, weekday, week, month and year (UTC, at creation) and the
+// template's own variables.
+type CreateTemplateRequest struct {
+    Name      string            `json:"name" binding:"required,max=200"`
+    Poll      CreatePollRequest `json:"poll"`
+    Variables map[string]string `json:"variables"` // defaults, overridable per instantiation
+    Schedule  string            `json:"schedule"` // optional cron expression or recurrence rule, e.g. "0 9 * * 1", "FREQ=WEEKLY;BYDAY=MO;BYHOUR=9", optionally prefixed with "TZ=Europe/Warsaw "
+    // Series links the polls created from the template into a series, each closing the one before.
+    Series    bool              `json:"series"`
+}
+
+type RecurrenceRequest struct {
+    Schedule string `json:"schedule" binding:"required"` // cron expression or recurrence rule, as for templates
+}
+
+type InstantiateTemplateRequest struct {
+    Variables map[string]string `json:"variables"`
+}
+
+// CreateCollectionRequest describes a collection. Without opens_at it opens at once; the polls in
+// poll_ids join it and follow its schedule from then on.
+type CreateCollectionRequest struct {
+    Name         string     `json:"name" binding:"required,max=200"`
+    Description  string     `json:"description"`
+    OpensAt      *time.Time `json:"opens_at"`
+    ClosesAt     *time.Time `json:"closes_at"`
+    Access       string     `json:"access" binding:"omitempty,oneof=public identified"`
+    WebhookScope string     `json:"webhook_scope" binding:"omitempty,oneof=polls collection none"`
+    PollIDs      []uint     `json:"poll_ids"`
+}
+
+// UpdateCollectionRequest changes the settings it carries and leaves the others as they are;
+// null opens_at or closes_at clears it.
+type UpdateCollectionRequest struct {
+    Name         *string      `json:"name" binding:"omitempty,min=1,max=200"`
+    Description  *string      `json:"description"`
+    OpensAt      NullableTime `json:"opens_at" swaggertype:"string" format:"date-time"`
+    ClosesAt     NullableTime `json:"closes_at" swaggertype:"string" format:"date-time"`
+    Access       *string      `json:"access" binding:"omitempty,oneof=public identified"`
+    WebhookScope *string      `json:"webhook_scope" binding:"omitempty,oneof=polls collection none"`
+}
+
+// NullableTime tells a time left out of a body from an explicit null.
+type NullableTime struct {
+    Set  bool
+    Time *time.Time
+}
+
+func (n *NullableTime) UnmarshalJSON(b []byte) error {
+    n.Set = true
+    return json.Unmarshal(b, &n.Time)
+}
+
+// patch returns nil when the time was left out and the zero time for null.
+func (n NullableTime) patch() *time.Time {
+    if !n.Set { return nil }
+    if n.Time == nil { return &time.Time{} }
+    return n.Time
 }
 
+type CollectionPollRequest struct {
+    PollID uint `json:"poll_id" binding:"required"`
+}
+
+// VoteResponse is a domain.Vote without the client metadata kept for fraud screening and moderation.
+// Fields are untagged so the body keeps domain.Vote's shape.
+type VoteResponse struct {
+    ID         uint
+    PollID     uint
+    OptionID   uint
+    Text       string
+    UserID     string
+    Weight     int
+    Status     domain.VoteStatus
+    FlagReason string
+    CreatedAt  time.Time
+}
+
+func newVoteResponse(v domain.Vote) VoteResponse {
+    return VoteResponse{ID: v.ID, PollID: v.PollID, OptionID: v.OptionID, Text: v.Text, UserID: v.UserID, Weight: v.Weight, Status: v.Status, FlagReason: v.FlagReason, CreatedAt: v.CreatedAt}
+}
+
+func newVoteResponses(vs []domain.Vote) []VoteResponse {
+    out := make([]VoteResponse, len(vs))
+    for i, v := range vs { out[i] = newVoteResponse(v) }
+    return out
+}
+
+type ReactionRequest struct {
+    Emoji string `json:"emoji" binding:"required"`
+}
+
+type ReactionEmojisResponse struct {
+    Emojis []string `json:"emojis"`
+}
+
+
+type CreateSurveyRequest struct {
+    Title       string                  `json:"title" binding:"required,min=1,max=200"`
+    Description string                  `json:"description"`
+    Questions   []CreateQuestionRequest `json:"questions" binding:"required,min=1,dive"`
+}
+
+type CreateQuestionRequest struct {
+    Prompt   string         `json:"prompt" binding:"required,min=1,max=500"`
+    Kind     string         `json:"kind" binding:"required,oneof=choice text"`
+    Required bool           `json:"required"`
+    Options  []CreateOption `json:"options" binding:"dive"` // choice questions only
+}
+
+type CreateQuizRequest struct {
+    Title       string                      `json:"title" binding:"required,min=1,max=200"`
+    Description string                      `json:"description"`
+    Questions   []CreateQuizQuestionRequest `json:"questions" binding:"required,min=1,dive"`
+}
+
+type CreateQuizQuestionRequest struct {
+    Prompt           string              `json:"prompt" binding:"required,min=1,max=500"`
+    Points           int                 `json:"points" binding:"min=0"`                       // default 1000
+    TimeLimitSeconds int                 `json:"time_limit_seconds" binding:"min=0,max=3600"` // default 30
+    Options          []QuizOptionRequest `json:"options" binding:"required,min=1,dive"`
+}
+
+// QuizOptionRequest is an option that is marked correct or not.
+type QuizOptionRequest struct {
+    CreateOption
+    Correct bool `json:"correct"`
+}
+
+type SubmitSurveyRequest struct {
+    UserID  string                `json:"user_id"`
+    Answers []SurveyAnswerRequest `json:"answers" binding:"dive"`
+}
+
+// SurveyAnswerRequest answers a choice question with option_id or a text question with text.
+type SurveyAnswerRequest struct {
+    QuestionID uint   `json:"question_id" binding:"required"`
+    OptionID   uint   `json:"option_id"`
+    Text       string `json:"text"`
+}
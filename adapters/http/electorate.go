@@ -0,0 +1,50 @@
+package httpadp
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// SetElectorate godoc
+// @Summary Replace a weighted poll's electorate
+// @Description Only listed voters may vote, once each, and their votes count with their weight. Votes already cast keep the weight they were cast with; an empty list removes everyone.
+// @Tags electorate
+// @Accept json
+// @Produce json
+// @Param id path int true "Poll ID"
+// @Param payload body ElectorateRequest true "Voters and weights"
+// @Success 200 {array} domain.Voter
+// @Failure 400 {object} gin.H
+// @Failure 404 {object} gin.H
+// @Router /polls/{id}/electorate [put]
+func (h *Handler) SetElectorate(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    var req ElectorateRequest
+    if err := c.ShouldBindJSON(&req); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    voters := make([]domain.Voter, 0, len(req.Voters))
+    for _, v := range req.Voters { voters = append(voters, domain.Voter{UserID: v.UserID, Weight: v.Weight}) }
+    res, err := h.svc.SetElectorate(c.Request.Context(), uint(id), voters)
+    if errors.Is(err, domain.ErrPollNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, res)
+}
+
+// Electorate godoc
+// @Summary List a weighted poll's electorate
+// @Tags electorate
+// @Produce json
+// @Param id path int true "Poll ID"
+// @Success 200 {array} domain.Voter
+// @Failure 404 {object} gin.H
+// @Router /polls/{id}/electorate [get]
+func (h *Handler) Electorate(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    res, err := h.svc.Electorate(c.Request.Context(), uint(id))
+    if errors.Is(err, domain.ErrPollNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,61 @@
+package httpadp
+
+import (
+    "fmt"
+    "hash/fnv"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// pollETag renders a poll version as a strong entity tag.
+func pollETag(version int) string { return fmt.Sprintf(`"v%d"`, version) }
+
+// resultsETag fingerprints the counts, the option list (order, content, weights and credits) and
+// the word-cloud terms so pollers can use If-None-Match. Quadratic reallocations can change weights
+// without changing any count.
+func resultsETag(res domain.Results) string {
+    ids := make([]uint, 0, len(res.OptionVotes))
+    for id := range res.OptionVotes { ids = append(ids, id) }
+    sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+    h := fnv.New64a()
+    for _, id := range ids { fmt.Fprintf(h, "%d=%d;", id, res.OptionVotes[id]) }
+    for _, o := range res.Options { fmt.Fprintf(h, "%d:%q:%q:%q:%q:%s:%d:%d:%t;", o.OptionID, o.Text, o.Description, o.ImageURL, o.Color, o.Metadata, o.Weight, o.Credits, o.Correct) }
+    for _, t := range res.Terms { fmt.Fprintf(h, "%q=%d;", t.Term, t.Count) }
+    return fmt.Sprintf(`"r%d-%x"`, res.Total, h.Sum64())
+}
+
+// ifMatchVersion reads the poll version from If-Match; 0 means any version ("*" or no header).
+// It writes the error response and returns false when the header is required but missing (428)
+// or cannot refer to a poll version (412).
+func (h *Handler) ifMatchVersion(c *gin.Context) (int, bool) {
+    raw := strings.TrimSpace(c.GetHeader("If-Match"))
+    if raw == "" {
+        if h.requireIfMatch {
+            c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header required"})
+            return 0, false
+        }
+        return 0, true
+    }
+    if raw == "*" { return 0, true }
+    tag := strings.Trim(strings.TrimPrefix(raw, "W/"), `"`)
+    v, err := strconv.Atoi(strings.TrimPrefix(tag, "v"))
+    if err != nil || !strings.HasPrefix(tag, "v") || v <= 0 {
+        c.JSON(http.StatusPreconditionFailed, gin.H{"error": domain.ErrVersionConflict.Error()})
+        return 0, false
+    }
+    return v, true
+}
+
+// noneMatch reports whether If-None-Match lists etag.
+func noneMatch(c *gin.Context, etag string) bool {
+    for _, t := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+        t = strings.TrimSpace(t)
+        if t == "*" || strings.TrimPrefix(t, "W/") == etag { return true }
+    }
+    return false
+}
@@ -1,8 +1,13 @@
 package httpadp
 
 import (
+    "encoding/csv"
+    "errors"
+    "io/fs"
+    "mime/multipart"
     "net/http"
     "strconv"
+    "strings"
     "time"
 
     "github.com/gin-gonic/gin"
@@ -11,11 +16,35 @@ import (
 )
 
 type Handler struct {
-    svc     *app.Service
-    stream  app.ResultsStreamer
+    svc          *app.Service
+    stream       app.ResultsStreamer
+    reactions    app.ReactionStreamer
+    leaderboards app.LeaderboardStreamer
+    collections  app.CollectionStreamer
+
+    requireIfMatch bool
 }
 
-func NewHandler(svc *app.Service, stream app.ResultsStreamer) *Handler { return &Handler{svc: svc, stream: stream} }
+// HandlerOption configures optional Handler behaviour.
+type HandlerOption func(*Handler)
+
+// WithRequireIfMatch makes poll mutations answer 428 unless they carry If-Match.
+func WithRequireIfMatch(require bool) HandlerOption { return func(h *Handler) { h.requireIfMatch = require } }
+
+// WithReactionStream adds the reaction bursts from rs to poll result streams.
+func WithReactionStream(rs app.ReactionStreamer) HandlerOption { return func(h *Handler) { h.reactions = rs } }
+
+// WithLeaderboardStream serves quiz leaderboards over SSE from ls.
+func WithLeaderboardStream(ls app.LeaderboardStreamer) HandlerOption { return func(h *Handler) { h.leaderboards = ls } }
+
+// WithCollectionStream serves collection updates over SSE from cs.
+func WithCollectionStream(cs app.CollectionStreamer) HandlerOption { return func(h *Handler) { h.collections = cs } }
+
+func NewHandler(svc *app.Service, stream app.ResultsStreamer, opts ...HandlerOption) *Handler {
+    h := &Handler{svc: svc, stream: stream}
+    for _, o := range opts { o(h) }
+    return h
+}
 
 // CreatePoll godoc
 // @Summary Create a poll
@@ -23,8 +52,10 @@ func NewHandler(svc *app.Service, stream app.ResultsStreamer) *Handler { return
 // @Accept json
 // @Produce json
 // @Param payload body CreatePollRequest true "Poll"
+// @Param Idempotency-Key header string false "Replays the original response for retried requests"
 // @Success 201 {object} domain.Poll
 // @Failure 400 {object} gin.H
+// @Failure 422 {object} gin.H "Idempotency key reused with a different payload"
 // @Router /polls [post]
 func (h *Handler) CreatePoll(c *gin.Context) {
     var req CreatePollRequest
@@ -32,10 +63,11 @@ func (h *Handler) CreatePoll(c *gin.Context) {
         c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
         return
     }
-    p := domain.Poll{Title: req.Title, Description: req.Description, Threshold: req.Threshold}
-    for _, o := range req.Options { p.Options = append(p.Options, domain.Option{Text: o.Text}) }
+    p := domain.Poll{Title: req.Title, Description: req.Description, ImageURL: req.ImageURL, Kind: domain.PollKind(req.Kind), Language: req.Language, Scale: req.Scale, Threshold: req.Threshold, ThresholdBasis: domain.ThresholdBasis(req.ThresholdBasis), Weighted: req.Weighted, CreditBudget: req.CreditBudget, Anonymous: req.Anonymous, VoteRatePerMinute: req.VoteRatePerMinute, VoteBurst: req.VoteBurst, ChallengeDifficulty: req.ChallengeDifficulty}
+    for _, o := range req.Options { p.Options = append(p.Options, optionFromRequest(o)) }
     res, err := h.svc.CreatePoll(c.Request.Context(), p)
     if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.Header("ETag", pollETag(res.Version))
     c.JSON(http.StatusCreated, res)
 }
 
@@ -45,12 +77,16 @@ func (h *Handler) CreatePoll(c *gin.Context) {
 // @Produce json
 // @Param id path int true "Poll ID"
 // @Success 200 {object} domain.Poll
+// @Header 200 {string} ETag "Poll version"
 // @Failure 404 {object} gin.H
 // @Router /polls/{id} [get]
 func (h *Handler) GetPoll(c *gin.Context) {
     id, _ := strconv.Atoi(c.Param("id"))
     p, err := h.svc.GetPoll(c.Request.Context(), uint(id))
     if err != nil { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    etag := pollETag(p.Version)
+    c.Header("ETag", etag)
+    if noneMatch(c, etag) { c.Status(http.StatusNotModified); return }
     c.JSON(http.StatusOK, p)
 }
 
@@ -60,61 +96,103 @@ func (h *Handler) GetPoll(c *gin.Context) {
 // @Produce json
 // @Param offset query int false "Offset"
 // @Param limit query int false "Limit"
+// @Param deleted query bool false "List the trash instead of live polls"
 // @Success 200 {array} domain.Poll
 // @Router /polls [get]
 func (h *Handler) ListPolls(c *gin.Context) {
     offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
     limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
-    res, err := h.svc.ListPolls(c.Request.Context(), offset, limit)
+    list := h.svc.ListPolls
+    if deleted, _ := strconv.ParseBool(c.Query("deleted")); deleted { list = h.svc.ListDeletedPolls }
+    res, err := list(c.Request.Context(), offset, limit)
     if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
     c.JSON(http.StatusOK, res)
 }
 
 // UpdatePoll godoc
 // @Summary Update a poll
+// @Description Fields present in the body are applied as given, zero values included: vote_rate_per_minute 0 removes the poll's override and restores the server default, and challenge_difficulty 0 stops requiring a challenge.
 // @Tags polls
 // @Accept json
 // @Produce json
 // @Param id path int true "Poll ID"
 // @Param payload body UpdatePollRequest true "Poll"
+// @Param If-Match header string false "ETag of the poll being edited"
 // @Success 200 {object} domain.Poll
+// @Header 200 {string} ETag "Poll version"
 // @Failure 400 {object} gin.H
+// @Failure 412 {object} gin.H
+// @Failure 428 {object} gin.H
 // @Router /polls/{id} [patch]
 func (h *Handler) UpdatePoll(c *gin.Context) {
     id, _ := strconv.Atoi(c.Param("id"))
+    version, ok := h.ifMatchVersion(c)
+    if !ok { return }
     var req UpdatePollRequest
     if err := c.ShouldBindJSON(&req); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
-    p := domain.Poll{ID: uint(id)}
-    if req.Title != nil { p.Title = *req.Title }
-    if req.Description != nil { p.Description = *req.Description }
-    if req.Threshold != nil { p.Threshold = *req.Threshold }
+    p := domain.PollPatch{ID: uint(id), Version: version, Title: req.Title, Description: req.Description, ImageURL: req.ImageURL, Threshold: req.Threshold, VoteRatePerMinute: req.VoteRatePerMinute, VoteBurst: req.VoteBurst, ChallengeDifficulty: req.ChallengeDifficulty}
+    if req.ThresholdBasis != nil { b := domain.ThresholdBasis(*req.ThresholdBasis); p.ThresholdBasis = &b }
     res, err := h.svc.UpdatePoll(c.Request.Context(), p)
+    if errors.Is(err, domain.ErrVersionConflict) { c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()}); return }
     if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.Header("ETag", pollETag(res.Version))
     c.JSON(http.StatusOK, res)
 }
 
 // DeletePoll godoc
-// @Summary Delete a poll
+// @Summary Move a poll to the trash
 // @Tags polls
 // @Param id path int true "Poll ID"
+// @Param If-Match header string false "ETag of the poll being deleted"
 // @Success 204
+// @Failure 412 {object} gin.H
+// @Failure 428 {object} gin.H
 // @Router /polls/{id} [delete]
 func (h *Handler) DeletePoll(c *gin.Context) {
     id, _ := strconv.Atoi(c.Param("id"))
-    if err := h.svc.DeletePoll(c.Request.Context(), uint(id)); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    version, ok := h.ifMatchVersion(c)
+    if !ok { return }
+    err := h.svc.DeletePoll(c.Request.Context(), uint(id), version)
+    if errors.Is(err, domain.ErrVersionConflict) { c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
     c.Status(http.StatusNoContent)
 }
 
+// RestorePoll godoc
+// @Summary Restore a poll from the trash
+// @Tags polls
+// @Produce json
+// @Param id path int true "Poll ID"
+// @Success 200 {object} domain.Poll
+// @Header 200 {string} ETag "Poll version"
+// @Failure 404 {object} gin.H
+// @Router /polls/{id}/restore [post]
+func (h *Handler) RestorePoll(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    res, err := h.svc.RestorePoll(c.Request.Context(), uint(id))
+    if errors.Is(err, domain.ErrPollNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.Header("ETag", pollETag(res.Version))
+    c.JSON(http.StatusOK, res)
+}
+
 // ClosePoll godoc
 // @Summary Close a poll
 // @Tags polls
 // @Param id path int true "Poll ID"
+// @Param If-Match header string false "ETag of the poll being closed"
 // @Success 200 {object} domain.Poll
+// @Failure 412 {object} gin.H
+// @Failure 428 {object} gin.H
 // @Router /polls/{id}/close [post]
 func (h *Handler) ClosePoll(c *gin.Context) {
     id, _ := strconv.Atoi(c.Param("id"))
-    res, err := h.svc.ClosePoll(c.Request.Context(), uint(id))
+    version, ok := h.ifMatchVersion(c)
+    if !ok { return }
+    res, err := h.svc.ClosePoll(c.Request.Context(), uint(id), version)
+    if errors.Is(err, domain.ErrVersionConflict) { c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()}); return }
     if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.Header("ETag", pollETag(res.Version))
     c.JSON(http.StatusOK, res)
 }
 
@@ -125,13 +203,21 @@ func (h *Handler) ClosePoll(c *gin.Context) {
 // @Produce json
 // @Param id path int true "Poll ID"
 // @Param payload body CreateOption true "Option"
+// @Param If-Match header string false "ETag of the poll being extended"
 // @Success 201 {object} domain.Option
+// @Failure 412 {object} gin.H
+// @Failure 428 {object} gin.H
 // @Router /polls/{id}/options [post]
 func (h *Handler) AddOption(c *gin.Context) {
     id, _ := strconv.Atoi(c.Param("id"))
+    version, ok := h.ifMatchVersion(c)
+    if !ok { return }
     var req CreateOption
     if err := c.ShouldBindJSON(&req); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
-    opt, err := h.svc.AddOption(c.Request.Context(), uint(id), req.Text)
+    in := optionFromRequest(req)
+    in.PollID = uint(id)
+    opt, err := h.svc.AddOption(c.Request.Context(), in, version)
+    if errors.Is(err, domain.ErrVersionConflict) { c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()}); return }
     if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
     c.JSON(http.StatusCreated, opt)
 }
@@ -150,6 +236,126 @@ func (h *Handler) ListOptions(c *gin.Context) {
     c.JSON(http.StatusOK, opts)
 }
 
+// UpdateOption godoc
+// @Summary Edit an option
+// @Description Fields present in the body are applied as given: an empty description, image_url or color, or null metadata, clears it.
+// @Tags options
+// @Accept json
+// @Produce json
+// @Param id path int true "Poll ID"
+// @Param optionId path int true "Option ID"
+// @Param payload body UpdateOptionRequest true "Option"
+// @Param If-Match header string false "ETag of the poll being edited"
+// @Success 200 {object} domain.Option
+// @Failure 400 {object} gin.H
+// @Failure 404 {object} gin.H
+// @Failure 412 {object} gin.H
+// @Failure 428 {object} gin.H
+// @Router /polls/{id}/options/{optionId} [patch]
+func (h *Handler) UpdateOption(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    optionID, _ := strconv.Atoi(c.Param("optionId"))
+    version, ok := h.ifMatchVersion(c)
+    if !ok { return }
+    var req UpdateOptionRequest
+    if err := c.ShouldBindJSON(&req); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    in := domain.OptionPatch{ID: uint(optionID), PollID: uint(id), Text: req.Text, Description: req.Description, ImageURL: req.ImageURL, Color: req.Color, Metadata: req.Metadata}
+    opt, err := h.svc.UpdateOption(c.Request.Context(), in, version)
+    if errors.Is(err, domain.ErrVersionConflict) { c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()}); return }
+    if errors.Is(err, domain.ErrOptionNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, opt)
+}
+
+// SetOptionImage godoc
+// @Summary Upload an option image
+// @Description Stores the image like POST /media (PNG, JPEG, GIF or WebP, sniffed from the content) and sets the option's image_url to it.
+// @Tags options
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Poll ID"
+// @Param optionId path int true "Option ID"
+// @Param image formData file true "Image"
+// @Param If-Match header string false "ETag of the poll being edited"
+// @Success 200 {object} domain.Option
+// @Failure 400 {object} gin.H
+// @Failure 404 {object} gin.H
+// @Failure 412 {object} gin.H
+// @Failure 413 {object} gin.H
+// @Failure 415 {object} gin.H
+// @Failure 428 {object} gin.H
+// @Router /polls/{id}/options/{optionId}/image [put]
+func (h *Handler) SetOptionImage(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    optionID, _ := strconv.Atoi(c.Param("optionId"))
+    version, ok := h.ifMatchVersion(c)
+    if !ok { return }
+    f, ok := formFile(c, "image")
+    if !ok { return }
+    defer f.Close()
+    opt, err := h.svc.SetOptionImage(c.Request.Context(), uint(id), uint(optionID), f, version)
+    if mediaError(c, err) { return }
+    if errors.Is(err, domain.ErrVersionConflict) { c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()}); return }
+    if errors.Is(err, domain.ErrOptionNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, opt)
+}
+
+// DeleteOption godoc
+// @Summary Delete an option
+// @Description policy decides what happens to the option's votes: reject (default) refuses while it has any, reassign moves them to reassign_to, discard deletes them.
+// @Tags options
+// @Param id path int true "Poll ID"
+// @Param optionId path int true "Option ID"
+// @Param policy query string false "reject, reassign or discard" Enums(reject, reassign, discard)
+// @Param reassign_to query int false "Option receiving the votes when policy=reassign"
+// @Param If-Match header string false "ETag of the poll being edited"
+// @Success 204
+// @Failure 400 {object} gin.H
+// @Failure 404 {object} gin.H
+// @Failure 409 {object} gin.H "Option has votes and policy is reject"
+// @Failure 412 {object} gin.H
+// @Failure 428 {object} gin.H
+// @Router /polls/{id}/options/{optionId} [delete]
+func (h *Handler) DeleteOption(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    optionID, _ := strconv.Atoi(c.Param("optionId"))
+    reassignTo, _ := strconv.Atoi(c.Query("reassign_to"))
+    version, ok := h.ifMatchVersion(c)
+    if !ok { return }
+    err := h.svc.DeleteOption(c.Request.Context(), uint(id), uint(optionID), domain.OptionDeletePolicy(c.Query("policy")), uint(reassignTo), version)
+    if errors.Is(err, domain.ErrVersionConflict) { c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()}); return }
+    if errors.Is(err, domain.ErrOptionHasVotes) { c.JSON(http.StatusConflict, gin.H{"error": err.Error()}); return }
+    if errors.Is(err, domain.ErrOptionNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.Status(http.StatusNoContent)
+}
+
+// ReorderOptions godoc
+// @Summary Reorder a poll's options
+// @Tags options
+// @Accept json
+// @Produce json
+// @Param id path int true "Poll ID"
+// @Param payload body ReorderOptionsRequest true "Every option ID of the poll, in the new order"
+// @Param If-Match header string false "ETag of the poll being edited"
+// @Success 200 {array} domain.Option
+// @Failure 400 {object} gin.H
+// @Failure 412 {object} gin.H
+// @Failure 428 {object} gin.H
+// @Router /polls/{id}/options/order [put]
+func (h *Handler) ReorderOptions(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    version, ok := h.ifMatchVersion(c)
+    if !ok { return }
+    var req ReorderOptionsRequest
+    if err := c.ShouldBindJSON(&req); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    opts, err := h.svc.ReorderOptions(c.Request.Context(), uint(id), req.OptionIDs, version)
+    if errors.Is(err, domain.ErrVersionConflict) { c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, opts)
+}
+
 // Vote godoc
 // @Summary Cast a vote
 // @Tags votes
@@ -157,49 +363,249 @@ func (h *Handler) ListOptions(c *gin.Context) {
 // @Produce json
 // @Param id path int true "Poll ID"
 // @Param payload body VoteRequest true "Vote"
-// @Success 201 {object} domain.Vote
+// @Param Idempotency-Key header string false "Replays the original response for retried requests"
+// @Success 201 {object} VoteResponse
+// @Success 202 {object} VoteResponse "Vote flagged for review"
+// @Failure 403 {object} gin.H "Missing or invalid proof-of-work solution, or the voter is not in a weighted poll's electorate"
+// @Success 200 {object} VoteResponse "Quadratic allocation withdrawn"
+// @Failure 409 {object} gin.H "Already voted, the quiz question is not open for answers, not enough credits left, or the poll's collection is not open"
+// @Failure 422 {object} gin.H "Option not in this poll, text sent to a choice poll (or missing for a text poll), or idempotency key reused with a different payload"
+// @Failure 429 {object} gin.H
 // @Router /polls/{id}/votes [post]
 func (h *Handler) Vote(c *gin.Context) {
     id, _ := strconv.Atoi(c.Param("id"))
     var req VoteRequest
     if err := c.ShouldBindJSON(&req); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
-    v, err := h.svc.Vote(c.Request.Context(), uint(id), req.OptionID, req.UserID)
+    in := domain.Vote{PollID: uint(id), OptionID: req.OptionID, Text: req.Text, UserID: req.UserID, Weight: 1, ClientIP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+    if req.Votes != nil { in.Weight = *req.Votes }
+    var proof *domain.ChallengeSolution
+    if req.Challenge != "" || req.Solution != "" { proof = &domain.ChallengeSolution{Token: req.Challenge, Solution: req.Solution} }
+    v, err := h.svc.Vote(c.Request.Context(), in, proof)
+    if errors.Is(err, domain.ErrChallengeRequired) || errors.Is(err, domain.ErrChallengeInvalid) || errors.Is(err, domain.ErrNotInElectorate) { c.JSON(http.StatusForbidden, gin.H{"error": err.Error()}); return }
+    if errors.Is(err, domain.ErrAlreadyVoted) || errors.Is(err, domain.ErrQuestionNotOpen) || errors.Is(err, domain.ErrInsufficientCredits) || errors.Is(err, domain.ErrCollectionNotOpen) { c.JSON(http.StatusConflict, gin.H{"error": err.Error()}); return }
+    if errors.Is(err, domain.ErrOptionNotFound) || errors.Is(err, domain.ErrInvalidVote) { c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    if v.Status == domain.VoteFlagged { c.JSON(http.StatusAccepted, newVoteResponse(*v)); return }
+    if v.ID == 0 && v.Weight == 0 { c.JSON(http.StatusOK, newVoteResponse(*v)); return } // withdrawn quadratic allocation
+    c.JSON(http.StatusCreated, newVoteResponse(*v))
+}
+
+// Challenge godoc
+// @Summary Issue a proof-of-work challenge
+// @Description Solve by finding a string s such that sha256(token + s) has Difficulty leading zero bits, then send token and s as challenge/solution with the vote.
+// @Tags votes
+// @Produce json
+// @Param id path int true "Poll ID"
+// @Success 200 {object} domain.Challenge
+// @Failure 400 {object} gin.H
+// @Router /polls/{id}/challenge [get]
+func (h *Handler) Challenge(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    ch, err := h.svc.Challenge(c.Request.Context(), uint(id))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.Header("Cache-Control", "no-store")
+    c.JSON(http.StatusOK, ch)
+}
+
+// Credits godoc
+// @Summary A voter's credits in a quadratic poll
+// @Description Budget, credits spent and remaining, and the votes allocated per option ID.
+// @Tags votes
+// @Produce json
+// @Param id path int true "Poll ID"
+// @Param user_id query string true "Voter"
+// @Success 200 {object} domain.CreditAccount
+// @Failure 400 {object} gin.H
+// @Failure 404 {object} gin.H
+// @Router /polls/{id}/credits [get]
+func (h *Handler) Credits(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    userID := c.Query("user_id")
+    if userID == "" { c.JSON(http.StatusBadRequest, gin.H{"error": "user_id required"}); return }
+    acc, err := h.svc.Credits(c.Request.Context(), uint(id), userID)
+    if errors.Is(err, domain.ErrPollNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
     if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
-    c.JSON(http.StatusCreated, v)
+    c.JSON(http.StatusOK, acc)
+}
+
+// ListVotes godoc
+// @Summary Per-voter breakdown of a poll
+// @Description Counted votes only: flagged votes and hidden text responses are left out. Refused with 403 for anonymous polls.
+// @Tags votes
+// @Produce json
+// @Param id path int true "Poll ID"
+// @Success 200 {array} VoteResponse
+// @Failure 403 {object} gin.H
+// @Router /polls/{id}/votes [get]
+func (h *Handler) ListVotes(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    vs, err := h.svc.ListVotes(c.Request.Context(), uint(id))
+    if errors.Is(err, domain.ErrAnonymousPoll) { c.JSON(http.StatusForbidden, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, newVoteResponses(vs))
+}
+
+// ExportVotes godoc
+// @Summary Export votes as CSV
+// @Description One row per counted vote, like GET /polls/{id}/votes; refused with 403 for anonymous polls.
+// @Tags votes
+// @Produce text/csv
+// @Param id path int true "Poll ID"
+// @Success 200 {string} string
+// @Failure 403 {object} gin.H
+// @Router /polls/{id}/export [get]
+func (h *Handler) ExportVotes(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    vs, err := h.svc.ListVotes(c.Request.Context(), uint(id))
+    if errors.Is(err, domain.ErrAnonymousPoll) { c.JSON(http.StatusForbidden, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.Header("Content-Type", "text/csv")
+    c.Header("Content-Disposition", "attachment; filename=poll-"+strconv.Itoa(id)+"-votes.csv")
+    w := csv.NewWriter(c.Writer)
+    _ = w.Write([]string{"vote_id", "option_id", "text", "user_id", "weight", "status", "created_at"})
+    for _, v := range vs {
+        _ = w.Write([]string{strconv.FormatUint(uint64(v.ID), 10), strconv.FormatUint(uint64(v.OptionID), 10), v.Text, v.UserID, strconv.Itoa(v.Weight), string(v.Status), v.CreatedAt.UTC().Format(time.RFC3339)})
+    }
+    w.Flush()
+}
+
+// ListFlaggedVotes godoc
+// @Summary List votes quarantined by fraud screening
+// @Description Each vote carries the reason it was flagged; client IPs and user agents stay in the database.
+// @Tags moderation
+// @Produce json
+// @Security AdminToken
+// @Param id path int true "Poll ID"
+// @Success 200 {array} VoteResponse
+// @Failure 401 {object} gin.H
+// @Router /polls/{id}/flagged-votes [get]
+func (h *Handler) ListFlaggedVotes(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    vs, err := h.svc.ListFlaggedVotes(c.Request.Context(), uint(id))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, newVoteResponses(vs))
+}
+
+// AcceptFlaggedVote godoc
+// @Summary Accept a flagged vote into the results
+// @Tags moderation
+// @Security AdminToken
+// @Param id path int true "Poll ID"
+// @Param voteId path int true "Vote ID"
+// @Success 204
+// @Failure 401 {object} gin.H
+// @Failure 404 {object} gin.H
+// @Router /polls/{id}/flagged-votes/{voteId}/accept [post]
+func (h *Handler) AcceptFlaggedVote(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    voteID, _ := strconv.Atoi(c.Param("voteId"))
+    err := h.svc.AcceptVote(c.Request.Context(), uint(id), uint(voteID))
+    if errors.Is(err, domain.ErrVoteNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.Status(http.StatusNoContent)
+}
+
+// PurgeFlaggedVote godoc
+// @Summary Permanently remove a flagged vote
+// @Tags moderation
+// @Security AdminToken
+// @Param id path int true "Poll ID"
+// @Param voteId path int true "Vote ID"
+// @Success 204
+// @Failure 401 {object} gin.H
+// @Failure 404 {object} gin.H
+// @Router /polls/{id}/flagged-votes/{voteId} [delete]
+func (h *Handler) PurgeFlaggedVote(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    voteID, _ := strconv.Atoi(c.Param("voteId"))
+    err := h.svc.PurgeVote(c.Request.Context(), uint(id), uint(voteID))
+    if errors.Is(err, domain.ErrVoteNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.Status(http.StatusNoContent)
+}
+
+// HideResponse godoc
+// @Summary Hide a text poll response from the results
+// @Description The response stays listed under /polls/{id}/votes with status hidden; its terms leave the word cloud.
+// @Tags moderation
+// @Param id path int true "Poll ID"
+// @Param voteId path int true "Vote ID"
+// @Success 204
+// @Failure 404 {object} gin.H
+// @Router /polls/{id}/responses/{voteId}/hide [post]
+func (h *Handler) HideResponse(c *gin.Context) { h.setResponseHidden(c, true) }
+
+// ShowResponse godoc
+// @Summary Show a hidden text poll response again
+// @Tags moderation
+// @Param id path int true "Poll ID"
+// @Param voteId path int true "Vote ID"
+// @Success 204
+// @Failure 404 {object} gin.H
+// @Router /polls/{id}/responses/{voteId}/show [post]
+func (h *Handler) ShowResponse(c *gin.Context) { h.setResponseHidden(c, false) }
+
+func (h *Handler) setResponseHidden(c *gin.Context, hidden bool) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    voteID, _ := strconv.Atoi(c.Param("voteId"))
+    err := h.svc.SetResponseHidden(c.Request.Context(), uint(id), uint(voteID), hidden)
+    if errors.Is(err, domain.ErrVoteNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.Status(http.StatusNoContent)
 }
 
 // Results godoc
 // @Summary Current poll results
+// @Description Text polls add the terms of their word cloud; nps and likert polls add their NPS or mean and top-box scores.
 // @Tags results
 // @Produce json
 // @Param id path int true "Poll ID"
+// @Param If-None-Match header string false "ETag of previously fetched results"
 // @Success 200 {object} domain.Results
+// @Success 304 "Results unchanged"
 // @Router /polls/{id}/results [get]
 func (h *Handler) Results(c *gin.Context) {
     id, _ := strconv.Atoi(c.Param("id"))
     res, err := h.svc.Results(c.Request.Context(), uint(id))
     if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    etag := resultsETag(res)
+    c.Header("ETag", etag)
+    if noneMatch(c, etag) { c.Status(http.StatusNotModified); return }
     c.JSON(http.StatusOK, res)
 }
 
 // ResultsStream godoc
 // @Summary Stream poll results via SSE
+// @Description Results arrive as "message" events. While people react, "reactions" events carry a domain.ReactionBurst every second, and one with empty counts once they stop.
 // @Tags results
 // @Produce text/event-stream
 // @Param id path int true "Poll ID"
 // @Router /polls/{id}/results/stream [get]
 func (h *Handler) ResultsStream(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    ch, cancel := h.stream.Subscribe(uint(id))
+    defer cancel()
+    var reactions <-chan domain.ReactionBurst
+    if h.reactions != nil {
+        var stop func()
+        reactions, stop = h.reactions.SubscribeReactions(uint(id))
+        defer stop()
+    }
+    res, err := h.svc.Results(c.Request.Context(), uint(id))
+    serveSSE(c, res, err == nil, ch, reactions)
+}
+
+// serveSSE sends first (if ok), then every value from ch, with keepalive comments in between, until
+// the client goes away. Bursts from reactions, if any, are sent as "reactions" events.
+func serveSSE[T any](c *gin.Context, first T, ok bool, ch <-chan T, reactions <-chan domain.ReactionBurst) {
     c.Writer.Header().Set("Content-Type", "text/event-stream")
     c.Writer.Header().Set("Cache-Control", "no-cache")
     c.Writer.Header().Set("Connection", "keep-alive")
     c.Writer.Header().Set("X-Accel-Buffering", "no")
-    id, _ := strconv.Atoi(c.Param("id"))
-    ch, cancel := h.stream.Subscribe(uint(id))
-    defer cancel()
 
     // send initial
-    if res, err := h.svc.Results(c.Request.Context(), uint(id)); err == nil {
-        sseWrite(c, res)
+    if ok {
+        sseWrite(c, first)
     }
 
     notify := c.Request.Context().Done()
@@ -211,8 +617,11 @@ func (h *Handler) ResultsStream(c *gin.Context) {
                 return
             case <-heartbeat:
                 return
-            case res := <-ch:
-                sseWrite(c, res)
+            case v := <-ch:
+                sseWrite(c, v)
+            case b := <-reactions:
+                c.SSEvent("reactions", b)
+                c.Writer.Flush()
             case <-timeAfter(15): // heartbeat every 15s
                 sseWriteComment(c, ":keepalive")
             }
@@ -233,3 +642,152 @@ func sseWriteComment(c *gin.Context, msg string) {
 
 // timeAfter split for testability without globals
 var timeAfter = func(seconds int) <-chan time.Time { return time.After(time.Duration(seconds) * time.Second) }
+
+// ReactionEmojis godoc
+// @Summary List the accepted reaction emojis
+// @Tags reactions
+// @Produce json
+// @Success 200 {object} ReactionEmojisResponse
+// @Router /reactions [get]
+func (h *Handler) ReactionEmojis(c *gin.Context) {
+    c.JSON(http.StatusOK, ReactionEmojisResponse{Emojis: h.svc.ReactionEmojis()})
+}
+
+// React godoc
+// @Summary React to a poll with an emoji
+// @Description Reactions are counted in memory over a rolling window, not stored, and reach viewers as bursts on the results stream.
+// @Tags reactions
+// @Accept json
+// @Param id path int true "Poll ID"
+// @Param payload body ReactionRequest true "Reaction"
+// @Success 202
+// @Failure 400 {object} gin.H
+// @Failure 404 {object} gin.H
+// @Failure 422 {object} gin.H "Emoji not in the configured set"
+// @Failure 429 {object} gin.H
+// @Router /polls/{id}/reactions [post]
+func (h *Handler) React(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    var req ReactionRequest
+    if err := c.ShouldBindJSON(&req); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    err := h.svc.React(c.Request.Context(), uint(id), req.Emoji)
+    if errors.Is(err, domain.ErrPollNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if errors.Is(err, domain.ErrUnsupportedReaction) { c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.Status(http.StatusAccepted)
+}
+
+// Reactions godoc
+// @Summary Current reaction rates of a poll
+// @Tags reactions
+// @Produce json
+// @Param id path int true "Poll ID"
+// @Success 200 {object} domain.ReactionBurst
+// @Failure 404 {object} gin.H
+// @Router /polls/{id}/reactions [get]
+func (h *Handler) Reactions(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    b, err := h.svc.Reactions(c.Request.Context(), uint(id))
+    if errors.Is(err, domain.ErrPollNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, b)
+}
+
+// CheckConsistency godoc
+// @Summary Report orphaned vote data
+// @Description Counts votes, ballots, participation records and options whose poll is gone, or whose option is missing or belongs to another poll.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} app.ConsistencyReport
+// @Failure 401 {object} gin.H
+// @Failure 503 {object} gin.H "ADMIN_TOKEN is not set"
+// @Router /admin/consistency [get]
+func (h *Handler) CheckConsistency(c *gin.Context) {
+    rep, err := h.svc.CheckConsistency(c.Request.Context(), false)
+    if err != nil { c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, rep)
+}
+
+// RepairConsistency godoc
+// @Summary Delete orphaned vote data
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} app.ConsistencyReport
+// @Failure 401 {object} gin.H
+// @Failure 503 {object} gin.H "ADMIN_TOKEN is not set"
+// @Router /admin/consistency/repair [post]
+func (h *Handler) RepairConsistency(c *gin.Context) {
+    rep, err := h.svc.CheckConsistency(c.Request.Context(), true)
+    if err != nil { c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, rep)
+}
+
+// UploadMedia godoc
+// @Summary Upload media
+// @Description Accepts PNG, JPEG, GIF, WebP and PDF, sniffed from the content. Files are stored under their SHA-256, so re-uploading the same bytes returns the existing media. Images get a thumbnail.
+// @Tags media
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "File"
+// @Success 201 {object} domain.Media
+// @Success 200 {object} domain.Media "Already stored"
+// @Failure 400 {object} gin.H
+// @Failure 413 {object} gin.H
+// @Failure 415 {object} gin.H
+// @Router /media [post]
+func (h *Handler) UploadMedia(c *gin.Context) {
+    f, ok := formFile(c, "file")
+    if !ok { return }
+    defer f.Close()
+    m, err := h.svc.UploadMedia(c.Request.Context(), f)
+    if mediaError(c, err) { return }
+    if err != nil { c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()}); return }
+    if m.Deduplicated { c.JSON(http.StatusOK, m); return }
+    c.JSON(http.StatusCreated, m)
+}
+
+// GetMedia godoc
+// @Summary Download uploaded media
+// @Tags media
+// @Param key path string true "Media key"
+// @Success 200 {file} binary
+// @Failure 404 {object} gin.H
+// @Router /media/{key} [get]
+func (h *Handler) GetMedia(c *gin.Context) {
+    rc, contentType, err := h.svc.OpenMedia(c.Request.Context(), strings.TrimPrefix(c.Param("key"), "/"))
+    if errors.Is(err, fs.ErrNotExist) { c.JSON(http.StatusNotFound, gin.H{"error": "media not found"}); return }
+    if err != nil { c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()}); return }
+    defer rc.Close()
+    c.Header("Cache-Control", "public, max-age=31536000, immutable") // keys are never reused
+    c.Header("X-Content-Type-Options", "nosniff")
+    c.DataFromReader(http.StatusOK, -1, contentType, rc, nil)
+}
+
+func optionFromRequest(o CreateOption) domain.Option {
+    return domain.Option{Text: o.Text, Description: o.Description, ImageURL: o.ImageURL, Color: o.Color, Metadata: o.Metadata}
+}
+
+// formFile opens an uploaded multipart file, answering 413 when the body limit was hit and 400
+// for other failures.
+func formFile(c *gin.Context, field string) (multipart.File, bool) {
+    var f multipart.File
+    fh, err := c.FormFile(field)
+    if err == nil { f, err = fh.Open() }
+    if err == nil { return f, true }
+    var tooLarge *http.MaxBytesError
+    if errors.As(err, &tooLarge) { c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()}); return nil, false }
+    c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    return nil, false
+}
+
+// mediaError writes 413/415 for rejected uploads and reports whether it did.
+func mediaError(c *gin.Context, err error) bool {
+    switch {
+    case errors.Is(err, domain.ErrMediaTooLarge): c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+    case errors.Is(err, domain.ErrUnsupportedMedia): c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+    default: return false
+    }
+    return true
+}
@@ -0,0 +1,129 @@
+package httpadp_test
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    httpadp "github.com/robjsliwa/pulse/adapters/http"
+    "github.com/robjsliwa/pulse/adapters/memory"
+    "github.com/robjsliwa/pulse/app"
+    "github.com/robjsliwa/pulse/domain"
+)
+
+type nopWebhooks struct{}
+
+func (nopWebhooks) Dispatch(context.Context, string, any) error { return nil }
+
+func newRouter(t *testing.T) (*gin.Engine, *app.Service) {
+    t.Helper()
+    gin.SetMode(gin.TestMode)
+    b := httpadp.NewBroadcaster()
+    svc := app.NewService(memory.NewRepo(), b, nopWebhooks{})
+    h := httpadp.NewHandler(svc, b)
+    r := gin.New()
+    r.POST("/polls/:id/votes", h.Vote)
+    r.GET("/polls/:id/votes", h.ListVotes)
+    r.POST("/polls/:id/options", h.AddOption)
+    r.PATCH("/polls/:id/options/:optionId", h.UpdateOption)
+    r.PATCH("/collections/:id", h.UpdateCollection)
+    return r, svc
+}
+
+func serveRequest(method, path, body string) *http.Request {
+    req := httptest.NewRequest(method, path, strings.NewReader(body))
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("User-Agent", "pulse-test/1.0")
+    return req
+}
+
+func record(r http.Handler, req *http.Request) *httptest.ResponseRecorder {
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    return w
+}
+
+func serve(r http.Handler, method, path, body string) *httptest.ResponseRecorder {
+    return record(r, serveRequest(method, path, body))
+}
+
+func TestVotesHideClientMetadata(t *testing.T) {
+    r, svc := newRouter(t)
+    created, err := svc.CreatePoll(context.Background(), domain.Poll{Title: "Ship it?", Options: []domain.Option{{Text: "yes"}, {Text: "no"}}})
+    if err != nil { t.Fatalf("create poll: %v", err) }
+    p, err := svc.GetPoll(context.Background(), created.ID)
+    if err != nil { t.Fatalf("get poll: %v", err) }
+    path := "/polls/" + strconv.Itoa(int(p.ID)) + "/votes"
+    w := serve(r, http.MethodPost, path, `{"option_id": `+strconv.Itoa(int(p.Options[0].ID))+`, "user_id": "u1"}`)
+    if w.Code != http.StatusCreated { t.Fatalf("vote: %d %s", w.Code, w.Body) }
+    for _, resp := range []*httptest.ResponseRecorder{w, serve(r, http.MethodGet, path, "")} {
+        body := resp.Body.String()
+        if !strings.Contains(body, `"UserID":"u1"`) { t.Fatalf("body lacks the vote: %s", body) }
+        if strings.Contains(body, "ClientIP") || strings.Contains(body, "UserAgent") || strings.Contains(body, "pulse-test") { t.Fatalf("body leaks client metadata: %s", body) }
+    }
+    vs, err := svc.ListVotes(context.Background(), p.ID)
+    if err != nil { t.Fatalf("list votes: %v", err) }
+    if len(vs) != 1 || vs[0].UserAgent != "pulse-test/1.0" { t.Fatalf("stored votes = %+v, want the user agent kept", vs) }
+}
+
+func TestOptionImageURLAcceptsMediaPaths(t *testing.T) {
+    r, svc := newRouter(t)
+    created, err := svc.CreatePoll(context.Background(), domain.Poll{Title: "Ship it?", Options: []domain.Option{{Text: "yes"}, {Text: "no"}}})
+    if err != nil { t.Fatalf("create poll: %v", err) }
+    path := "/polls/" + strconv.Itoa(int(created.ID)) + "/options"
+    w := serve(r, http.MethodPost, path, `{"text": "maybe", "image_url": "/media/sha256/ab/abc.png"}`)
+    if w.Code != http.StatusCreated { t.Fatalf("add option with a media path: %d %s", w.Code, w.Body) }
+    if w := serve(r, http.MethodPost, path, `{"text": "never", "image_url": "ftp://example.com/x.png"}`); w.Code != http.StatusBadRequest { t.Fatalf("add option with an ftp URL: %d", w.Code) }
+    p, err := svc.GetPoll(context.Background(), created.ID)
+    if err != nil { t.Fatalf("get poll: %v", err) }
+    opt := p.Options[2]
+    w = serve(r, http.MethodPatch, path+"/"+strconv.Itoa(int(opt.ID)), `{"image_url": "", "metadata": null}`)
+    if w.Code != http.StatusOK { t.Fatalf("clear image: %d %s", w.Code, w.Body) }
+    if strings.Contains(w.Body.String(), "/media/") { t.Fatalf("image kept: %s", w.Body) }
+}
+
+func TestUpdateCollectionClearsNullTimes(t *testing.T) {
+    r, svc := newRouter(t)
+    closes := time.Now().Add(time.Hour)
+    c, err := svc.CreateCollection(context.Background(), domain.Collection{Name: "talks", ClosesAt: &closes}, nil)
+    if err != nil { t.Fatalf("create collection: %v", err) }
+    path := "/collections/" + strconv.Itoa(int(c.ID))
+    if w := serve(r, http.MethodPatch, path, `{"name": "Talks"}`); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"ClosesAt":"`) { t.Fatalf("rename: %d %s", w.Code, w.Body) }
+    w := serve(r, http.MethodPatch, path, `{"closes_at": null}`)
+    if w.Code != http.StatusOK { t.Fatalf("clear closes_at: %d %s", w.Code, w.Body) }
+    if body := w.Body.String(); !strings.Contains(body, `"ClosesAt":null`) || !strings.Contains(body, `"Name":"Talks"`) { t.Fatalf("body = %s", body) }
+}
+
+type flagAll struct{}
+
+func (flagAll) Lookback() time.Duration { return time.Minute }
+func (flagAll) Screen(context.Context, domain.Vote, []domain.Vote) app.Screening {
+    return app.Screening{Flagged: true, Reasons: []string{"burst"}}
+}
+
+func TestFlaggedVotesNeedAdminAndHideClientMetadata(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    b := httpadp.NewBroadcaster()
+    svc := app.NewService(memory.NewRepo(), b, nopWebhooks{}, app.WithVoteScreener(flagAll{}))
+    h := httpadp.NewHandler(svc, b)
+    r := gin.New()
+    r.GET("/polls/:id/flagged-votes", httpadp.AdminAuth("s3cret"), h.ListFlaggedVotes)
+    created, err := svc.CreatePoll(context.Background(), domain.Poll{Title: "Ship it?", Options: []domain.Option{{Text: "yes"}, {Text: "no"}}})
+    if err != nil { t.Fatalf("create poll: %v", err) }
+    p, err := svc.GetPoll(context.Background(), created.ID)
+    if err != nil { t.Fatalf("get poll: %v", err) }
+    if _, err := svc.Vote(context.Background(), domain.Vote{PollID: p.ID, OptionID: p.Options[0].ID, UserID: "u1", ClientIP: "203.0.113.7", UserAgent: "pulse-test/1.0"}, nil); err != nil { t.Fatalf("vote: %v", err) }
+    path := "/polls/" + strconv.Itoa(int(p.ID)) + "/flagged-votes"
+    if w := serve(r, http.MethodGet, path, ""); w.Code != http.StatusUnauthorized { t.Fatalf("without a token: %d", w.Code) }
+    req := serveRequest(http.MethodGet, path, "")
+    req.Header.Set("Authorization", "Bearer s3cret")
+    w := record(r, req)
+    body := w.Body.String()
+    if w.Code != http.StatusOK || !strings.Contains(body, `"FlagReason":"burst"`) { t.Fatalf("flagged votes: %d %s", w.Code, body) }
+    if strings.Contains(body, "203.0.113.7") || strings.Contains(body, "pulse-test") { t.Fatalf("body leaks client metadata: %s", body) }
+}
@@ -0,0 +1,88 @@
+package httpadp
+
+import (
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "io"
+    "net/http"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/robjsliwa/pulse/internal/idempotency"
+)
+
+// IdempotencyKeyHeader lets clients retry POSTs safely.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Idempotent replays the stored response for a repeated Idempotency-Key instead of running the
+// handler again. Reusing a key with a different payload yields 422; a key whose first request is
+// still running yields 409. Only successes and client errors that a retry would repeat are stored;
+// any other outcome (rate limiting, a missing challenge, a conflict, a server error) releases the key
+// so the client can retry.
+func Idempotent(store idempotency.Store, ttl time.Duration) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        key := c.GetHeader(IdempotencyKeyHeader)
+        if key == "" { c.Next(); return }
+        if len(key) > 200 { c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "idempotency key too long"}); return }
+        body, err := io.ReadAll(c.Request.Body)
+        if err != nil { c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+        c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+        sum := sha256.Sum256(append([]byte(c.Request.Method+" "+c.Request.URL.Path+"\n"), body...))
+        rec := idempotency.Record{Key: c.Request.URL.Path + "|" + key, RequestHash: hex.EncodeToString(sum[:]), ExpiresAt: time.Now().Add(ttl)}
+        existing, reserved, err := store.Reserve(c.Request.Context(), rec)
+        if err != nil { c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()}); return }
+        if !reserved {
+            switch {
+            case existing.RequestHash != rec.RequestHash:
+                c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "idempotency key reused with a different payload"})
+            case !existing.Completed:
+                c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "request with this idempotency key is in progress"})
+            default:
+                c.Header("Idempotent-Replayed", "true")
+                c.Data(existing.Status, "application/json; charset=utf-8", existing.Body)
+                c.Abort()
+            }
+            return
+        }
+
+        w := &capturingWriter{ResponseWriter: c.Writer}
+        c.Writer = w
+        c.Next()
+        // detach from the request context so a client disconnect doesn't lose the record
+        ctx := context.WithoutCancel(c.Request.Context())
+        if !replayable(w.Status()) {
+            _ = store.Release(ctx, rec.Key)
+            return
+        }
+        _ = store.Complete(ctx, rec.Key, w.Status(), w.buf.Bytes())
+    }
+}
+
+// replayable reports whether a response is final for its request: a success, or a 4xx that
+// depends on the payload alone and would come back the same on retry.
+func replayable(status int) bool {
+    switch status {
+    case http.StatusBadRequest, http.StatusNotFound, http.StatusGone, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+        return true
+    }
+    return status < http.StatusBadRequest
+}
+
+// capturingWriter keeps a copy of the response body for replay.
+type capturingWriter struct {
+    gin.ResponseWriter
+    buf bytes.Buffer
+}
+
+func (w *capturingWriter) Write(b []byte) (int, error) {
+    w.buf.Write(b)
+    return w.ResponseWriter.Write(b)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+    w.buf.WriteString(s)
+    return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,42 @@
+package httpadp_test
+
+import (
+    "net/http"
+    "testing"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    httpadp "github.com/robjsliwa/pulse/adapters/http"
+    "github.com/robjsliwa/pulse/internal/idempotency"
+)
+
+func TestIdempotentStoresOnlyFinalResponses(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    for _, tc := range []struct {
+        status int
+        replay bool
+    }{
+        {http.StatusCreated, true},
+        {http.StatusUnprocessableEntity, true},
+        {http.StatusForbidden, false},
+        {http.StatusConflict, false},
+        {http.StatusTooManyRequests, false},
+        {http.StatusInternalServerError, false},
+    } {
+        calls := 0
+        r := gin.New()
+        r.POST("/polls", httpadp.Idempotent(idempotency.NewMemoryStore(), time.Hour), func(c *gin.Context) {
+            calls++
+            c.JSON(tc.status, gin.H{"call": calls})
+        })
+        for range 2 {
+            req := serveRequest(http.MethodPost, "/polls", `{"title":"x"}`)
+            req.Header.Set(httpadp.IdempotencyKeyHeader, "k1")
+            w := record(r, req)
+            if w.Code != tc.status { t.Fatalf("status %d: got %d", tc.status, w.Code) }
+        }
+        want := 2
+        if tc.replay { want = 1 }
+        if calls != want { t.Errorf("status %d: handler ran %d times, want %d", tc.status, calls, want) }
+    }
+}
@@ -0,0 +1,142 @@
+package httpadp
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// CreateQuiz godoc
+// @Summary Create a quiz
+// @Description Each question becomes a poll; participants answer by voting in it with a user_id. Correct options are hidden until the question is revealed.
+// @Tags quizzes
+// @Accept json
+// @Produce json
+// @Param payload body CreateQuizRequest true "Quiz"
+// @Param Idempotency-Key header string false "Replays the original response for retried requests"
+// @Success 201 {object} domain.Quiz
+// @Failure 400 {object} gin.H
+// @Router /quizzes [post]
+func (h *Handler) CreateQuiz(c *gin.Context) {
+    var req CreateQuizRequest
+    if err := c.ShouldBindJSON(&req); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    in := domain.Quiz{Title: req.Title, Description: req.Description}
+    for _, q := range req.Questions {
+        dq := domain.QuizQuestion{Prompt: q.Prompt, Points: q.Points, TimeLimitSeconds: q.TimeLimitSeconds}
+        for _, o := range q.Options {
+            opt := optionFromRequest(o.CreateOption)
+            opt.Correct = o.Correct
+            dq.Options = append(dq.Options, opt)
+        }
+        in.Questions = append(in.Questions, dq)
+    }
+    q, err := h.svc.CreateQuiz(c.Request.Context(), in)
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusCreated, q)
+}
+
+// ListQuizzes godoc
+// @Summary List quizzes
+// @Tags quizzes
+// @Produce json
+// @Param offset query int false "Offset"
+// @Param limit query int false "Limit"
+// @Success 200 {array} domain.Quiz
+// @Router /quizzes [get]
+func (h *Handler) ListQuizzes(c *gin.Context) {
+    offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+    limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+    res, err := h.svc.ListQuizzes(c.Request.Context(), offset, limit)
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, res)
+}
+
+// GetQuiz godoc
+// @Summary Get a quiz with its questions
+// @Tags quizzes
+// @Produce json
+// @Param id path int true "Quiz ID"
+// @Success 200 {object} domain.Quiz
+// @Failure 404 {object} gin.H
+// @Router /quizzes/{id} [get]
+func (h *Handler) GetQuiz(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    q, err := h.svc.GetQuiz(c.Request.Context(), uint(id))
+    if err != nil { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, q)
+}
+
+// OpenQuizQuestion godoc
+// @Summary Open a question for answers
+// @Description Starts the clock: answers are accepted until the question's time limit and score more the sooner they arrive.
+// @Tags quizzes
+// @Produce json
+// @Param id path int true "Quiz ID"
+// @Param questionId path int true "Question ID"
+// @Success 200 {object} domain.Quiz
+// @Failure 400 {object} gin.H
+// @Failure 404 {object} gin.H
+// @Router /quizzes/{id}/questions/{questionId}/open [post]
+func (h *Handler) OpenQuizQuestion(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    questionID, _ := strconv.Atoi(c.Param("questionId"))
+    q, err := h.svc.OpenQuestion(c.Request.Context(), uint(id), uint(questionID))
+    if errors.Is(err, domain.ErrQuizNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, q)
+}
+
+// RevealQuizQuestion godoc
+// @Summary Reveal a question's correct options
+// @Description Closes the question's poll, so no more answers are taken, and scores it on the leaderboard.
+// @Tags quizzes
+// @Produce json
+// @Param id path int true "Quiz ID"
+// @Param questionId path int true "Question ID"
+// @Success 200 {object} domain.Quiz
+// @Failure 404 {object} gin.H
+// @Router /quizzes/{id}/questions/{questionId}/reveal [post]
+func (h *Handler) RevealQuizQuestion(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    questionID, _ := strconv.Atoi(c.Param("questionId"))
+    q, err := h.svc.RevealQuestion(c.Request.Context(), uint(id), uint(questionID))
+    if errors.Is(err, domain.ErrQuizNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, q)
+}
+
+// Leaderboard godoc
+// @Summary Quiz leaderboard
+// @Description Scores count revealed questions only; ties on score are broken by the time taken for correct answers.
+// @Tags quizzes
+// @Produce json
+// @Param id path int true "Quiz ID"
+// @Success 200 {object} domain.Leaderboard
+// @Failure 404 {object} gin.H
+// @Router /quizzes/{id}/leaderboard [get]
+func (h *Handler) Leaderboard(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    lb, err := h.svc.Leaderboard(c.Request.Context(), uint(id))
+    if errors.Is(err, domain.ErrQuizNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, lb)
+}
+
+// LeaderboardStream godoc
+// @Summary Stream a quiz leaderboard via SSE
+// @Description Sends the leaderboard on connect and again after every answer and reveal.
+// @Tags quizzes
+// @Produce text/event-stream
+// @Param id path int true "Quiz ID"
+// @Router /quizzes/{id}/leaderboard/stream [get]
+func (h *Handler) LeaderboardStream(c *gin.Context) {
+    if h.leaderboards == nil { c.JSON(http.StatusNotImplemented, gin.H{"error": "leaderboard streaming is not configured"}); return }
+    id, _ := strconv.Atoi(c.Param("id"))
+    ch, cancel := h.leaderboards.SubscribeLeaderboard(uint(id))
+    defer cancel()
+    lb, err := h.svc.Leaderboard(c.Request.Context(), uint(id))
+    serveSSE(c, lb, err == nil, ch, nil)
+}
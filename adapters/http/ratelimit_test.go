@@ -0,0 +1,64 @@
+package httpadp_test
+
+import (
+    "context"
+    "net/http"
+    "strconv"
+    "testing"
+
+    "github.com/gin-gonic/gin"
+    httpadp "github.com/robjsliwa/pulse/adapters/http"
+    "github.com/robjsliwa/pulse/adapters/memory"
+    "github.com/robjsliwa/pulse/app"
+    "github.com/robjsliwa/pulse/domain"
+    "github.com/robjsliwa/pulse/internal/ratelimit"
+)
+
+// newLimitedRouter serves 204 for votes that pass VoteRateLimit with a default burst of 2.
+func newLimitedRouter(t *testing.T, trustedProxies []string) (*gin.Engine, *app.Service) {
+    t.Helper()
+    gin.SetMode(gin.TestMode)
+    b := httpadp.NewBroadcaster()
+    svc := app.NewService(memory.NewRepo(), b, nopWebhooks{})
+    h := httpadp.NewHandler(svc, b)
+    r := gin.New()
+    if err := r.SetTrustedProxies(trustedProxies); err != nil { t.Fatalf("trusted proxies: %v", err) }
+    l := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.PerMinute(1, 2))
+    r.POST("/polls/:id/votes", h.VoteRateLimit(l), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+    return r, svc
+}
+
+func voteFrom(r http.Handler, path, forwardedFor string) int {
+    req := serveRequest(http.MethodPost, path, `{}`)
+    if forwardedFor != "" { req.Header.Set("X-Forwarded-For", forwardedFor) }
+    return record(r, req).Code
+}
+
+func TestVoteRateLimitIgnoresForgedForwardedFor(t *testing.T) {
+    r, _ := newLimitedRouter(t, nil)
+    for i, want := range []int{http.StatusNoContent, http.StatusNoContent, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+        if got := voteFrom(r, "/polls/1/votes", "203.0.113."+strconv.Itoa(i)); got != want { t.Fatalf("request %d with a fresh X-Forwarded-For: %d, want %d", i, got, want) }
+    }
+    w := record(r, serveRequest(http.MethodPost, "/polls/1/votes", `{}`))
+    if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" { t.Fatalf("limited response: %d, Retry-After %q", w.Code, w.Header().Get("Retry-After")) }
+}
+
+func TestVoteRateLimitBelievesTrustedProxy(t *testing.T) {
+    // httptest requests come from 192.0.2.1
+    r, _ := newLimitedRouter(t, []string{"192.0.2.0/24"})
+    for i := range 3 {
+        if got := voteFrom(r, "/polls/1/votes", "203.0.113."+strconv.Itoa(i)); got != http.StatusNoContent { t.Fatalf("client %d behind the proxy: %d", i, got) }
+    }
+    voteFrom(r, "/polls/1/votes", "203.0.113.0")
+    if got := voteFrom(r, "/polls/1/votes", "203.0.113.0"); got != http.StatusTooManyRequests { t.Fatalf("third vote of one client: %d, want 429", got) }
+}
+
+func TestVoteRateLimitUsesPollOverride(t *testing.T) {
+    r, svc := newLimitedRouter(t, nil)
+    p, err := svc.CreatePoll(context.Background(), domain.Poll{Title: "Live?", VoteRatePerMinute: 60, VoteBurst: 1, Options: []domain.Option{{Text: "yes"}, {Text: "no"}}})
+    if err != nil { t.Fatalf("create poll: %v", err) }
+    path := "/polls/" + strconv.Itoa(int(p.ID)) + "/votes"
+    if got := voteFrom(r, path, ""); got != http.StatusNoContent { t.Fatalf("first vote: %d", got) }
+    if got := voteFrom(r, path, ""); got != http.StatusTooManyRequests { t.Fatalf("second vote with a burst of 1: %d, want 429", got) }
+    if got := voteFrom(r, "/polls/999/votes", ""); got != http.StatusNoContent { t.Fatalf("other poll shares the bucket: %d", got) }
+}
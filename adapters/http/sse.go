@@ -6,39 +6,72 @@ import (
     "github.com/robjsliwa/pulse/domain"
 )
 
-// Broadcaster is a simple in-memory results broadcaster.
+// Broadcaster is a simple in-memory broadcaster of poll results, reaction bursts, quiz leaderboards
+// and collection updates.
 type Broadcaster struct {
-    mu      sync.RWMutex
-    subs    map[uint]map[chan domain.Results]struct{}
+    results      *hub[domain.Results]
+    reactions    *hub[domain.ReactionBurst]
+    leaderboards *hub[domain.Leaderboard]
+    collections  *hub[domain.CollectionUpdate]
+}
+
+func NewBroadcaster() *Broadcaster {
+    return &Broadcaster{results: newHub[domain.Results](), reactions: newHub[domain.ReactionBurst](), leaderboards: newHub[domain.Leaderboard](), collections: newHub[domain.CollectionUpdate]()}
 }
 
-func NewBroadcaster() *Broadcaster { return &Broadcaster{subs: make(map[uint]map[chan domain.Results]struct{})} }
+var (
+    _ app.ResultsStreamer     = (*Broadcaster)(nil)
+    _ app.ReactionStreamer    = (*Broadcaster)(nil)
+    _ app.LeaderboardStreamer = (*Broadcaster)(nil)
+    _ app.CollectionStreamer  = (*Broadcaster)(nil)
+)
+
+func (b *Broadcaster) Broadcast(pollID uint, res domain.Results) { b.results.broadcast(pollID, res) }
+
+func (b *Broadcaster) Subscribe(pollID uint) (<-chan domain.Results, func()) { return b.results.subscribe(pollID) }
+
+func (b *Broadcaster) BroadcastReactions(pollID uint, burst domain.ReactionBurst) { b.reactions.broadcast(pollID, burst) }
+
+func (b *Broadcaster) SubscribeReactions(pollID uint) (<-chan domain.ReactionBurst, func()) { return b.reactions.subscribe(pollID) }
 
-var _ app.ResultsStreamer = (*Broadcaster)(nil)
+func (b *Broadcaster) BroadcastLeaderboard(quizID uint, lb domain.Leaderboard) { b.leaderboards.broadcast(quizID, lb) }
 
-func (b *Broadcaster) Broadcast(pollID uint, res domain.Results) {
-    b.mu.RLock()
-    defer b.mu.RUnlock()
-    for ch := range b.subs[pollID] {
-        select { case ch <- res: default: }
+func (b *Broadcaster) SubscribeLeaderboard(quizID uint) (<-chan domain.Leaderboard, func()) { return b.leaderboards.subscribe(quizID) }
+
+func (b *Broadcaster) BroadcastCollection(collectionID uint, u domain.CollectionUpdate) { b.collections.broadcast(collectionID, u) }
+
+func (b *Broadcaster) SubscribeCollection(collectionID uint) (<-chan domain.CollectionUpdate, func()) { return b.collections.subscribe(collectionID) }
+
+// hub fans values out to the subscribers of a key; a subscriber whose buffer is full misses the value.
+type hub[T any] struct {
+    mu      sync.RWMutex
+    subs    map[uint]map[chan T]struct{}
+}
+
+func newHub[T any]() *hub[T] { return &hub[T]{subs: make(map[uint]map[chan T]struct{})} }
+
+func (h *hub[T]) broadcast(key uint, v T) {
+    h.mu.RLock()
+    defer h.mu.RUnlock()
+    for ch := range h.subs[key] {
+        select { case ch <- v: default: }
     }
 }
 
-func (b *Broadcaster) Subscribe(pollID uint) (<-chan domain.Results, func()) {
-    ch := make(chan domain.Results, 8)
-    b.mu.Lock()
-    if _, ok := b.subs[pollID]; !ok { b.subs[pollID] = make(map[chan domain.Results]struct{}) }
-    b.subs[pollID][ch] = struct{}{}
-    b.mu.Unlock()
+func (h *hub[T]) subscribe(key uint) (<-chan T, func()) {
+    ch := make(chan T, 8)
+    h.mu.Lock()
+    if _, ok := h.subs[key]; !ok { h.subs[key] = make(map[chan T]struct{}) }
+    h.subs[key][ch] = struct{}{}
+    h.mu.Unlock()
     cancel := func() {
-        b.mu.Lock()
-        if m, ok := b.subs[pollID]; ok {
+        h.mu.Lock()
+        if m, ok := h.subs[key]; ok {
             delete(m, ch)
-            if len(m) == 0 { delete(b.subs, pollID) }
+            if len(m) == 0 { delete(h.subs, key) }
         }
-        b.mu.Unlock()
+        h.mu.Unlock()
         close(ch)
     }
     return ch, cancel
 }
-
@@ -0,0 +1,127 @@
+package httpadp
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// CreateSurvey godoc
+// @Summary Create a survey
+// @Description Each choice question is backed by a poll holding its options; text questions take free-text answers.
+// @Tags surveys
+// @Accept json
+// @Produce json
+// @Param payload body CreateSurveyRequest true "Survey"
+// @Param Idempotency-Key header string false "Replays the original response for retried requests"
+// @Success 201 {object} domain.Survey
+// @Failure 400 {object} gin.H
+// @Router /surveys [post]
+func (h *Handler) CreateSurvey(c *gin.Context) {
+    var req CreateSurveyRequest
+    if err := c.ShouldBindJSON(&req); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    in := domain.Survey{Title: req.Title, Description: req.Description}
+    for _, q := range req.Questions {
+        dq := domain.Question{Kind: domain.QuestionKind(q.Kind), Prompt: q.Prompt, Required: q.Required}
+        for _, o := range q.Options { dq.Options = append(dq.Options, optionFromRequest(o)) }
+        in.Questions = append(in.Questions, dq)
+    }
+    sv, err := h.svc.CreateSurvey(c.Request.Context(), in)
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusCreated, sv)
+}
+
+// ListSurveys godoc
+// @Summary List surveys
+// @Tags surveys
+// @Produce json
+// @Param offset query int false "Offset"
+// @Param limit query int false "Limit"
+// @Success 200 {array} domain.Survey
+// @Router /surveys [get]
+func (h *Handler) ListSurveys(c *gin.Context) {
+    offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+    limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+    res, err := h.svc.ListSurveys(c.Request.Context(), offset, limit)
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, res)
+}
+
+// GetSurvey godoc
+// @Summary Get a survey with its questions
+// @Tags surveys
+// @Produce json
+// @Param id path int true "Survey ID"
+// @Success 200 {object} domain.Survey
+// @Failure 404 {object} gin.H
+// @Router /surveys/{id} [get]
+func (h *Handler) GetSurvey(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    sv, err := h.svc.GetSurvey(c.Request.Context(), uint(id))
+    if err != nil { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, sv)
+}
+
+// CloseSurvey godoc
+// @Summary Close a survey and the polls behind its questions
+// @Tags surveys
+// @Produce json
+// @Param id path int true "Survey ID"
+// @Success 200 {object} domain.Survey
+// @Failure 404 {object} gin.H
+// @Router /surveys/{id}/close [post]
+func (h *Handler) CloseSurvey(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    sv, err := h.svc.CloseSurvey(c.Request.Context(), uint(id))
+    if errors.Is(err, domain.ErrSurveyNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, sv)
+}
+
+// SubmitSurvey godoc
+// @Summary Submit answers to a survey
+// @Description The submission is stored as a whole or not at all. Choice answers count as votes in the question's poll.
+// @Tags surveys
+// @Accept json
+// @Produce json
+// @Param id path int true "Survey ID"
+// @Param payload body SubmitSurveyRequest true "Answers"
+// @Param Idempotency-Key header string false "Replays the original response for retried requests"
+// @Success 201 {object} domain.SurveySubmission
+// @Failure 400 {object} gin.H
+// @Failure 404 {object} gin.H
+// @Failure 409 {object} gin.H "user_id already submitted this survey"
+// @Failure 422 {object} gin.H "Required question unanswered, or an answer does not fit its question"
+// @Router /surveys/{id}/submissions [post]
+func (h *Handler) SubmitSurvey(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    var req SubmitSurveyRequest
+    if err := c.ShouldBindJSON(&req); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    in := domain.SurveySubmission{SurveyID: uint(id), UserID: req.UserID}
+    for _, a := range req.Answers { in.Answers = append(in.Answers, domain.SurveyAnswer{QuestionID: a.QuestionID, OptionID: a.OptionID, Text: a.Text}) }
+    sub, err := h.svc.SubmitSurvey(c.Request.Context(), in)
+    if errors.Is(err, domain.ErrSurveyNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if errors.Is(err, domain.ErrAlreadySubmitted) { c.JSON(http.StatusConflict, gin.H{"error": err.Error()}); return }
+    if errors.Is(err, domain.ErrInvalidSubmission) { c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusCreated, sub)
+}
+
+// SurveyResults godoc
+// @Summary Survey results and completion rates
+// @Tags surveys
+// @Produce json
+// @Param id path int true "Survey ID"
+// @Success 200 {object} domain.SurveyResults
+// @Failure 404 {object} gin.H
+// @Router /surveys/{id}/results [get]
+func (h *Handler) SurveyResults(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    res, err := h.svc.SurveyResults(c.Request.Context(), uint(id))
+    if errors.Is(err, domain.ErrSurveyNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, res)
+}
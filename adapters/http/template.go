@@ -0,0 +1,171 @@
+package httpadp
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+    "github.com/robjsliwa/pulse/app"
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// ClonePoll godoc
+// @Summary Clone a poll
+// @Description Creates an open poll with the source poll's title, description, settings and options, without its votes. A weighted poll's clone gets the same electorate. Survey and quiz polls cannot be cloned.
+// @Tags polls
+// @Produce json
+// @Param id path int true "Poll ID"
+// @Param Idempotency-Key header string false "Replays the original response for retried requests"
+// @Success 201 {object} domain.Poll
+// @Header 201 {string} ETag "Poll version"
+// @Failure 400 {object} gin.H
+// @Failure 404 {object} gin.H
+// @Router /polls/{id}/clone [post]
+func (h *Handler) ClonePoll(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    p, err := h.svc.ClonePoll(c.Request.Context(), uint(id))
+    if errors.Is(err, domain.ErrPollNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.Header("ETag", pollETag(p.Version))
+    c.JSON(http.StatusCreated, p)
+}
+
+// CreateTemplate godoc
+// @Summary Create a poll template
+// @Description Every custom variable the poll uses needs a default in variables. With a schedule, a poll is created each time the cron expression fires (UTC unless prefixed with TZ=zone).
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param payload body CreateTemplateRequest true "Template"
+// @Param Idempotency-Key header string false "Replays the original response for retried requests"
+// @Success 201 {object} domain.PollTemplate
+// @Failure 400 {object} gin.H
+// @Router /templates [post]
+func (h *Handler) CreateTemplate(c *gin.Context) {
+    var req CreateTemplateRequest
+    if err := c.ShouldBindJSON(&req); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    pr := req.Poll
+    p := domain.Poll{Title: pr.Title, Description: pr.Description, ImageURL: pr.ImageURL, Kind: domain.PollKind(pr.Kind), Language: pr.Language, Scale: pr.Scale, Threshold: pr.Threshold, ThresholdBasis: domain.ThresholdBasis(pr.ThresholdBasis), Weighted: pr.Weighted, CreditBudget: pr.CreditBudget, Anonymous: pr.Anonymous, VoteRatePerMinute: pr.VoteRatePerMinute, VoteBurst: pr.VoteBurst, ChallengeDifficulty: pr.ChallengeDifficulty}
+    for _, o := range pr.Options { p.Options = append(p.Options, optionFromRequest(o)) }
+    t, err := h.svc.CreateTemplate(c.Request.Context(), domain.PollTemplate{Name: req.Name, Poll: p, Variables: req.Variables, Schedule: req.Schedule, Series: req.Series})
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusCreated, t)
+}
+
+// ListTemplates godoc
+// @Summary List poll templates
+// @Tags templates
+// @Produce json
+// @Param offset query int false "Offset"
+// @Param limit query int false "Limit"
+// @Success 200 {array} domain.PollTemplate
+// @Router /templates [get]
+func (h *Handler) ListTemplates(c *gin.Context) {
+    offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+    limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+    res, err := h.svc.ListTemplates(c.Request.Context(), offset, limit)
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, res)
+}
+
+// GetTemplate godoc
+// @Summary Get a poll template
+// @Tags templates
+// @Produce json
+// @Param id path int true "Template ID"
+// @Success 200 {object} domain.PollTemplate
+// @Failure 404 {object} gin.H
+// @Router /templates/{id} [get]
+func (h *Handler) GetTemplate(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    t, err := h.svc.GetTemplate(c.Request.Context(), uint(id))
+    if err != nil { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, t)
+}
+
+// DeleteTemplate godoc
+// @Summary Delete a poll template
+// @Description Polls already created from the template are kept.
+// @Tags templates
+// @Param id path int true "Template ID"
+// @Success 204
+// @Failure 404 {object} gin.H
+// @Router /templates/{id} [delete]
+func (h *Handler) DeleteTemplate(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    err := h.svc.DeleteTemplate(c.Request.Context(), uint(id))
+    if errors.Is(err, domain.ErrTemplateNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()}); return }
+    c.Status(http.StatusNoContent)
+}
+
+// InstantiateTemplate godoc
+// @Summary Create a poll from a template
+// @Description Variables given here override the template's. A series template's poll becomes the series' latest instance and closes the open ones before it.
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param payload body InstantiateTemplateRequest false "Variables"
+// @Param Idempotency-Key header string false "Replays the original response for retried requests"
+// @Success 201 {object} domain.Poll
+// @Header 201 {string} ETag "Poll version"
+// @Failure 400 {object} gin.H
+// @Failure 404 {object} gin.H
+// @Router /templates/{id}/instantiate [post]
+func (h *Handler) InstantiateTemplate(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    var req InstantiateTemplateRequest
+    if c.Request.ContentLength != 0 {
+        if err := c.ShouldBindJSON(&req); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    }
+    p, err := h.svc.InstantiateTemplate(c.Request.Context(), uint(id), req.Variables)
+    if errors.Is(err, domain.ErrTemplateNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if p == nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    // the poll exists; a previous series instance that failed to close is not the caller's problem
+    if err != nil { _ = c.Error(err) }
+    c.Header("ETag", pollETag(p.Version))
+    c.JSON(http.StatusCreated, p)
+}
+
+// SetRecurrence godoc
+// @Summary Make a poll recur
+// @Description Turns the poll into the first instance of a series: a series template with its settings and options creates the next instance whenever the schedule fires and closes the previous one. Delete the returned template to end the series.
+// @Tags series
+// @Accept json
+// @Produce json
+// @Param id path int true "Poll ID"
+// @Param payload body RecurrenceRequest true "Schedule"
+// @Success 201 {object} domain.PollTemplate
+// @Failure 400 {object} gin.H
+// @Failure 404 {object} gin.H
+// @Router /polls/{id}/recurrence [put]
+func (h *Handler) SetRecurrence(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    var req RecurrenceRequest
+    if err := c.ShouldBindJSON(&req); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    t, err := h.svc.SetRecurrence(c.Request.Context(), uint(id), req.Schedule)
+    if errors.Is(err, domain.ErrPollNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusCreated, t)
+}
+
+// SeriesResults godoc
+// @Summary Compare the instances of a series
+// @Description The series ID is the ID of its template. Reports each instance's results, oldest first, and per-option trends (votes and shares per instance, matched by option text). NPS and Likert instances carry their score or mean.
+// @Tags series
+// @Produce json
+// @Param id path int true "Series ID"
+// @Param limit query int false "Latest instances to compare (default 20, 0 for all)"
+// @Success 200 {object} domain.SeriesResults
+// @Failure 404 {object} gin.H
+// @Router /series/{id}/results [get]
+func (h *Handler) SeriesResults(c *gin.Context) {
+    id, _ := strconv.Atoi(c.Param("id"))
+    limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(app.DefaultSeriesInstances)))
+    res, err := h.svc.SeriesResults(c.Request.Context(), uint(id), limit)
+    if errors.Is(err, domain.ErrSeriesNotFound) { c.JSON(http.StatusNotFound, gin.H{"error": err.Error()}); return }
+    if err != nil { c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()}); return }
+    c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,97 @@
+package memory
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "time"
+
+    "github.com/robjsliwa/pulse/domain"
+)
+
+func (r *Repo) CreateCollection(_ context.Context, c *domain.Collection) error {
+    defer r.lock()()
+    st := *r.st
+    now := r.now()
+    c.ID, c.CreatedAt, c.UpdatedAt, c.PollIDs = st.id(), now, now, nil
+    st.collections[c.ID] = *c
+    return nil
+}
+
+func (r *Repo) GetCollection(_ context.Context, id uint) (*domain.Collection, error) {
+    defer r.lock()()
+    st := *r.st
+    c, ok := st.collections[id]
+    if !ok { return nil, fmt.Errorf("get collection: %w", domain.ErrCollectionNotFound) }
+    c.PollIDs = st.collectionPolls(id)
+    return &c, nil
+}
+
+func (r *Repo) ListCollections(_ context.Context, offset, limit int) ([]domain.Collection, error) {
+    defer r.lock()()
+    st := *r.st
+    out := make([]domain.Collection, 0, len(st.collections))
+    for id, c := range st.collections {
+        c.PollIDs = st.collectionPolls(id)
+        out = append(out, c)
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+    return page(out, offset, limit), nil
+}
+
+func (r *Repo) UpdateCollection(_ context.Context, c *domain.Collection) error {
+    defer r.lock()()
+    st := *r.st
+    row, ok := st.collections[c.ID]
+    if !ok { return domain.ErrCollectionNotFound }
+    row.Name, row.Description, row.OpensAt, row.ClosesAt, row.Access, row.WebhookScope = c.Name, c.Description, c.OpensAt, c.ClosesAt, c.Access, c.WebhookScope
+    row.UpdatedAt = r.now()
+    st.collections[c.ID] = row
+    return nil
+}
+
+func (r *Repo) SetCollectionStatus(_ context.Context, id uint, from, to domain.CollectionStatus) (bool, error) {
+    defer r.lock()()
+    st := *r.st
+    c, ok := st.collections[id]
+    if !ok || c.Status != from { return false, nil }
+    c.Status, c.UpdatedAt = to, r.now()
+    st.collections[id] = c
+    return true, nil
+}
+
+func (r *Repo) DueCollections(_ context.Context, now time.Time) ([]domain.Collection, error) {
+    defer r.lock()()
+    st := *r.st
+    var out []domain.Collection
+    for id, c := range st.collections {
+        opening := c.Status == domain.CollectionScheduled && c.OpensAt != nil && !c.OpensAt.After(now)
+        closing := c.Status == domain.CollectionOpen && c.ClosesAt != nil && !c.ClosesAt.After(now)
+        if !opening && !closing { continue }
+        c.PollIDs = st.collectionPolls(id)
+        out = append(out, c)
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+    return out, nil
+}
+
+func (r *Repo) DeleteCollection(_ context.Context, id uint) error {
+    defer r.lock()()
+    st := *r.st
+    if _, ok := st.collections[id]; !ok { return domain.ErrCollectionNotFound }
+    for pid, p := range st.polls {
+        if p.CollectionID == id { p.CollectionID, p.ClosedByCollection = 0, false; st.polls[pid] = p }
+    }
+    delete(st.collections, id)
+    return nil
+}
+
+// collectionPolls returns the IDs of a collection's live polls, ascending.
+func (s *state) collectionPolls(id uint) []uint {
+    var ids []uint
+    for pid, p := range s.polls {
+        if p.CollectionID == id && p.DeletedAt == nil { ids = append(ids, pid) }
+    }
+    sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+    return ids
+}
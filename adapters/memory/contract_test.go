@@ -0,0 +1,13 @@
+package memory_test
+
+import (
+    "testing"
+
+    "github.com/robjsliwa/pulse/adapters/memory"
+    "github.com/robjsliwa/pulse/app"
+    "github.com/robjsliwa/pulse/app/repotest"
+)
+
+func TestContract(t *testing.T) {
+    repotest.Run(t, func(t *testing.T) app.PollRepository { return memory.NewRepo() })
+}
@@ -0,0 +1,36 @@
+package memory
+
+import (
+    "context"
+    "fmt"
+    "sort"
+
+    "github.com/robjsliwa/pulse/domain"
+)
+
+func (r *Repo) SetElectorate(_ context.Context, pollID uint, voters []domain.Voter) error {
+    defer r.lock()()
+    st := *r.st
+    if len(voters) == 0 { delete(st.electorates, pollID); return nil }
+    m := make(map[string]int, len(voters))
+    for _, v := range voters { m[v.UserID] = v.Weight }
+    st.electorates[pollID] = m
+    return nil
+}
+
+func (r *Repo) ListElectorate(_ context.Context, pollID uint) ([]domain.Voter, error) {
+    defer r.lock()()
+    st := *r.st
+    out := make([]domain.Voter, 0, len(st.electorates[pollID]))
+    for u, w := range st.electorates[pollID] { out = append(out, domain.Voter{PollID: pollID, UserID: u, Weight: w}) }
+    sort.Slice(out, func(i, j int) bool { return out[i].UserID < out[j].UserID })
+    return out, nil
+}
+
+func (r *Repo) GetVoter(_ context.Context, pollID uint, userID string) (*domain.Voter, error) {
+    defer r.lock()()
+    st := *r.st
+    w, ok := st.electorates[pollID][userID]
+    if !ok { return nil, fmt.Errorf("get voter: %w", domain.ErrNotInElectorate) }
+    return &domain.Voter{PollID: pollID, UserID: userID, Weight: w}, nil
+}
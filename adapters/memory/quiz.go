@@ -0,0 +1,78 @@
+package memory
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "time"
+
+    "github.com/robjsliwa/pulse/domain"
+)
+
+func (r *Repo) CreateQuiz(_ context.Context, q *domain.Quiz) error {
+    defer r.lock()()
+    st := *r.st
+    now := r.now()
+    q.ID, q.CreatedAt, q.UpdatedAt = st.id(), now, now
+    for i := range q.Questions {
+        qq := &q.Questions[i]
+        p := &domain.Poll{Kind: domain.PollChoice, Title: qq.Prompt, Status: domain.PollOpen, QuizID: q.ID, Options: qq.Options}
+        st.createPoll(p, now)
+        qq.ID, qq.QuizID, qq.Position, qq.PollID = st.id(), q.ID, i, p.ID
+    }
+    row := *q
+    row.Questions = make([]domain.QuizQuestion, len(q.Questions))
+    for i, qq := range q.Questions {
+        qq.Prompt, qq.Options = "", nil
+        row.Questions[i] = qq
+    }
+    st.quizzes[q.ID] = row
+    return nil
+}
+
+func (r *Repo) GetQuiz(_ context.Context, id uint) (*domain.Quiz, error) {
+    defer r.lock()()
+    q, ok := (*r.st).quiz(id)
+    if !ok { return nil, fmt.Errorf("get quiz: %w", domain.ErrQuizNotFound) }
+    return &q, nil
+}
+
+func (r *Repo) ListQuizzes(_ context.Context, offset, limit int) ([]domain.Quiz, error) {
+    defer r.lock()()
+    st := *r.st
+    out := make([]domain.Quiz, 0, len(st.quizzes))
+    for id := range st.quizzes {
+        q, _ := st.quiz(id)
+        out = append(out, q)
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+    return page(out, offset, limit), nil
+}
+
+func (r *Repo) OpenQuizQuestion(_ context.Context, quizID, questionID uint, at time.Time) error {
+    defer r.lock()()
+    st := *r.st
+    q, ok := st.quizzes[quizID]
+    if !ok { return domain.ErrQuizNotFound }
+    q.Questions = append([]domain.QuizQuestion(nil), q.Questions...)
+    for i := range q.Questions {
+        if q.Questions[i].ID != questionID { continue }
+        q.Questions[i].OpenedAt = &at
+        st.quizzes[quizID] = q
+        return nil
+    }
+    return domain.ErrQuizNotFound
+}
+
+// quiz returns a copy of a quiz whose questions carry their poll's title, status and options; the caller holds the lock.
+func (s *state) quiz(id uint) (domain.Quiz, bool) {
+    q, ok := s.quizzes[id]
+    if !ok { return domain.Quiz{}, false }
+    q.Questions = append([]domain.QuizQuestion(nil), q.Questions...)
+    for i, qq := range q.Questions {
+        p := s.polls[qq.PollID]
+        q.Questions[i].Prompt, q.Questions[i].Revealed = p.Title, p.Status == domain.PollClosed
+        q.Questions[i].Options = s.pollOptions(qq.PollID)
+    }
+    return q, true
+}
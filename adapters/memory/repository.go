@@ -0,0 +1,514 @@
+// Package memory is an in-process app.PollRepository for unit tests and demos. It satisfies the
+// same contract as the GORM repository (see app/repotest) but keeps nothing across restarts.
+package memory
+
+import (
+    "context"
+    "fmt"
+    "slices"
+    "sort"
+    "sync"
+    "time"
+
+    "github.com/robjsliwa/pulse/app"
+    "github.com/robjsliwa/pulse/domain"
+)
+
+type ballot struct {
+    pollID   uint
+    optionID uint
+}
+
+type state struct {
+    polls         map[uint]domain.Poll // Options are kept in options, not here
+    options       map[uint]domain.Option
+    votes         map[uint]domain.Vote
+    ballots       []ballot
+    participation map[uint]map[string]struct{}
+    electorates   map[uint]map[string]int // poll ID -> user ID -> weight
+    surveys       map[uint]domain.Survey // Questions are kept without Options
+    submissions   map[uint]domain.SurveySubmission
+    quizzes       map[uint]domain.Quiz // Questions are kept without Prompt, Revealed and Options
+    templates     map[uint]domain.PollTemplate
+    collections   map[uint]domain.Collection // PollIDs are derived from polls, not kept here
+    spent         map[string]time.Time // spent challenge key -> expiry
+    nextID        uint
+}
+
+func newState() *state {
+    return &state{polls: map[uint]domain.Poll{}, options: map[uint]domain.Option{}, votes: map[uint]domain.Vote{}, participation: map[uint]map[string]struct{}{}, electorates: map[uint]map[string]int{}, surveys: map[uint]domain.Survey{}, submissions: map[uint]domain.SurveySubmission{}, quizzes: map[uint]domain.Quiz{}, templates: map[uint]domain.PollTemplate{}, collections: map[uint]domain.Collection{}, spent: map[string]time.Time{}}
+}
+
+func (s *state) clone() *state {
+    c := newState()
+    for k, v := range s.polls { c.polls[k] = v }
+    for k, v := range s.options { c.options[k] = v }
+    for k, v := range s.votes { c.votes[k] = v }
+    for k, v := range s.surveys { c.surveys[k] = v }
+    for k, v := range s.submissions { c.submissions[k] = v }
+    for k, v := range s.quizzes { c.quizzes[k] = v }
+    for k, v := range s.templates { c.templates[k] = v }
+    for k, v := range s.collections { c.collections[k] = v }
+    for k, v := range s.spent { c.spent[k] = v }
+    c.ballots = append([]ballot(nil), s.ballots...)
+    for k, m := range s.participation {
+        c.participation[k] = make(map[string]struct{}, len(m))
+        for u := range m { c.participation[k][u] = struct{}{} }
+    }
+    for k, m := range s.electorates {
+        c.electorates[k] = make(map[string]int, len(m))
+        for u, w := range m { c.electorates[k][u] = w }
+    }
+    c.nextID = s.nextID
+    return c
+}
+
+func (s *state) id() uint { s.nextID++; return s.nextID }
+
+// Repo serialises all access behind one mutex. A unit of work holds the mutex for its whole
+// duration, which also makes GetForUpdate's lock implicit.
+type Repo struct {
+    mu   *sync.Mutex
+    st   **state
+    inTx bool
+    now  func() time.Time
+}
+
+func NewRepo() *Repo {
+    st := newState()
+    return &Repo{mu: &sync.Mutex{}, st: &st, now: time.Now}
+}
+
+var _ app.PollRepository = (*Repo)(nil)
+
+// lock takes the mutex unless the caller is already inside a unit of work.
+func (r *Repo) lock() func() {
+    if r.inTx { return func() {} }
+    r.mu.Lock()
+    return r.mu.Unlock
+}
+
+func (r *Repo) WithTx(ctx context.Context, fn func(tx app.PollRepository) error) error {
+    unlock := r.lock()
+    defer unlock()
+    snapshot := (*r.st).clone()
+    if err := fn(&Repo{mu: r.mu, st: r.st, inTx: true, now: r.now}); err != nil {
+        *r.st = snapshot
+        return err
+    }
+    return nil
+}
+
+func (r *Repo) GetForUpdate(ctx context.Context, id uint) (*domain.Poll, error) { return r.GetByID(ctx, id) }
+
+func (r *Repo) Create(_ context.Context, p *domain.Poll) error {
+    defer r.lock()()
+    (*r.st).createPoll(p, r.now())
+    p.Options = nil // caller should re-fetch if needed
+    return nil
+}
+
+// createPoll stores p and its options, which take their Position from their order; the caller holds the lock.
+func (s *state) createPoll(p *domain.Poll, now time.Time) {
+    p.ID, p.Version, p.CreatedAt, p.UpdatedAt = s.id(), 1, now, now
+    if p.Kind == "" { p.Kind = domain.PollChoice } // the column default
+    if p.ThresholdBasis == "" { p.ThresholdBasis = domain.ThresholdVotes }
+    row := *p
+    row.Options = nil
+    s.polls[p.ID] = row
+    for i, o := range p.Options {
+        o.ID, o.PollID, o.Position, o.CreatedAt, o.UpdatedAt = s.id(), p.ID, i, now, now
+        s.options[o.ID] = o
+    }
+}
+
+func (r *Repo) Update(_ context.Context, p *domain.Poll) error {
+    defer r.lock()()
+    st := *r.st
+    row, ok := st.polls[p.ID]
+    if !ok || row.DeletedAt != nil || row.Version != p.Version { return domain.ErrVersionConflict }
+    row.Title, row.Description, row.ImageURL, row.Status, row.Threshold, row.ThresholdBasis = p.Title, p.Description, p.ImageURL, p.Status, p.Threshold, p.ThresholdBasis
+    row.VoteRatePerMinute, row.VoteBurst, row.ChallengeDifficulty, row.SeriesID = p.VoteRatePerMinute, p.VoteBurst, p.ChallengeDifficulty, p.SeriesID
+    row.CollectionID, row.ClosedByCollection = p.CollectionID, p.ClosedByCollection
+    row.Version++
+    row.UpdatedAt = r.now()
+    st.polls[p.ID] = row
+    p.Version = row.Version
+    return nil
+}
+
+func (r *Repo) Delete(_ context.Context, id uint) error {
+    defer r.lock()()
+    st := *r.st
+    p, ok := st.polls[id]
+    if !ok || p.DeletedAt != nil { return nil }
+    now := r.now()
+    p.DeletedAt = &now
+    st.polls[id] = p
+    return nil
+}
+
+func (r *Repo) ListDeleted(_ context.Context, offset, limit int) ([]domain.Poll, error) {
+    defer r.lock()()
+    out := (*r.st).listPolls(func(p domain.Poll) bool { return p.DeletedAt != nil })
+    sort.SliceStable(out, func(i, j int) bool { return out[i].DeletedAt.After(*out[j].DeletedAt) })
+    return page(out, offset, limit), nil
+}
+
+func (r *Repo) Restore(_ context.Context, id uint) error {
+    defer r.lock()()
+    st := *r.st
+    p, ok := st.polls[id]
+    if !ok || p.DeletedAt == nil { return domain.ErrPollNotFound }
+    p.DeletedAt = nil
+    st.polls[id] = p
+    return nil
+}
+
+func (r *Repo) PurgeDeleted(_ context.Context, before time.Time) (int, error) {
+    defer r.lock()()
+    st := *r.st
+    purged := map[uint]bool{}
+    for id, p := range st.polls {
+        if p.DeletedAt != nil && p.DeletedAt.Before(before) { purged[id] = true; delete(st.polls, id) }
+    }
+    if len(purged) == 0 { return 0, nil }
+    for id, o := range st.options {
+        if purged[o.PollID] { delete(st.options, id) }
+    }
+    for id, v := range st.votes {
+        if purged[v.PollID] { delete(st.votes, id) }
+    }
+    kept := st.ballots[:0]
+    for _, b := range st.ballots {
+        if !purged[b.pollID] { kept = append(kept, b) }
+    }
+    st.ballots = kept
+    for id := range purged { delete(st.participation, id); delete(st.electorates, id) }
+    return len(purged), nil
+}
+
+func (r *Repo) GetByID(_ context.Context, id uint) (*domain.Poll, error) {
+    defer r.lock()()
+    p, ok := (*r.st).polls[id]
+    if !ok || p.DeletedAt != nil { return nil, fmt.Errorf("get poll: %w", domain.ErrPollNotFound) }
+    p.Options = (*r.st).pollOptions(id)
+    return &p, nil
+}
+
+func (r *Repo) List(_ context.Context, offset, limit int) ([]domain.Poll, error) {
+    defer r.lock()()
+    out := (*r.st).listPolls(func(p domain.Poll) bool { return p.DeletedAt == nil })
+    return page(out, offset, limit), nil
+}
+
+func (r *Repo) ListSeries(_ context.Context, seriesID uint) ([]domain.Poll, error) {
+    defer r.lock()()
+    out := (*r.st).listPolls(func(p domain.Poll) bool { return p.DeletedAt == nil && p.SeriesID == seriesID })
+    slices.Reverse(out)
+    return out, nil
+}
+
+func (r *Repo) ListCollectionPolls(_ context.Context, collectionID uint) ([]domain.Poll, error) {
+    defer r.lock()()
+    out := (*r.st).listPolls(func(p domain.Poll) bool { return p.DeletedAt == nil && p.CollectionID == collectionID })
+    slices.Reverse(out)
+    return out, nil
+}
+
+// listPolls returns matching polls with their options, newest first.
+func (s *state) listPolls(keep func(domain.Poll) bool) []domain.Poll {
+    out := make([]domain.Poll, 0, len(s.polls))
+    for _, p := range s.polls {
+        if !keep(p) { continue }
+        p.Options = s.pollOptions(p.ID)
+        out = append(out, p)
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+    return out
+}
+
+func page[T any](xs []T, offset, limit int) []T {
+    if offset >= len(xs) { return []T{} }
+    xs = xs[offset:]
+    if limit > 0 && limit < len(xs) { xs = xs[:limit] }
+    return xs
+}
+
+func (r *Repo) AddOption(_ context.Context, opt *domain.Option) error {
+    defer r.lock()()
+    st := *r.st
+    now := r.now()
+    opt.ID, opt.CreatedAt, opt.UpdatedAt = st.id(), now, now
+    st.options[opt.ID] = *opt
+    return nil
+}
+
+func (r *Repo) ListOptions(_ context.Context, pollID uint) ([]domain.Option, error) {
+    defer r.lock()()
+    out := (*r.st).pollOptions(pollID)
+    if out == nil { out = []domain.Option{} }
+    return out, nil
+}
+
+func (r *Repo) UpdateOption(_ context.Context, opt *domain.Option) error {
+    defer r.lock()()
+    st := *r.st
+    o, ok := st.options[opt.ID]
+    if !ok || o.PollID != opt.PollID { return domain.ErrOptionNotFound }
+    o.Text, o.Description, o.ImageURL, o.Color, o.Metadata = opt.Text, opt.Description, opt.ImageURL, opt.Color, opt.Metadata
+    o.UpdatedAt = r.now()
+    st.options[o.ID] = o
+    *opt = o
+    return nil
+}
+
+func (r *Repo) DeleteOption(_ context.Context, pollID, optionID uint) error {
+    defer r.lock()()
+    st := *r.st
+    o, ok := st.options[optionID]
+    if !ok || o.PollID != pollID { return domain.ErrOptionNotFound }
+    delete(st.options, optionID)
+    for id, v := range st.votes {
+        if v.OptionID == optionID { delete(st.votes, id) }
+    }
+    kept := st.ballots[:0]
+    for _, b := range st.ballots {
+        if b.optionID != optionID { kept = append(kept, b) }
+    }
+    st.ballots = kept
+    return nil
+}
+
+func (r *Repo) ReorderOptions(_ context.Context, pollID uint, optionIDs []uint) error {
+    defer r.lock()()
+    st := *r.st
+    for _, id := range optionIDs {
+        if o, ok := st.options[id]; !ok || o.PollID != pollID { return domain.ErrOptionNotFound }
+    }
+    for i, id := range optionIDs {
+        o := st.options[id]
+        o.Position = i
+        st.options[id] = o
+    }
+    return nil
+}
+
+func (r *Repo) CountOptionVotes(_ context.Context, pollID, optionID uint) (int, error) {
+    defer r.lock()()
+    st := *r.st
+    n := 0
+    for _, v := range st.votes {
+        if v.PollID == pollID && v.OptionID == optionID { n++ }
+    }
+    for _, b := range st.ballots {
+        if b.pollID == pollID && b.optionID == optionID { n++ }
+    }
+    return n, nil
+}
+
+func (r *Repo) ReassignVotes(_ context.Context, pollID, from, to uint) (int, error) {
+    defer r.lock()()
+    st := *r.st
+    n := 0
+    for id, v := range st.votes {
+        if v.PollID == pollID && v.OptionID == from { v.OptionID = to; st.votes[id] = v; n++ }
+    }
+    for i, b := range st.ballots {
+        if b.pollID == pollID && b.optionID == from { st.ballots[i].optionID = to; n++ }
+    }
+    return n, nil
+}
+
+func (r *Repo) CreateVote(_ context.Context, v *domain.Vote) error {
+    defer r.lock()()
+    st := *r.st
+    if !st.refersToVoteTarget(*v) { return fmt.Errorf("create vote: %w", domain.ErrOptionNotFound) }
+    v.ID = st.id()
+    if v.Status == "" { v.Status = domain.VoteCounted }
+    if v.Weight == 0 { v.Weight = 1 }
+    if v.CreatedAt.IsZero() { v.CreatedAt = r.now() }
+    st.votes[v.ID] = *v
+    return nil
+}
+
+func (r *Repo) CreateParticipation(_ context.Context, pollID uint, userID string) error {
+    defer r.lock()()
+    st := *r.st
+    if _, ok := st.polls[pollID]; !ok { return fmt.Errorf("create participation: %w", domain.ErrPollNotFound) }
+    voters := st.participation[pollID]
+    if voters == nil { voters = map[string]struct{}{}; st.participation[pollID] = voters }
+    if _, ok := voters[userID]; ok { return domain.ErrAlreadyVoted }
+    voters[userID] = struct{}{}
+    return nil
+}
+
+func (r *Repo) DeleteParticipation(_ context.Context, pollID uint, userID string) error {
+    defer r.lock()()
+    delete((*r.st).participation[pollID], userID)
+    return nil
+}
+
+func (r *Repo) CreateBallot(_ context.Context, pollID, optionID uint) error {
+    defer r.lock()()
+    st := *r.st
+    if !st.refersToOption(pollID, optionID) { return fmt.Errorf("create ballot: %w", domain.ErrOptionNotFound) }
+    st.ballots = append(st.ballots, ballot{pollID: pollID, optionID: optionID})
+    return nil
+}
+
+func (r *Repo) ListVotes(_ context.Context, pollID uint, status domain.VoteStatus) ([]domain.Vote, error) {
+    defer r.lock()()
+    return (*r.st).pollVotes(func(v domain.Vote) bool { return v.PollID == pollID && (status == "" || v.Status == status) }), nil
+}
+
+func (r *Repo) HasVoted(_ context.Context, pollID uint, userID string) (bool, error) {
+    defer r.lock()()
+    for _, v := range (*r.st).votes {
+        if v.PollID == pollID && v.UserID == userID { return true, nil }
+    }
+    return false, nil
+}
+
+func (r *Repo) ListVoterVotes(_ context.Context, pollID uint, userID string) ([]domain.Vote, error) {
+    defer r.lock()()
+    return (*r.st).pollVotes(func(v domain.Vote) bool { return v.PollID == pollID && v.UserID == userID }), nil
+}
+
+func (r *Repo) RecentVotes(_ context.Context, pollID uint, since time.Time) ([]domain.Vote, error) {
+    defer r.lock()()
+    out := (*r.st).pollVotes(func(v domain.Vote) bool { return v.PollID == pollID && !v.CreatedAt.Before(since) })
+    sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+    return out, nil
+}
+
+func (r *Repo) SpendChallenge(_ context.Context, key string, expiresAt, now time.Time) error {
+    defer r.lock()()
+    st := *r.st
+    for k, exp := range st.spent {
+        if exp.Before(now) { delete(st.spent, k) }
+    }
+    if _, ok := st.spent[key]; ok { return fmt.Errorf("%w: challenge already used", domain.ErrChallengeInvalid) }
+    st.spent[key] = expiresAt
+    return nil
+}
+
+func (r *Repo) GetVote(_ context.Context, pollID, voteID uint) (*domain.Vote, error) {
+    defer r.lock()()
+    v, ok := (*r.st).votes[voteID]
+    if !ok || v.PollID != pollID { return nil, domain.ErrVoteNotFound }
+    return &v, nil
+}
+
+func (r *Repo) SetVoteStatus(_ context.Context, pollID, voteID uint, status domain.VoteStatus) error {
+    defer r.lock()()
+    st := *r.st
+    v, ok := st.votes[voteID]
+    if !ok || v.PollID != pollID { return domain.ErrVoteNotFound }
+    v.Status = status
+    if status == domain.VoteCounted { v.FlagReason = "" }
+    st.votes[voteID] = v
+    return nil
+}
+
+func (r *Repo) DeleteVote(_ context.Context, pollID, voteID uint) error {
+    defer r.lock()()
+    st := *r.st
+    v, ok := st.votes[voteID]
+    if !ok || v.PollID != pollID { return domain.ErrVoteNotFound }
+    delete(st.votes, voteID)
+    return nil
+}
+
+func (r *Repo) CountVotesByOption(_ context.Context, pollID uint) (domain.Tally, error) {
+    defer r.lock()()
+    st := *r.st
+    t := domain.Tally{Votes: map[uint]int{}, Weights: map[uint]int{}}
+    count := func(optionID uint, weight int) {
+        t.Votes[optionID]++
+        t.Weights[optionID] += weight
+        t.Total++
+        t.TotalWeight += weight
+    }
+    for _, v := range st.votes {
+        if v.PollID != pollID || v.Status != domain.VoteCounted || v.OptionID == 0 { continue }
+        count(v.OptionID, v.Weight)
+    }
+    for _, b := range st.ballots {
+        if b.pollID != pollID { continue }
+        count(b.optionID, 1)
+    }
+    return t, nil
+}
+
+// pollOptions returns a poll's options in insertion order; the caller holds the lock.
+func (s *state) pollOptions(pollID uint) []domain.Option {
+    var out []domain.Option
+    for _, o := range s.options {
+        if o.PollID == pollID { out = append(out, o) }
+    }
+    sort.Slice(out, func(i, j int) bool {
+        if out[i].Position != out[j].Position { return out[i].Position < out[j].Position }
+        return out[i].ID < out[j].ID
+    })
+    return out
+}
+
+// pollVotes returns matching votes in insertion order; the caller holds the lock.
+func (s *state) pollVotes(match func(domain.Vote) bool) []domain.Vote {
+    out := []domain.Vote{}
+    for _, v := range s.votes {
+        if match(v) { out = append(out, v) }
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+    return out
+}
+
+// refersToOption mirrors the database's foreign keys: the poll exists and the option belongs to it.
+func (s *state) refersToOption(pollID, optionID uint) bool {
+    _, ok := s.polls[pollID]
+    o, found := s.options[optionID]
+    return ok && found && o.PollID == pollID
+}
+
+// refersToVoteTarget is refersToOption for choice votes; text responses (no option) only need the poll.
+func (s *state) refersToVoteTarget(v domain.Vote) bool {
+    if v.OptionID == 0 {
+        _, ok := s.polls[v.PollID]
+        return ok
+    }
+    return s.refersToOption(v.PollID, v.OptionID)
+}
+
+// CheckConsistency scans for the same orphans as the GORM repository. Purges remove dependent
+// rows and votes are checked on insert, so a healthy in-memory store always reports zero.
+func (r *Repo) CheckConsistency(_ context.Context, repair bool) (app.ConsistencyReport, error) {
+    defer r.lock()()
+    st := *r.st
+    rep := app.ConsistencyReport{Repaired: repair}
+    for id, v := range st.votes {
+        if st.refersToVoteTarget(v) { continue }
+        rep.OrphanVotes++
+        if repair { delete(st.votes, id) }
+    }
+    kept := st.ballots[:0]
+    for _, b := range st.ballots {
+        if !st.refersToOption(b.pollID, b.optionID) {
+            rep.OrphanBallots++
+            if repair { continue }
+        }
+        kept = append(kept, b)
+    }
+    st.ballots = kept
+    for pollID, voters := range st.participation {
+        if _, ok := st.polls[pollID]; ok { continue }
+        rep.OrphanParticipation += len(voters)
+        if repair { delete(st.participation, pollID) }
+    }
+    for id, o := range st.options {
+        if _, ok := st.polls[o.PollID]; ok { continue }
+        rep.OrphanOptions++
+        if repair { delete(st.options, id) }
+    }
+    return rep, nil
+}
@@ -0,0 +1,119 @@
+package memory
+
+import (
+    "context"
+    "fmt"
+    "sort"
+
+    "github.com/robjsliwa/pulse/app"
+    "github.com/robjsliwa/pulse/domain"
+)
+
+func (r *Repo) CreateSurvey(_ context.Context, s *domain.Survey) error {
+    defer r.lock()()
+    st := *r.st
+    now := r.now()
+    s.ID, s.CreatedAt, s.UpdatedAt = st.id(), now, now
+    for i := range s.Questions {
+        q := &s.Questions[i]
+        q.ID, q.SurveyID, q.Position = st.id(), s.ID, i
+        if q.Kind == domain.QuestionChoice {
+            p := &domain.Poll{Title: q.Prompt, Status: s.Status, SurveyID: s.ID, Options: q.Options}
+            st.createPoll(p, now)
+            q.PollID = p.ID
+        }
+        q.Options = nil
+    }
+    row := *s
+    row.Questions = append([]domain.Question(nil), s.Questions...)
+    st.surveys[s.ID] = row
+    return nil
+}
+
+func (r *Repo) GetSurvey(_ context.Context, id uint) (*domain.Survey, error) {
+    defer r.lock()()
+    s, ok := (*r.st).survey(id)
+    if !ok { return nil, fmt.Errorf("get survey: %w", domain.ErrSurveyNotFound) }
+    return &s, nil
+}
+
+func (r *Repo) GetSurveyForUpdate(ctx context.Context, id uint) (*domain.Survey, error) { return r.GetSurvey(ctx, id) }
+
+func (r *Repo) ListSurveys(_ context.Context, offset, limit int) ([]domain.Survey, error) {
+    defer r.lock()()
+    st := *r.st
+    out := make([]domain.Survey, 0, len(st.surveys))
+    for id := range st.surveys {
+        s, _ := st.survey(id)
+        out = append(out, s)
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+    return page(out, offset, limit), nil
+}
+
+func (r *Repo) SetSurveyStatus(_ context.Context, id uint, status domain.PollStatus) error {
+    defer r.lock()()
+    st := *r.st
+    s, ok := st.surveys[id]
+    if !ok { return domain.ErrSurveyNotFound }
+    s.Status, s.UpdatedAt = status, r.now()
+    st.surveys[id] = s
+    return nil
+}
+
+func (r *Repo) CreateSurveySubmission(_ context.Context, sub *domain.SurveySubmission) error {
+    defer r.lock()()
+    st := *r.st
+    if _, ok := st.surveys[sub.SurveyID]; !ok { return fmt.Errorf("create submission: %w", domain.ErrSurveyNotFound) }
+    if sub.UserID != "" {
+        for _, other := range st.submissions {
+            if other.SurveyID == sub.SurveyID && other.UserID == sub.UserID { return domain.ErrAlreadySubmitted }
+        }
+    }
+    sub.ID = st.id()
+    if sub.CreatedAt.IsZero() { sub.CreatedAt = r.now() }
+    row := *sub
+    row.Answers = append([]domain.SurveyAnswer(nil), sub.Answers...)
+    st.submissions[sub.ID] = row
+    return nil
+}
+
+func (r *Repo) TallySurvey(_ context.Context, surveyID uint) (app.SurveyTally, error) {
+    defer r.lock()()
+    st := *r.st
+    questions := len(st.surveys[surveyID].Questions)
+    t := app.SurveyTally{Answered: map[uint]int{}}
+    for _, sub := range st.submissions {
+        if sub.SurveyID != surveyID { continue }
+        t.Submissions++
+        if len(sub.Answers) == questions { t.Complete++ }
+        for _, a := range sub.Answers { t.Answered[a.QuestionID]++ }
+    }
+    return t, nil
+}
+
+func (r *Repo) ListTextAnswers(_ context.Context, questionID uint, limit int) ([]string, error) {
+    defer r.lock()()
+    st := *r.st
+    subs := make([]domain.SurveySubmission, 0, len(st.submissions))
+    for _, sub := range st.submissions { subs = append(subs, sub) }
+    sort.Slice(subs, func(i, j int) bool { return subs[i].ID > subs[j].ID })
+    out := []string{}
+    for _, sub := range subs {
+        for _, a := range sub.Answers {
+            if a.QuestionID == questionID { out = append(out, a.Text) }
+        }
+    }
+    return page(out, 0, limit), nil
+}
+
+// survey returns a copy of a survey whose choice questions carry their poll's options; the caller holds the lock.
+func (s *state) survey(id uint) (domain.Survey, bool) {
+    sv, ok := s.surveys[id]
+    if !ok { return domain.Survey{}, false }
+    sv.Questions = append([]domain.Question(nil), sv.Questions...)
+    for i, q := range sv.Questions {
+        if q.PollID != 0 { sv.Questions[i].Options = s.pollOptions(q.PollID) }
+    }
+    return sv, true
+}
@@ -0,0 +1,77 @@
+package memory
+
+import (
+    "context"
+    "fmt"
+    "maps"
+    "slices"
+    "sort"
+    "time"
+
+    "github.com/robjsliwa/pulse/domain"
+)
+
+func (r *Repo) CreateTemplate(_ context.Context, t *domain.PollTemplate) error {
+    defer r.lock()()
+    st := *r.st
+    now := r.now()
+    t.ID, t.CreatedAt, t.UpdatedAt = st.id(), now, now
+    st.templates[t.ID] = copyTemplate(*t)
+    return nil
+}
+
+func (r *Repo) GetTemplate(_ context.Context, id uint) (*domain.PollTemplate, error) {
+    defer r.lock()()
+    t, ok := (*r.st).templates[id]
+    if !ok { return nil, fmt.Errorf("get template: %w", domain.ErrTemplateNotFound) }
+    t = copyTemplate(t)
+    return &t, nil
+}
+
+func (r *Repo) ListTemplates(_ context.Context, offset, limit int) ([]domain.PollTemplate, error) {
+    defer r.lock()()
+    st := *r.st
+    out := make([]domain.PollTemplate, 0, len(st.templates))
+    for _, t := range st.templates { out = append(out, copyTemplate(t)) }
+    sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+    return page(out, offset, limit), nil
+}
+
+func (r *Repo) DeleteTemplate(_ context.Context, id uint) error {
+    defer r.lock()()
+    st := *r.st
+    if _, ok := st.templates[id]; !ok { return domain.ErrTemplateNotFound }
+    delete(st.templates, id)
+    return nil
+}
+
+func (r *Repo) DueTemplates(_ context.Context, now time.Time) ([]domain.PollTemplate, error) {
+    defer r.lock()()
+    var out []domain.PollTemplate
+    for _, t := range (*r.st).templates {
+        if t.NextRunAt != nil && !t.NextRunAt.After(now) { out = append(out, copyTemplate(t)) }
+    }
+    sort.Slice(out, func(i, j int) bool {
+        if !out[i].NextRunAt.Equal(*out[j].NextRunAt) { return out[i].NextRunAt.Before(*out[j].NextRunAt) }
+        return out[i].ID < out[j].ID
+    })
+    return out, nil
+}
+
+func (r *Repo) AdvanceTemplate(_ context.Context, id uint, now, next time.Time) (bool, error) {
+    defer r.lock()()
+    st := *r.st
+    t, ok := st.templates[id]
+    if !ok || t.NextRunAt == nil || t.NextRunAt.After(now) { return false, nil }
+    t.NextRunAt, t.LastRunAt, t.UpdatedAt = nil, &now, r.now()
+    if !next.IsZero() { t.NextRunAt = &next }
+    st.templates[id] = t
+    return true, nil
+}
+
+// copyTemplate keeps callers from sharing the stored template's options and variables.
+func copyTemplate(t domain.PollTemplate) domain.PollTemplate {
+    t.Poll.Options = slices.Clone(t.Poll.Options)
+    t.Variables = maps.Clone(t.Variables)
+    return t
+}
@@ -0,0 +1,58 @@
+package persistence_test
+
+import (
+    "context"
+    "path/filepath"
+    "strconv"
+    "testing"
+
+    "github.com/robjsliwa/pulse/adapters/persistence"
+    "github.com/robjsliwa/pulse/app"
+    "github.com/robjsliwa/pulse/data"
+    "github.com/robjsliwa/pulse/domain"
+    "gorm.io/gorm"
+)
+
+type nopStream struct{}
+
+func (nopStream) Broadcast(uint, domain.Results)                {}
+func (nopStream) Subscribe(uint) (<-chan domain.Results, func()) { return nil, func() {} }
+
+type nopWebhooks struct{}
+
+func (nopWebhooks) Dispatch(context.Context, string, any) error { return nil }
+
+// castAnonymousVotes votes n times in a fresh anonymous poll through the service.
+func castAnonymousVotes(t *testing.T, db *gorm.DB, n int) {
+    t.Helper()
+    ctx := context.Background()
+    svc := app.NewService(persistence.NewRepo(db, voterKey), nopStream{}, nopWebhooks{})
+    p, err := svc.CreatePoll(ctx, domain.Poll{Title: "secret", Anonymous: true, Options: []domain.Option{{Text: "a"}, {Text: "b"}}})
+    if err != nil { t.Fatalf("create poll: %v", err) }
+    p, err = svc.GetPoll(ctx, p.ID)
+    if err != nil { t.Fatalf("get poll: %v", err) }
+    for i := 0; i < n; i++ {
+        if _, err := svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: p.Options[i%2].ID, UserID: "u" + strconv.Itoa(i)}, nil); err != nil { t.Fatalf("vote: %v", err) }
+    }
+}
+
+// TestBallotsShareNoTransactionWithParticipation checks that no ballot carries the transaction ID
+// (xmin) of a participation row, which would join a ballot to its voter.
+func TestBallotsShareNoTransactionWithParticipation(t *testing.T) {
+    db := postgresDB(t)
+    castAnonymousVotes(t, db, 5)
+    var ballots, joined int64
+    if err := db.Raw("SELECT COUNT(*) FROM ballot_models").Scan(&ballots).Error; err != nil { t.Fatalf("count ballots: %v", err) }
+    if err := db.Raw("SELECT COUNT(*) FROM ballot_models b JOIN participation_models p ON b.xmin::text = p.xmin::text").Scan(&joined).Error; err != nil { t.Fatalf("join on xmin: %v", err) }
+    if ballots != 5 || joined != 0 { t.Fatalf("ballots = %d, joined to participation = %d; want 5 and 0", ballots, joined) }
+}
+
+// TestAnonymousTablesHaveNoRowID checks that SQLite keeps no insertion-ordered rowid on either table.
+func TestAnonymousTablesHaveNoRowID(t *testing.T) {
+    db := migrated(t, data.Config{Driver: data.DriverSQLite, DSN: filepath.Join(t.TempDir(), "pulse.db")})
+    castAnonymousVotes(t, db, 2)
+    for _, table := range []string{"ballot_models", "participation_models"} {
+        var ids []int64
+        if err := db.Raw("SELECT rowid FROM " + table).Scan(&ids).Error; err == nil { t.Fatalf("%s has a rowid: %v", table, ids) }
+    }
+}
@@ -0,0 +1,92 @@
+package persistence
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/robjsliwa/pulse/domain"
+    "gorm.io/gorm"
+)
+
+func (r *Repo) CreateCollection(ctx context.Context, c *domain.Collection) error {
+    m := toCollectionModel(c)
+    if err := r.db.WithContext(ctx).Create(&m).Error; err != nil { return fmt.Errorf("create collection: %w", err) }
+    c.ID, c.CreatedAt, c.UpdatedAt = m.ID, m.CreatedAt, m.UpdatedAt
+    return nil
+}
+
+func (r *Repo) GetCollection(ctx context.Context, id uint) (*domain.Collection, error) {
+    var m CollectionModel
+    if err := r.db.WithContext(ctx).First(&m, id).Error; err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) { err = domain.ErrCollectionNotFound }
+        return nil, fmt.Errorf("get collection: %w", err)
+    }
+    out, err := r.toDomainCollections(ctx, []CollectionModel{m})
+    if err != nil { return nil, err }
+    return &out[0], nil
+}
+
+func (r *Repo) ListCollections(ctx context.Context, offset, limit int) ([]domain.Collection, error) {
+    var ms []CollectionModel
+    q := r.db.WithContext(ctx).Order("id DESC").Offset(offset)
+    if limit > 0 { q = q.Limit(limit) }
+    if err := q.Find(&ms).Error; err != nil { return nil, fmt.Errorf("list collections: %w", err) }
+    return r.toDomainCollections(ctx, ms)
+}
+
+func (r *Repo) UpdateCollection(ctx context.Context, c *domain.Collection) error {
+    res := r.db.WithContext(ctx).Model(&CollectionModel{}).Where("id = ?", c.ID).Updates(map[string]any{
+        "name": c.Name, "description": c.Description, "opens_at": c.OpensAt, "closes_at": c.ClosesAt, "access": string(c.Access), "webhook_scope": string(c.WebhookScope), "updated_at": time.Now(),
+    })
+    if res.Error != nil { return fmt.Errorf("update collection: %w", res.Error) }
+    if res.RowsAffected == 0 { return domain.ErrCollectionNotFound }
+    return nil
+}
+
+func (r *Repo) SetCollectionStatus(ctx context.Context, id uint, from, to domain.CollectionStatus) (bool, error) {
+    res := r.db.WithContext(ctx).Model(&CollectionModel{}).Where("id = ? AND status = ?", id, string(from)).Updates(map[string]any{"status": string(to), "updated_at": time.Now()})
+    if res.Error != nil { return false, fmt.Errorf("set collection status: %w", res.Error) }
+    return res.RowsAffected > 0, nil
+}
+
+func (r *Repo) DueCollections(ctx context.Context, now time.Time) ([]domain.Collection, error) {
+    var ms []CollectionModel
+    err := r.db.WithContext(ctx).Where("(status = ? AND opens_at <= ?) OR (status = ? AND closes_at <= ?)", string(domain.CollectionScheduled), now, string(domain.CollectionOpen), now).Order("id").Find(&ms).Error
+    if err != nil { return nil, fmt.Errorf("due collections: %w", err) }
+    return r.toDomainCollections(ctx, ms)
+}
+
+func (r *Repo) DeleteCollection(ctx context.Context, id uint) error {
+    return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        if err := tx.Unscoped().Model(&PollModel{}).Where("collection_id = ?", id).Updates(map[string]any{"collection_id": nil, "closed_by_collection": false}).Error; err != nil { return fmt.Errorf("release polls: %w", err) }
+        res := tx.Delete(&CollectionModel{}, id)
+        if res.Error != nil { return fmt.Errorf("delete collection: %w", res.Error) }
+        if res.RowsAffected == 0 { return domain.ErrCollectionNotFound }
+        return nil
+    })
+}
+
+func toCollectionModel(c *domain.Collection) CollectionModel {
+    return CollectionModel{Name: c.Name, Description: c.Description, Status: string(c.Status), OpensAt: c.OpensAt, ClosesAt: c.ClosesAt, Access: string(c.Access), WebhookScope: string(c.WebhookScope)}
+}
+
+// toDomainCollections maps collections and loads the IDs of their live polls in one query.
+func (r *Repo) toDomainCollections(ctx context.Context, ms []CollectionModel) ([]domain.Collection, error) {
+    out := make([]domain.Collection, 0, len(ms))
+    if len(ms) == 0 { return out, nil }
+    ids := make([]uint, 0, len(ms))
+    for _, m := range ms { ids = append(ids, m.ID) }
+    var members []PollModel
+    if err := r.db.WithContext(ctx).Select("id", "collection_id").Where("collection_id IN ?", ids).Order("id").Find(&members).Error; err != nil {
+        return nil, fmt.Errorf("list collection polls: %w", err)
+    }
+    pollIDs := map[uint][]uint{}
+    for _, p := range members { pollIDs[*p.CollectionID] = append(pollIDs[*p.CollectionID], p.ID) }
+    for _, m := range ms {
+        out = append(out, domain.Collection{ID: m.ID, Name: m.Name, Description: m.Description, Status: domain.CollectionStatus(m.Status), OpensAt: m.OpensAt, ClosesAt: m.ClosesAt,
+            Access: domain.AccessMode(m.Access), WebhookScope: domain.WebhookScope(m.WebhookScope), PollIDs: pollIDs[m.ID], CreatedAt: m.CreatedAt, UpdatedAt: m.UpdatedAt})
+    }
+    return out, nil
+}
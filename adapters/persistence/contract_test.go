@@ -0,0 +1,113 @@
+package persistence_test
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+    "io"
+    "net"
+    "net/url"
+    "os"
+    "path/filepath"
+    "sync"
+    "testing"
+
+    embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+    "github.com/robjsliwa/pulse/adapters/persistence"
+    "github.com/robjsliwa/pulse/app"
+    "github.com/robjsliwa/pulse/app/repotest"
+    "github.com/robjsliwa/pulse/data"
+    "gorm.io/gorm"
+)
+
+var voterKey = persistence.WithVoterKey([]byte("contract-test-voter-key"))
+
+func TestContractSQLite(t *testing.T) {
+    repotest.Run(t, func(t *testing.T) app.PollRepository {
+        return persistence.NewRepo(migrated(t, data.Config{Driver: data.DriverSQLite, DSN: filepath.Join(t.TempDir(), "pulse.db")}), voterKey)
+    })
+}
+
+// TestContractPostgres runs against a PostgreSQL server, each test in a schema of its own.
+func TestContractPostgres(t *testing.T) {
+    repotest.Run(t, func(t *testing.T) app.PollRepository { return persistence.NewRepo(postgresDB(t), voterKey) })
+}
+
+// postgresDB migrates a fresh schema and connects to it.
+func postgresDB(t *testing.T) *gorm.DB {
+    t.Helper()
+    dsn := postgresURL(t)
+    admin, err := data.Connect(data.Config{Driver: data.DriverPostgres, DSN: dsn})
+    if err != nil { t.Fatalf("connect: %v", err) }
+    schema := "pulse_test_" + randomHex(t)
+    if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil { t.Fatalf("create schema: %v", err) }
+    t.Cleanup(func() {
+        admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+        if sqlDB, err := admin.DB(); err == nil { sqlDB.Close() }
+    })
+    u, err := url.Parse(dsn)
+    if err != nil { t.Fatalf("parse PostgreSQL URL: %v", err) }
+    q := u.Query()
+    q.Set("search_path", schema)
+    u.RawQuery = q.Encode()
+    return migrated(t, data.Config{Driver: data.DriverPostgres, DSN: u.String()})
+}
+
+// embedded is the PostgreSQL the package starts when DATABASE_URL is not set.
+var embedded struct {
+    once sync.Once
+    db   *embeddedpostgres.EmbeddedPostgres
+    dir  string
+    url  string
+    err  error
+}
+
+// postgresURL returns DATABASE_URL or starts an embedded PostgreSQL, once for the package. The
+// embedded server's binaries are downloaded on first use and cached; where that fails the tests
+// skip, except under CI, where they fail.
+func postgresURL(t *testing.T) string {
+    t.Helper()
+    if dsn := os.Getenv("DATABASE_URL"); dsn != "" { return dsn }
+    embedded.once.Do(func() {
+        port, err := freePort()
+        if err != nil { embedded.err = err; return }
+        if embedded.dir, err = os.MkdirTemp("", "pulse-postgres-"); err != nil { embedded.err = err; return }
+        cfg := embeddedpostgres.DefaultConfig().Port(port).RuntimePath(embedded.dir).Logger(io.Discard)
+        db := embeddedpostgres.NewDatabase(cfg)
+        if embedded.err = db.Start(); embedded.err != nil { return }
+        embedded.db, embedded.url = db, cfg.GetConnectionURL()+"?sslmode=disable"
+    })
+    if embedded.err != nil {
+        if os.Getenv("CI") != "" { t.Fatalf("start embedded PostgreSQL: %v", embedded.err) }
+        t.Skipf("embedded PostgreSQL unavailable (%v); set DATABASE_URL to use a running server", embedded.err)
+    }
+    return embedded.url
+}
+
+func freePort() (uint32, error) {
+    l, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil { return 0, err }
+    defer l.Close()
+    return uint32(l.Addr().(*net.TCPAddr).Port), nil
+}
+
+func TestMain(m *testing.M) {
+    code := m.Run()
+    if embedded.db != nil { embedded.db.Stop() }
+    if embedded.dir != "" { os.RemoveAll(embedded.dir) }
+    os.Exit(code)
+}
+
+func migrated(t *testing.T, cfg data.Config) *gorm.DB {
+    t.Helper()
+    db, err := data.Connect(cfg)
+    if err != nil { t.Fatalf("connect: %v", err) }
+    t.Cleanup(func() { if sqlDB, err := db.DB(); err == nil { sqlDB.Close() } })
+    if _, err := data.MigrateUp(db); err != nil { t.Fatalf("migrate up: %v", err) }
+    return db
+}
+
+func randomHex(t *testing.T) string {
+    b := make([]byte, 6)
+    if _, err := rand.Read(b); err != nil { t.Fatalf("random: %v", err) }
+    return hex.EncodeToString(b)
+}
@@ -0,0 +1,40 @@
+package persistence
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "github.com/robjsliwa/pulse/domain"
+    "gorm.io/gorm"
+)
+
+func (r *Repo) SetElectorate(ctx context.Context, pollID uint, voters []domain.Voter) error {
+    return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        if err := tx.Where("poll_id = ?", pollID).Delete(&VoterModel{}).Error; err != nil { return fmt.Errorf("clear electorate: %w", err) }
+        if len(voters) == 0 { return nil }
+        ms := make([]VoterModel, 0, len(voters))
+        for _, v := range voters { ms = append(ms, VoterModel{PollID: pollID, UserID: v.UserID, Weight: v.Weight}) }
+        if err := tx.CreateInBatches(ms, 500).Error; err != nil { return fmt.Errorf("set electorate: %w", err) }
+        return nil
+    })
+}
+
+func (r *Repo) ListElectorate(ctx context.Context, pollID uint) ([]domain.Voter, error) {
+    var ms []VoterModel
+    if err := r.db.WithContext(ctx).Where("poll_id = ?", pollID).Order("user_id").Find(&ms).Error; err != nil {
+        return nil, fmt.Errorf("list electorate: %w", err)
+    }
+    out := make([]domain.Voter, 0, len(ms))
+    for _, m := range ms { out = append(out, domain.Voter{PollID: m.PollID, UserID: m.UserID, Weight: m.Weight}) }
+    return out, nil
+}
+
+func (r *Repo) GetVoter(ctx context.Context, pollID uint, userID string) (*domain.Voter, error) {
+    var m VoterModel
+    if err := r.db.WithContext(ctx).Where("poll_id = ? AND user_id = ?", pollID, userID).First(&m).Error; err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) { err = domain.ErrNotInElectorate }
+        return nil, fmt.Errorf("get voter: %w", err)
+    }
+    return &domain.Voter{PollID: m.PollID, UserID: m.UserID, Weight: m.Weight}, nil
+}
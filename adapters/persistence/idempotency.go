@@ -0,0 +1,84 @@
+package persistence
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sync"
+    "time"
+
+    "github.com/robjsliwa/pulse/internal/idempotency"
+    "gorm.io/gorm"
+)
+
+// IdempotencyStore keeps idempotency records in the database so any replica can replay them.
+type IdempotencyStore struct {
+    db  *gorm.DB
+    now func() time.Time
+
+    mu        sync.Mutex
+    lastSweep time.Time
+}
+
+func NewIdempotencyStore(db *gorm.DB) *IdempotencyStore { return &IdempotencyStore{db: db, now: time.Now} }
+
+var _ idempotency.Store = (*IdempotencyStore)(nil)
+
+func (s *IdempotencyStore) Reserve(ctx context.Context, rec idempotency.Record) (idempotency.Record, bool, error) {
+    now := s.now()
+    s.sweep(ctx, now)
+    var out idempotency.Record
+    reserved := false
+    err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        var m IdempotencyModel
+        err := tx.First(&m, "idempotency_key = ?", rec.Key).Error
+        switch {
+        case err == nil && now.Before(m.ExpiresAt):
+            out = toRecord(m)
+            return nil
+        case err == nil:
+            // expired: the key is free again
+            if err := tx.Delete(&IdempotencyModel{}, "idempotency_key = ?", rec.Key).Error; err != nil { return err }
+        case !errors.Is(err, gorm.ErrRecordNotFound):
+            return err
+        }
+        m = IdempotencyModel{Key: rec.Key, RequestHash: rec.RequestHash, ExpiresAt: rec.ExpiresAt}
+        if err := tx.Create(&m).Error; err != nil { return err }
+        out, reserved = toRecord(m), true
+        return nil
+    })
+    if err != nil {
+        // lost a race with a concurrent reservation of the same key
+        var m IdempotencyModel
+        if ferr := s.db.WithContext(ctx).First(&m, "idempotency_key = ?", rec.Key).Error; ferr == nil { return toRecord(m), false, nil }
+        return idempotency.Record{}, false, fmt.Errorf("reserve idempotency key: %w", err)
+    }
+    return out, reserved, nil
+}
+
+func (s *IdempotencyStore) Complete(ctx context.Context, key string, status int, body []byte) error {
+    err := s.db.WithContext(ctx).Model(&IdempotencyModel{}).Where("idempotency_key = ?", key).
+        Updates(map[string]any{"completed": true, "status": status, "body": body}).Error
+    if err != nil { return fmt.Errorf("complete idempotency key: %w", err) }
+    return nil
+}
+
+func (s *IdempotencyStore) Release(ctx context.Context, key string) error {
+    if err := s.db.WithContext(ctx).Delete(&IdempotencyModel{}, "idempotency_key = ?", key).Error; err != nil {
+        return fmt.Errorf("release idempotency key: %w", err)
+    }
+    return nil
+}
+
+// sweep deletes expired records at most once a minute.
+func (s *IdempotencyStore) sweep(ctx context.Context, now time.Time) {
+    s.mu.Lock()
+    if now.Sub(s.lastSweep) < time.Minute { s.mu.Unlock(); return }
+    s.lastSweep = now
+    s.mu.Unlock()
+    _ = s.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&IdempotencyModel{}).Error
+}
+
+func toRecord(m IdempotencyModel) idempotency.Record {
+    return idempotency.Record{Key: m.Key, RequestHash: m.RequestHash, Completed: m.Completed, Status: m.Status, Body: m.Body, ExpiresAt: m.ExpiresAt}
+}
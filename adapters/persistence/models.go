@@ -2,32 +2,186 @@ package persistence
 
 import (
     "time"
+
+    "gorm.io/gorm"
 )
 
 // GORM models kept separate from domain to keep domain pure.
 type PollModel struct {
-    ID          uint      `gorm:"primaryKey"`
-    Title       string    `gorm:"not null"`
+    ID                  uint           `gorm:"primaryKey"`
+    Kind                string         `gorm:"not null;default:choice"`
+    Language            string
+    Scale               int            `gorm:"not null;default:0"`
+    Title               string         `gorm:"not null"`
+    Description         string
+    ImageURL            string
+    Status              string         `gorm:"index;not null"`
+    Threshold           int            `gorm:"default:0"`
+    ThresholdBasis      string         `gorm:"not null;default:votes"`
+    Weighted            bool           `gorm:"not null;default:false"`
+    CreditBudget        int            `gorm:"not null;default:0"`
+    Anonymous           bool           `gorm:"default:false"`
+    VoteRatePerMinute   int            `gorm:"default:0"`
+    VoteBurst           int            `gorm:"default:0"`
+    ChallengeDifficulty int            `gorm:"default:0"`
+    Version             int            `gorm:"not null;default:1"`
+    SurveyID            *uint          `gorm:"index"` // nil unless the poll backs a survey question
+    QuizID              *uint          `gorm:"index"` // nil unless the poll is a quiz question
+    SeriesID            *uint          `gorm:"index"` // nil unless the poll is an instance of a series
+    CollectionID        *uint          `gorm:"index"` // nil unless the poll belongs to a collection
+    ClosedByCollection  bool           `gorm:"not null;default:false"`
+    CreatedAt           time.Time
+    UpdatedAt           time.Time
+    DeletedAt           gorm.DeletedAt `gorm:"index"`
+    Options             []OptionModel  `gorm:"foreignKey:PollID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+type OptionModel struct {
+    ID          uint   `gorm:"primaryKey"`
+    PollID      uint   `gorm:"index;not null"`
+    Text        string `gorm:"not null"`
     Description string
-    Status      string    `gorm:"index;not null"`
-    Threshold   int       `gorm:"default:0"`
+    ImageURL    string
+    Color       string
+    Metadata    string // JSON text; empty when unset
+    Position    int    `gorm:"not null;default:0"`
+    Correct     bool   `gorm:"not null;default:false"`
     CreatedAt   time.Time
     UpdatedAt   time.Time
-    Options     []OptionModel `gorm:"foreignKey:PollID;references:ID;constraint:OnDelete:CASCADE"`
 }
 
-type OptionModel struct {
+type VoteModel struct {
+    ID         uint      `gorm:"primaryKey"`
+    PollID     uint      `gorm:"index;not null"`
+    OptionID   *uint     `gorm:"index"` // nil for text poll responses
+    Text       string
+    UserID     string    `gorm:"index"`
+    Weight     int       `gorm:"not null;default:1"`
+    Status     string    `gorm:"index;not null;default:counted"`
+    FlagReason string
+    ClientIP   string
+    UserAgent  string
+    CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+// VoterModel is a member of a weighted poll's electorate.
+type VoterModel struct {
+    PollID uint   `gorm:"primaryKey"`
+    UserID string `gorm:"primaryKey"`
+    Weight int    `gorm:"not null"`
+}
+
+// ParticipationModel records that a voter took part in an anonymous poll. It shares no key with
+// BallotModel and carries no ID or timestamp, so a ballot cannot be traced back to its voter.
+type ParticipationModel struct {
+    PollID    uint   `gorm:"primaryKey;autoIncrement:false"`
+    VoterHash string `gorm:"primaryKey"`
+}
+
+// BallotModel is an anonymous ballot: a random ID, the poll and the chosen option, nothing else.
+type BallotModel struct {
+    ID       string `gorm:"primaryKey;size:32"`
+    PollID   uint   `gorm:"index;not null"`
+    OptionID uint   `gorm:"index;not null"`
+}
+
+type SurveyModel struct {
+    ID          uint                  `gorm:"primaryKey"`
+    Title       string                `gorm:"not null"`
+    Description string
+    Status      string                `gorm:"index;not null"`
+    CreatedAt   time.Time
+    UpdatedAt   time.Time
+    Questions   []SurveyQuestionModel `gorm:"foreignKey:SurveyID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+type SurveyQuestionModel struct {
+    ID       uint   `gorm:"primaryKey"`
+    SurveyID uint   `gorm:"index;not null"`
+    Position int    `gorm:"not null;default:0"`
+    Kind     string `gorm:"not null"`
+    Prompt   string `gorm:"not null"`
+    Required bool   `gorm:"not null;default:false"`
+    PollID   *uint  `gorm:"index"` // backing poll of a choice question
+}
+
+// SurveySubmissionModel is one respondent's submission; UserID is NULL for unidentified respondents,
+// which the unique index leaves unconstrained.
+type SurveySubmissionModel struct {
     ID        uint      `gorm:"primaryKey"`
-    PollID    uint      `gorm:"index;not null"`
-    Text      string    `gorm:"not null"`
+    SurveyID  uint      `gorm:"uniqueIndex:idx_survey_submission_user;not null"`
+    UserID    *string   `gorm:"uniqueIndex:idx_survey_submission_user"`
+    CreatedAt time.Time
+}
+
+type SurveyAnswerModel struct {
+    ID           uint  `gorm:"primaryKey"`
+    SubmissionID uint  `gorm:"index;not null"`
+    QuestionID   uint  `gorm:"index;not null"`
+    OptionID     *uint // choice questions
+    Text         string
+    CreatedAt    time.Time
+}
+
+type QuizModel struct {
+    ID          uint                `gorm:"primaryKey"`
+    Title       string              `gorm:"not null"`
+    Description string
+    CreatedAt   time.Time
+    UpdatedAt   time.Time
+    Questions   []QuizQuestionModel `gorm:"foreignKey:QuizID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+type QuizQuestionModel struct {
+    ID               uint `gorm:"primaryKey"`
+    QuizID           uint `gorm:"index;not null"`
+    Position         int  `gorm:"not null;default:0"`
+    PollID           uint `gorm:"index;not null"`
+    Points           int  `gorm:"not null"`
+    TimeLimitSeconds int  `gorm:"not null"`
+    OpenedAt         *time.Time
+}
+
+// PollTemplateModel stores a poll template; Spec and Variables are JSON text.
+type PollTemplateModel struct {
+    ID        uint       `gorm:"primaryKey"`
+    Name      string     `gorm:"not null"`
+    Spec      string     `gorm:"not null"`
+    Variables string
+    Schedule  string
+    Series    bool       `gorm:"not null;default:false"`
+    NextRunAt *time.Time `gorm:"index"` // nil unless scheduled
+    LastRunAt *time.Time
     CreatedAt time.Time
     UpdatedAt time.Time
 }
 
-type VoteModel struct {
-    ID        uint      `gorm:"primaryKey"`
-    PollID    uint      `gorm:"index;not null"`
-    OptionID  uint      `gorm:"index;not null"`
-    UserID    string    `gorm:"index"`
-    CreatedAt time.Time `gorm:"autoCreateTime"`
+type CollectionModel struct {
+    ID           uint   `gorm:"primaryKey"`
+    Name         string `gorm:"not null"`
+    Description  string
+    Status       string `gorm:"index;not null"`
+    OpensAt      *time.Time
+    ClosesAt     *time.Time
+    Access       string `gorm:"not null;default:public"`
+    WebhookScope string `gorm:"not null;default:polls"`
+    CreatedAt    time.Time
+    UpdatedAt    time.Time
+}
+
+// SpentChallengeModel marks a proof-of-work token as spent until it expires.
+type SpentChallengeModel struct {
+    Key       string    `gorm:"column:challenge_key;primaryKey;size:64"`
+    ExpiresAt time.Time `gorm:"index;not null"`
+}
+
+// IdempotencyModel stores the response to a request made with an Idempotency-Key.
+type IdempotencyModel struct {
+    Key         string    `gorm:"column:idempotency_key;primaryKey;size:255"`
+    RequestHash string    `gorm:"not null"`
+    Completed   bool      `gorm:"not null;default:false"`
+    Status      int
+    Body        []byte
+    ExpiresAt   time.Time `gorm:"index;not null"`
+    CreatedAt   time.Time
 }
@@ -0,0 +1,85 @@
+package persistence
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/robjsliwa/pulse/domain"
+    "gorm.io/gorm"
+)
+
+// CreateQuiz stores the quiz, a poll per question and the questions in one transaction.
+func (r *Repo) CreateQuiz(ctx context.Context, q *domain.Quiz) error {
+    return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        m := QuizModel{Title: q.Title, Description: q.Description}
+        if err := tx.Create(&m).Error; err != nil { return fmt.Errorf("create quiz: %w", err) }
+        for i := range q.Questions {
+            qq := &q.Questions[i]
+            pm := toPollModel(domain.Poll{Kind: domain.PollChoice, Title: qq.Prompt, Status: domain.PollOpen, QuizID: m.ID, Options: qq.Options})
+            if err := tx.Create(&pm).Error; err != nil { return fmt.Errorf("create question poll: %w", err) }
+            qm := QuizQuestionModel{QuizID: m.ID, Position: i, PollID: pm.ID, Points: qq.Points, TimeLimitSeconds: qq.TimeLimitSeconds}
+            if err := tx.Create(&qm).Error; err != nil { return fmt.Errorf("create question: %w", err) }
+            qq.ID, qq.QuizID, qq.Position, qq.PollID = qm.ID, m.ID, i, pm.ID
+        }
+        q.ID, q.CreatedAt, q.UpdatedAt = m.ID, m.CreatedAt, m.UpdatedAt
+        return nil
+    })
+}
+
+func (r *Repo) GetQuiz(ctx context.Context, id uint) (*domain.Quiz, error) {
+    var m QuizModel
+    if err := r.db.WithContext(ctx).Preload("Questions", orderByPosition).First(&m, id).Error; err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) { err = domain.ErrQuizNotFound }
+        return nil, fmt.Errorf("get quiz: %w", err)
+    }
+    out, err := r.toDomainQuizzes(ctx, []QuizModel{m})
+    if err != nil { return nil, err }
+    return &out[0], nil
+}
+
+func (r *Repo) ListQuizzes(ctx context.Context, offset, limit int) ([]domain.Quiz, error) {
+    var ms []QuizModel
+    q := r.db.WithContext(ctx).Model(&QuizModel{}).Order("id DESC").Offset(offset)
+    if limit > 0 { q = q.Limit(limit) }
+    if err := q.Preload("Questions", orderByPosition).Find(&ms).Error; err != nil {
+        return nil, fmt.Errorf("list quizzes: %w", err)
+    }
+    return r.toDomainQuizzes(ctx, ms)
+}
+
+func (r *Repo) OpenQuizQuestion(ctx context.Context, quizID, questionID uint, at time.Time) error {
+    res := r.db.WithContext(ctx).Model(&QuizQuestionModel{}).Where("id = ? AND quiz_id = ?", questionID, quizID).Update("opened_at", at)
+    if res.Error != nil { return fmt.Errorf("open question: %w", res.Error) }
+    if res.RowsAffected == 0 { return domain.ErrQuizNotFound }
+    return nil
+}
+
+// toDomainQuizzes maps quizzes and loads their question polls with options in one query.
+func (r *Repo) toDomainQuizzes(ctx context.Context, ms []QuizModel) ([]domain.Quiz, error) {
+    var pollIDs []uint
+    for _, m := range ms {
+        for _, q := range m.Questions { pollIDs = append(pollIDs, q.PollID) }
+    }
+    polls := map[uint]PollModel{}
+    if len(pollIDs) > 0 {
+        var pms []PollModel
+        if err := r.db.WithContext(ctx).Preload("Options", orderByPosition).Where("id IN ?", pollIDs).Find(&pms).Error; err != nil {
+            return nil, fmt.Errorf("list question polls: %w", err)
+        }
+        for _, pm := range pms { polls[pm.ID] = pm }
+    }
+    out := make([]domain.Quiz, 0, len(ms))
+    for _, m := range ms {
+        q := domain.Quiz{ID: m.ID, Title: m.Title, Description: m.Description, CreatedAt: m.CreatedAt, UpdatedAt: m.UpdatedAt}
+        for _, qm := range m.Questions {
+            pm := polls[qm.PollID]
+            qq := domain.QuizQuestion{ID: qm.ID, QuizID: qm.QuizID, Position: qm.Position, PollID: qm.PollID, Prompt: pm.Title, Points: qm.Points, TimeLimitSeconds: qm.TimeLimitSeconds, OpenedAt: qm.OpenedAt, Revealed: pm.Status == string(domain.PollClosed)}
+            for _, o := range pm.Options { qq.Options = append(qq.Options, toDomainOption(o)) }
+            q.Questions = append(q.Questions, qq)
+        }
+        out = append(out, q)
+    }
+    return out, nil
+}
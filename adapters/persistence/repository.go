@@ -2,110 +2,509 @@ package persistence
 
 import (
     "context"
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
     "fmt"
+    "time"
 
+    "github.com/robjsliwa/pulse/app"
     "github.com/robjsliwa/pulse/domain"
     "gorm.io/gorm"
+    "gorm.io/gorm/clause"
 )
 
 type Repo struct {
-    db *gorm.DB
+    db       *gorm.DB
+    voterKey []byte
 }
 
-func NewRepo(db *gorm.DB) *Repo { return &Repo{db: db} }
+// RepoOption configures optional Repo behaviour.
+type RepoOption func(*Repo)
 
-func (r *Repo) Create(ctx context.Context, p *domain.Poll) error {
-    m := PollModel{Title: p.Title, Description: p.Description, Status: string(p.Status), Threshold: p.Threshold}
-    for _, o := range p.Options {
-        m.Options = append(m.Options, OptionModel{Text: o.Text})
+// WithVoterKey keys the hashes that record who took part in anonymous polls. Without a key the
+// repo refuses anonymous votes. Changing the key lets earlier voters vote again.
+func WithVoterKey(key []byte) RepoOption { return func(r *Repo) { r.voterKey = key } }
+
+func NewRepo(db *gorm.DB, opts ...RepoOption) *Repo {
+    r := &Repo{db: db}
+    for _, o := range opts { o(r) }
+    return r
+}
+
+var _ app.PollRepository = (*Repo)(nil)
+
+func (r *Repo) WithTx(ctx context.Context, fn func(tx app.PollRepository) error) error {
+    return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error { return fn(&Repo{db: tx, voterKey: r.voterKey}) })
+}
+
+// GetForUpdate takes a row lock on PostgreSQL. SQLite ignores row locks; there the connection
+// begins transactions IMMEDIATE (see data.Connect), which serialises writers instead.
+func (r *Repo) GetForUpdate(ctx context.Context, id uint) (*domain.Poll, error) {
+    var m PollModel
+    if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Options", orderByPosition).First(&m, id).Error; err != nil {
+        return nil, fmt.Errorf("get poll: %w", notFound(err))
     }
+    p := toDomainPoll(m)
+    return &p, nil
+}
+
+func (r *Repo) Create(ctx context.Context, p *domain.Poll) error {
+    m := toPollModel(*p)
     if err := r.db.WithContext(ctx).Create(&m).Error; err != nil {
         return fmt.Errorf("create poll: %w", err)
     }
     p.ID = m.ID
+    p.Version = m.Version
     p.Options = nil // caller should re-fetch if needed
     return nil
 }
 
+// Update writes p only if the stored version still equals p.Version, then bumps the version.
+// A stale p yields domain.ErrVersionConflict.
 func (r *Repo) Update(ctx context.Context, p *domain.Poll) error {
-    return r.db.WithContext(ctx).Model(&PollModel{ID: p.ID}).Updates(map[string]any{
-        "title": p.Title, "description": p.Description, "status": string(p.Status), "threshold": p.Threshold,
-    }).Error
+    var seriesID, collectionID *uint
+    if p.SeriesID != 0 { seriesID = &p.SeriesID }
+    if p.CollectionID != 0 { collectionID = &p.CollectionID }
+    res := r.db.WithContext(ctx).Model(&PollModel{}).Where("id = ? AND version = ?", p.ID, p.Version).Updates(map[string]any{
+        "title": p.Title, "description": p.Description, "image_url": p.ImageURL, "status": string(p.Status), "threshold": p.Threshold, "threshold_basis": string(p.ThresholdBasis),
+        "vote_rate_per_minute": p.VoteRatePerMinute, "vote_burst": p.VoteBurst, "challenge_difficulty": p.ChallengeDifficulty, "series_id": seriesID,
+        "collection_id": collectionID, "closed_by_collection": p.ClosedByCollection, "version": gorm.Expr("version + 1"),
+    })
+    if res.Error != nil { return fmt.Errorf("update poll: %w", res.Error) }
+    if res.RowsAffected == 0 { return domain.ErrVersionConflict }
+    p.Version++
+    return nil
 }
 
+// Delete moves a poll to the trash; PurgeDeleted removes it for good.
 func (r *Repo) Delete(ctx context.Context, id uint) error {
     return r.db.WithContext(ctx).Delete(&PollModel{}, id).Error
 }
 
+func (r *Repo) ListDeleted(ctx context.Context, offset, limit int) ([]domain.Poll, error) {
+    var ms []PollModel
+    q := r.db.WithContext(ctx).Unscoped().Model(&PollModel{}).Where("deleted_at IS NOT NULL").Order("deleted_at DESC, id DESC").Offset(offset)
+    if limit > 0 { q = q.Limit(limit) }
+    if err := q.Preload("Options", orderByPosition).Find(&ms).Error; err != nil {
+        return nil, fmt.Errorf("list deleted polls: %w", err)
+    }
+    out := make([]domain.Poll, 0, len(ms))
+    for _, m := range ms { out = append(out, toDomainPoll(m)) }
+    return out, nil
+}
+
+func (r *Repo) Restore(ctx context.Context, id uint) error {
+    res := r.db.WithContext(ctx).Unscoped().Model(&PollModel{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+    if res.Error != nil { return fmt.Errorf("restore poll: %w", res.Error) }
+    if res.RowsAffected == 0 { return domain.ErrPollNotFound }
+    return nil
+}
+
+// PurgeDeleted permanently removes polls trashed before the cutoff together with their options,
+// votes, ballots and participation records.
+func (r *Repo) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+    var ids []uint
+    err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        if err := tx.Unscoped().Model(&PollModel{}).Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Pluck("id", &ids).Error; err != nil {
+            return err
+        }
+        if len(ids) == 0 { return nil }
+        for _, m := range []any{&VoteModel{}, &BallotModel{}, &ParticipationModel{}, &VoterModel{}, &OptionModel{}} {
+            if err := tx.Where("poll_id IN ?", ids).Delete(m).Error; err != nil { return err }
+        }
+        return tx.Unscoped().Delete(&PollModel{}, ids).Error
+    })
+    if err != nil { return 0, fmt.Errorf("purge deleted polls: %w", err) }
+    return len(ids), nil
+}
+
 func (r *Repo) GetByID(ctx context.Context, id uint) (*domain.Poll, error) {
     var m PollModel
-    if err := r.db.WithContext(ctx).Preload("Options").First(&m, id).Error; err != nil {
-        return nil, fmt.Errorf("get poll: %w", err)
+    if err := r.db.WithContext(ctx).Preload("Options", orderByPosition).First(&m, id).Error; err != nil {
+        return nil, fmt.Errorf("get poll: %w", notFound(err))
     }
-    p := &domain.Poll{ID: m.ID, Title: m.Title, Description: m.Description, Status: domain.PollStatus(m.Status), Threshold: m.Threshold, CreatedAt: m.CreatedAt, UpdatedAt: m.UpdatedAt}
-    for _, o := range m.Options {
-        p.Options = append(p.Options, domain.Option{ID: o.ID, PollID: o.PollID, Text: o.Text, CreatedAt: o.CreatedAt, UpdatedAt: o.UpdatedAt})
+    p := toDomainPoll(m)
+    return &p, nil
+}
+
+func (r *Repo) ListSeries(ctx context.Context, seriesID uint) ([]domain.Poll, error) {
+    out, err := r.listPollsWhere(ctx, "series_id = ?", seriesID)
+    if err != nil { return nil, fmt.Errorf("list series: %w", err) }
+    return out, nil
+}
+
+func (r *Repo) ListCollectionPolls(ctx context.Context, collectionID uint) ([]domain.Poll, error) {
+    out, err := r.listPollsWhere(ctx, "collection_id = ?", collectionID)
+    if err != nil { return nil, fmt.Errorf("list collection polls: %w", err) }
+    return out, nil
+}
+
+// listPollsWhere returns the matching live polls with their options, oldest first.
+func (r *Repo) listPollsWhere(ctx context.Context, cond string, args ...any) ([]domain.Poll, error) {
+    var ms []PollModel
+    if err := r.db.WithContext(ctx).Where(cond, args...).Order("id").Preload("Options", orderByPosition).Find(&ms).Error; err != nil {
+        return nil, err
     }
-    return p, nil
+    out := make([]domain.Poll, 0, len(ms))
+    for _, m := range ms { out = append(out, toDomainPoll(m)) }
+    return out, nil
 }
 
 func (r *Repo) List(ctx context.Context, offset, limit int) ([]domain.Poll, error) {
     var ms []PollModel
     q := r.db.WithContext(ctx).Model(&PollModel{}).Order("id DESC").Offset(offset)
     if limit > 0 { q = q.Limit(limit) }
-    if err := q.Preload("Options").Find(&ms).Error; err != nil {
+    if err := q.Preload("Options", orderByPosition).Find(&ms).Error; err != nil {
         return nil, fmt.Errorf("list polls: %w", err)
     }
     out := make([]domain.Poll, 0, len(ms))
     for _, m := range ms {
-        p := domain.Poll{ID: m.ID, Title: m.Title, Description: m.Description, Status: domain.PollStatus(m.Status), Threshold: m.Threshold, CreatedAt: m.CreatedAt, UpdatedAt: m.UpdatedAt}
-        for _, o := range m.Options { p.Options = append(p.Options, domain.Option{ID: o.ID, PollID: o.PollID, Text: o.Text, CreatedAt: o.CreatedAt, UpdatedAt: o.UpdatedAt}) }
-        out = append(out, p)
+        out = append(out, toDomainPoll(m))
     }
     return out, nil
 }
 
 func (r *Repo) AddOption(ctx context.Context, opt *domain.Option) error {
-    m := OptionModel{PollID: opt.PollID, Text: opt.Text}
+    m := toOptionModel(*opt)
     if err := r.db.WithContext(ctx).Create(&m).Error; err != nil {
         return fmt.Errorf("add option: %w", err)
     }
-    opt.ID = m.ID
+    opt.ID, opt.CreatedAt, opt.UpdatedAt = m.ID, m.CreatedAt, m.UpdatedAt
     return nil
 }
 
 func (r *Repo) ListOptions(ctx context.Context, pollID uint) ([]domain.Option, error) {
     var ms []OptionModel
-    if err := r.db.WithContext(ctx).Where("poll_id = ?", pollID).Find(&ms).Error; err != nil {
+    if err := orderByPosition(r.db.WithContext(ctx).Where("poll_id = ?", pollID)).Find(&ms).Error; err != nil {
         return nil, fmt.Errorf("list options: %w", err)
     }
     out := make([]domain.Option, 0, len(ms))
-    for _, m := range ms { out = append(out, domain.Option{ID: m.ID, PollID: m.PollID, Text: m.Text, CreatedAt: m.CreatedAt, UpdatedAt: m.UpdatedAt}) }
+    for _, m := range ms { out = append(out, toDomainOption(m)) }
     return out, nil
 }
 
+func (r *Repo) UpdateOption(ctx context.Context, opt *domain.Option) error {
+    db := r.db.WithContext(ctx)
+    res := db.Model(&OptionModel{}).Where("id = ? AND poll_id = ?", opt.ID, opt.PollID).Updates(map[string]any{"text": opt.Text, "description": opt.Description, "image_url": opt.ImageURL, "color": opt.Color, "metadata": string(opt.Metadata), "updated_at": time.Now()})
+    if res.Error != nil { return fmt.Errorf("update option: %w", res.Error) }
+    if res.RowsAffected == 0 { return domain.ErrOptionNotFound }
+    var m OptionModel
+    if err := db.First(&m, opt.ID).Error; err != nil { return fmt.Errorf("update option: %w", err) }
+    *opt = toDomainOption(m)
+    return nil
+}
+
+// DeleteOption deletes dependent rows explicitly rather than relying on ON DELETE CASCADE, so the
+// outcome does not depend on the connection enforcing foreign keys.
+func (r *Repo) DeleteOption(ctx context.Context, pollID, optionID uint) error {
+    return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        for _, m := range []any{&VoteModel{}, &BallotModel{}} {
+            if err := tx.Where("poll_id = ? AND option_id = ?", pollID, optionID).Delete(m).Error; err != nil { return fmt.Errorf("delete option votes: %w", err) }
+        }
+        res := tx.Where("id = ? AND poll_id = ?", optionID, pollID).Delete(&OptionModel{})
+        if res.Error != nil { return fmt.Errorf("delete option: %w", res.Error) }
+        if res.RowsAffected == 0 { return domain.ErrOptionNotFound }
+        return nil
+    })
+}
+
+func (r *Repo) ReorderOptions(ctx context.Context, pollID uint, optionIDs []uint) error {
+    return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        for i, id := range optionIDs {
+            res := tx.Model(&OptionModel{}).Where("id = ? AND poll_id = ?", id, pollID).Update("position", i)
+            if res.Error != nil { return fmt.Errorf("reorder options: %w", res.Error) }
+            if res.RowsAffected == 0 { return domain.ErrOptionNotFound }
+        }
+        return nil
+    })
+}
+
+func (r *Repo) CountOptionVotes(ctx context.Context, pollID, optionID uint) (int, error) {
+    var votes, ballots int64
+    db := r.db.WithContext(ctx)
+    if err := db.Model(&VoteModel{}).Where("poll_id = ? AND option_id = ?", pollID, optionID).Count(&votes).Error; err != nil {
+        return 0, fmt.Errorf("count option votes: %w", err)
+    }
+    if err := db.Model(&BallotModel{}).Where("poll_id = ? AND option_id = ?", pollID, optionID).Count(&ballots).Error; err != nil {
+        return 0, fmt.Errorf("count option ballots: %w", err)
+    }
+    return int(votes + ballots), nil
+}
+
+func (r *Repo) ReassignVotes(ctx context.Context, pollID, from, to uint) (int, error) {
+    var moved int64
+    err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        for _, m := range []any{&VoteModel{}, &BallotModel{}} {
+            res := tx.Model(m).Where("poll_id = ? AND option_id = ?", pollID, from).Update("option_id", to)
+            if res.Error != nil { return res.Error }
+            moved += res.RowsAffected
+        }
+        return nil
+    })
+    if err != nil { return 0, fmt.Errorf("reassign votes: %w", err) }
+    return int(moved), nil
+}
+
 func (r *Repo) CreateVote(ctx context.Context, v *domain.Vote) error {
-    m := VoteModel{PollID: v.PollID, OptionID: v.OptionID, UserID: v.UserID, CreatedAt: v.CreatedAt}
+    m := VoteModel{PollID: v.PollID, Text: v.Text, UserID: v.UserID, Weight: v.Weight, Status: string(v.Status), FlagReason: v.FlagReason, ClientIP: v.ClientIP, UserAgent: v.UserAgent, CreatedAt: v.CreatedAt}
+    if v.OptionID != 0 { m.OptionID = &v.OptionID }
+    if m.Status == "" { m.Status = string(domain.VoteCounted) }
+    if m.Weight == 0 { m.Weight = 1 }
     if err := r.db.WithContext(ctx).Create(&m).Error; err != nil {
         return fmt.Errorf("create vote: %w", err)
     }
-    v.ID = m.ID
+    v.ID, v.Weight = m.ID, m.Weight
     return nil
 }
 
-func (r *Repo) CountVotesByOption(ctx context.Context, pollID uint) (map[uint]int, int, error) {
-    type row struct{ OptionID uint; Cnt int }
+func (r *Repo) CountVotesByOption(ctx context.Context, pollID uint) (domain.Tally, error) {
+    type row struct{ OptionID uint; Cnt, Weight int }
     var rows []row
     err := r.db.WithContext(ctx).
         Model(&VoteModel{}).
-        Select("option_id, COUNT(*) as cnt").
-        Where("poll_id = ?", pollID).
+        Select("option_id, COUNT(*) as cnt, SUM(weight) as weight").
+        Where("poll_id = ? AND status = ? AND option_id IS NOT NULL", pollID, string(domain.VoteCounted)).
         Group("option_id").
         Scan(&rows).Error
-    if err != nil { return nil, 0, fmt.Errorf("count votes: %w", err) }
-    res := map[uint]int{}
-    total := 0
-    for _, rrow := range rows { res[rrow.OptionID] = rrow.Cnt; total += rrow.Cnt }
-    return res, total, nil
+    if err != nil { return domain.Tally{}, fmt.Errorf("count votes: %w", err) }
+    // anonymous ballots live in their own table and weigh 1 each
+    var ballots []row
+    err = r.db.WithContext(ctx).
+        Model(&BallotModel{}).
+        Select("option_id, COUNT(*) as cnt, COUNT(*) as weight").
+        Where("poll_id = ?", pollID).
+        Group("option_id").
+        Scan(&ballots).Error
+    if err != nil { return domain.Tally{}, fmt.Errorf("count ballots: %w", err) }
+    t := domain.Tally{Votes: map[uint]int{}, Weights: map[uint]int{}}
+    for _, rrow := range append(rows, ballots...) {
+        t.Votes[rrow.OptionID] += rrow.Cnt
+        t.Weights[rrow.OptionID] += rrow.Weight
+        t.Total += rrow.Cnt
+        t.TotalWeight += rrow.Weight
+    }
+    return t, nil
+}
+
+// CreateParticipation stores the voter only as a keyed per-poll hash. The ballot is written by
+// CreateBallot in a transaction of its own, so on PostgreSQL the two rows never share an xmin.
+func (r *Repo) CreateParticipation(ctx context.Context, pollID uint, userID string) error {
+    if len(r.voterKey) == 0 { return errors.New("anonymous voting needs a voter key (VOTER_SECRET)") }
+    // the primary key settles concurrent first votes of the same voter
+    res := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&ParticipationModel{PollID: pollID, VoterHash: r.voterHash(pollID, userID)})
+    if res.Error != nil { return fmt.Errorf("create participation: %w", res.Error) }
+    if res.RowsAffected == 0 { return domain.ErrAlreadyVoted }
+    return nil
 }
 
+func (r *Repo) DeleteParticipation(ctx context.Context, pollID uint, userID string) error {
+    if err := r.db.WithContext(ctx).Where("poll_id = ? AND voter_hash = ?", pollID, r.voterHash(pollID, userID)).Delete(&ParticipationModel{}).Error; err != nil {
+        return fmt.Errorf("delete participation: %w", err)
+    }
+    return nil
+}
+
+// CreateBallot stores a ballot under a random ID; neither table has a time-ordered key, so neither
+// row points at the other.
+func (r *Repo) CreateBallot(ctx context.Context, pollID, optionID uint) error {
+    ballotID, err := randomID()
+    if err != nil { return fmt.Errorf("ballot id: %w", err) }
+    if err := r.db.WithContext(ctx).Create(&BallotModel{ID: ballotID, PollID: pollID, OptionID: optionID}).Error; err != nil {
+        return fmt.Errorf("create ballot: %w", err)
+    }
+    return nil
+}
+
+// ListVotes lists a poll's votes, optionally only those in the given status.
+func (r *Repo) ListVotes(ctx context.Context, pollID uint, status domain.VoteStatus) ([]domain.Vote, error) {
+    var ms []VoteModel
+    q := r.db.WithContext(ctx).Where("poll_id = ?", pollID)
+    if status != "" { q = q.Where("status = ?", string(status)) }
+    if err := q.Order("id").Find(&ms).Error; err != nil {
+        return nil, fmt.Errorf("list votes: %w", err)
+    }
+    out := make([]domain.Vote, 0, len(ms))
+    for _, m := range ms { out = append(out, toDomainVote(m)) }
+    return out, nil
+}
+
+func (r *Repo) HasVoted(ctx context.Context, pollID uint, userID string) (bool, error) {
+    var ids []uint
+    if err := r.db.WithContext(ctx).Model(&VoteModel{}).Where("poll_id = ? AND user_id = ?", pollID, userID).Limit(1).Pluck("id", &ids).Error; err != nil {
+        return false, fmt.Errorf("has voted: %w", err)
+    }
+    return len(ids) > 0, nil
+}
+
+func (r *Repo) ListVoterVotes(ctx context.Context, pollID uint, userID string) ([]domain.Vote, error) {
+    var ms []VoteModel
+    if err := r.db.WithContext(ctx).Where("poll_id = ? AND user_id = ?", pollID, userID).Order("id").Find(&ms).Error; err != nil {
+        return nil, fmt.Errorf("list voter votes: %w", err)
+    }
+    out := make([]domain.Vote, 0, len(ms))
+    for _, m := range ms { out = append(out, toDomainVote(m)) }
+    return out, nil
+}
+
+func (r *Repo) RecentVotes(ctx context.Context, pollID uint, since time.Time) ([]domain.Vote, error) {
+    var ms []VoteModel
+    if err := r.db.WithContext(ctx).Where("poll_id = ? AND created_at >= ?", pollID, since).Order("created_at").Find(&ms).Error; err != nil {
+        return nil, fmt.Errorf("recent votes: %w", err)
+    }
+    out := make([]domain.Vote, 0, len(ms))
+    for _, m := range ms { out = append(out, toDomainVote(m)) }
+    return out, nil
+}
+
+// SpendChallenge relies on the primary key, so concurrent spends of one token on any replica
+// succeed once.
+func (r *Repo) SpendChallenge(ctx context.Context, key string, expiresAt, now time.Time) error {
+    db := r.db.WithContext(ctx)
+    if err := db.Where("expires_at < ?", now.UTC()).Delete(&SpentChallengeModel{}).Error; err != nil {
+        return fmt.Errorf("sweep spent challenges: %w", err)
+    }
+    res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&SpentChallengeModel{Key: key, ExpiresAt: expiresAt.UTC()})
+    if res.Error != nil { return fmt.Errorf("spend challenge: %w", res.Error) }
+    if res.RowsAffected == 0 { return fmt.Errorf("%w: challenge already used", domain.ErrChallengeInvalid) }
+    return nil
+}
+
+func (r *Repo) GetVote(ctx context.Context, pollID, voteID uint) (*domain.Vote, error) {
+    var m VoteModel
+    if err := r.db.WithContext(ctx).Where("id = ? AND poll_id = ?", voteID, pollID).First(&m).Error; err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) { return nil, domain.ErrVoteNotFound }
+        return nil, fmt.Errorf("get vote: %w", err)
+    }
+    v := toDomainVote(m)
+    return &v, nil
+}
+
+func (r *Repo) SetVoteStatus(ctx context.Context, pollID, voteID uint, status domain.VoteStatus) error {
+    updates := map[string]any{"status": string(status)}
+    if status == domain.VoteCounted { updates["flag_reason"] = "" }
+    res := r.db.WithContext(ctx).Model(&VoteModel{}).Where("id = ? AND poll_id = ?", voteID, pollID).Updates(updates)
+    if res.Error != nil { return fmt.Errorf("set vote status: %w", res.Error) }
+    if res.RowsAffected == 0 { return domain.ErrVoteNotFound }
+    return nil
+}
+
+func (r *Repo) DeleteVote(ctx context.Context, pollID, voteID uint) error {
+    res := r.db.WithContext(ctx).Where("id = ? AND poll_id = ?", voteID, pollID).Delete(&VoteModel{})
+    if res.Error != nil { return fmt.Errorf("delete vote: %w", res.Error) }
+    if res.RowsAffected == 0 { return domain.ErrVoteNotFound }
+    return nil
+}
+
+// notFound maps GORM's missing-row error onto the domain error.
+func notFound(err error) error {
+    if errors.Is(err, gorm.ErrRecordNotFound) { return domain.ErrPollNotFound }
+    return err
+}
+
+// orderByPosition keeps preloaded options in display order (ties in insertion order); PostgreSQL
+// guarantees no order otherwise.
+func orderByPosition(db *gorm.DB) *gorm.DB { return db.Order("position, id") }
+
+// toPollModel maps a new poll and its options, which take their Position from their order.
+func toPollModel(p domain.Poll) PollModel {
+    m := PollModel{Kind: string(p.Kind), Language: p.Language, Scale: p.Scale, Title: p.Title, Description: p.Description, ImageURL: p.ImageURL, Status: string(p.Status), Threshold: p.Threshold, ThresholdBasis: string(p.ThresholdBasis), Weighted: p.Weighted, CreditBudget: p.CreditBudget, Anonymous: p.Anonymous, VoteRatePerMinute: p.VoteRatePerMinute, VoteBurst: p.VoteBurst, ChallengeDifficulty: p.ChallengeDifficulty, ClosedByCollection: p.ClosedByCollection, Version: 1}
+    if p.SurveyID != 0 { m.SurveyID = &p.SurveyID }
+    if p.QuizID != 0 { m.QuizID = &p.QuizID }
+    if p.SeriesID != 0 { m.SeriesID = &p.SeriesID }
+    if p.CollectionID != 0 { m.CollectionID = &p.CollectionID }
+    for i, o := range p.Options {
+        om := toOptionModel(o)
+        om.Position = i
+        m.Options = append(m.Options, om)
+    }
+    return m
+}
+
+func toDomainPoll(m PollModel) domain.Poll {
+    var deletedAt *time.Time
+    if m.DeletedAt.Valid { deletedAt = &m.DeletedAt.Time }
+    p := domain.Poll{DeletedAt: deletedAt, ID: m.ID, Kind: domain.PollKind(m.Kind), Language: m.Language, Scale: m.Scale, Title: m.Title, Description: m.Description, ImageURL: m.ImageURL, Status: domain.PollStatus(m.Status), Threshold: m.Threshold, ThresholdBasis: domain.ThresholdBasis(m.ThresholdBasis), Weighted: m.Weighted, CreditBudget: m.CreditBudget, Anonymous: m.Anonymous, VoteRatePerMinute: m.VoteRatePerMinute, VoteBurst: m.VoteBurst, ChallengeDifficulty: m.ChallengeDifficulty, ClosedByCollection: m.ClosedByCollection, Version: m.Version, CreatedAt: m.CreatedAt, UpdatedAt: m.UpdatedAt}
+    if m.SurveyID != nil { p.SurveyID = *m.SurveyID }
+    if m.QuizID != nil { p.QuizID = *m.QuizID }
+    if m.SeriesID != nil { p.SeriesID = *m.SeriesID }
+    if m.CollectionID != nil { p.CollectionID = *m.CollectionID }
+    for _, o := range m.Options {
+        p.Options = append(p.Options, toDomainOption(o))
+    }
+    return p
+}
+
+func toDomainOption(m OptionModel) domain.Option {
+    o := domain.Option{ID: m.ID, PollID: m.PollID, Text: m.Text, Description: m.Description, ImageURL: m.ImageURL, Color: m.Color, Position: m.Position, Correct: m.Correct, CreatedAt: m.CreatedAt, UpdatedAt: m.UpdatedAt}
+    if m.Metadata != "" { o.Metadata = json.RawMessage(m.Metadata) }
+    return o
+}
+
+func toOptionModel(o domain.Option) OptionModel {
+    return OptionModel{PollID: o.PollID, Text: o.Text, Description: o.Description, ImageURL: o.ImageURL, Color: o.Color, Metadata: string(o.Metadata), Position: o.Position, Correct: o.Correct}
+}
+
+func toDomainVote(m VoteModel) domain.Vote {
+    v := domain.Vote{ID: m.ID, PollID: m.PollID, Text: m.Text, UserID: m.UserID, Weight: m.Weight, Status: domain.VoteStatus(m.Status), FlagReason: m.FlagReason, ClientIP: m.ClientIP, UserAgent: m.UserAgent, CreatedAt: m.CreatedAt}
+    if m.OptionID != nil { v.OptionID = *m.OptionID }
+    return v
+}
+
+func (r *Repo) voterHash(pollID uint, userID string) string {
+    mac := hmac.New(sha256.New, r.voterKey)
+    fmt.Fprintf(mac, "%d:%s", pollID, userID)
+    return hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomID() (string, error) {
+    b := make([]byte, 16)
+    if _, err := rand.Read(b); err != nil { return "", err }
+    return hex.EncodeToString(b), nil
+}
+
+// orphanChecks pairs each referencing table with the condition that makes a row an orphan. Votes
+// and ballots for a deleted poll also fail the option condition; they are counted once.
+var orphanChecks = []struct {
+    model any
+    where string
+}{
+    {&VoteModel{}, "poll_id NOT IN (SELECT id FROM poll_models) OR (option_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM option_models o WHERE o.id = vote_models.option_id AND o.poll_id = vote_models.poll_id))"},
+    {&BallotModel{}, "poll_id NOT IN (SELECT id FROM poll_models) OR NOT EXISTS (SELECT 1 FROM option_models o WHERE o.id = ballot_models.option_id AND o.poll_id = ballot_models.poll_id)"},
+    {&ParticipationModel{}, "poll_id NOT IN (SELECT id FROM poll_models)"},
+    {&OptionModel{}, "poll_id NOT IN (SELECT id FROM poll_models)"},
+}
+
+// pgIntegrityConstraints are added NOT VALID by migration 0009 and validated once orphans are gone.
+var pgIntegrityConstraints = [][2]string{
+    {"vote_models", "fk_vote_models_poll"}, {"vote_models", "fk_vote_models_option"},
+    {"ballot_models", "fk_ballot_models_poll"}, {"ballot_models", "fk_ballot_models_option"},
+    {"participation_models", "fk_participation_models_poll"},
+}
+
+func (r *Repo) CheckConsistency(ctx context.Context, repair bool) (app.ConsistencyReport, error) {
+    var rep app.ConsistencyReport
+    counts := []*int{&rep.OrphanVotes, &rep.OrphanBallots, &rep.OrphanParticipation, &rep.OrphanOptions}
+    err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        for i, c := range orphanChecks {
+            var n int64
+            if err := tx.Model(c.model).Where(c.where).Count(&n).Error; err != nil { return err }
+            *counts[i] = int(n)
+            if repair && n > 0 {
+                if err := tx.Where(c.where).Delete(c.model).Error; err != nil { return err }
+            }
+        }
+        if repair && tx.Dialector.Name() == "postgres" {
+            for _, c := range pgIntegrityConstraints {
+                if err := tx.Exec(fmt.Sprintf("ALTER TABLE %s VALIDATE CONSTRAINT %s", c[0], c[1])).Error; err != nil { return err }
+            }
+        }
+        return nil
+    })
+    if err != nil { return app.ConsistencyReport{}, fmt.Errorf("check consistency: %w", err) }
+    rep.Repaired = repair
+    return rep, nil
+}
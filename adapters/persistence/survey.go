@@ -0,0 +1,161 @@
+package persistence
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "github.com/robjsliwa/pulse/app"
+    "github.com/robjsliwa/pulse/domain"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+// CreateSurvey stores the survey, its questions and a poll per choice question in one transaction.
+func (r *Repo) CreateSurvey(ctx context.Context, s *domain.Survey) error {
+    return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        m := SurveyModel{Title: s.Title, Description: s.Description, Status: string(s.Status)}
+        if err := tx.Create(&m).Error; err != nil { return fmt.Errorf("create survey: %w", err) }
+        for i := range s.Questions {
+            q := &s.Questions[i]
+            qm := SurveyQuestionModel{SurveyID: m.ID, Position: i, Kind: string(q.Kind), Prompt: q.Prompt, Required: q.Required}
+            if q.Kind == domain.QuestionChoice {
+                pm := toPollModel(domain.Poll{Title: q.Prompt, Status: s.Status, SurveyID: m.ID, Options: q.Options})
+                if err := tx.Create(&pm).Error; err != nil { return fmt.Errorf("create question poll: %w", err) }
+                qm.PollID = &pm.ID
+            }
+            if err := tx.Create(&qm).Error; err != nil { return fmt.Errorf("create question: %w", err) }
+            *q = toDomainQuestion(qm)
+        }
+        s.ID, s.CreatedAt, s.UpdatedAt = m.ID, m.CreatedAt, m.UpdatedAt
+        return nil
+    })
+}
+
+func (r *Repo) GetSurvey(ctx context.Context, id uint) (*domain.Survey, error) { return r.getSurvey(ctx, id, false) }
+
+// GetSurveyForUpdate locks the survey row on PostgreSQL; see GetForUpdate for SQLite.
+func (r *Repo) GetSurveyForUpdate(ctx context.Context, id uint) (*domain.Survey, error) { return r.getSurvey(ctx, id, true) }
+
+func (r *Repo) getSurvey(ctx context.Context, id uint, lock bool) (*domain.Survey, error) {
+    var m SurveyModel
+    q := r.db.WithContext(ctx)
+    if lock { q = q.Clauses(clause.Locking{Strength: "UPDATE"}) }
+    if err := q.Preload("Questions", orderByPosition).First(&m, id).Error; err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) { err = domain.ErrSurveyNotFound }
+        return nil, fmt.Errorf("get survey: %w", err)
+    }
+    out, err := r.toDomainSurveys(ctx, []SurveyModel{m})
+    if err != nil { return nil, err }
+    return &out[0], nil
+}
+
+func (r *Repo) ListSurveys(ctx context.Context, offset, limit int) ([]domain.Survey, error) {
+    var ms []SurveyModel
+    q := r.db.WithContext(ctx).Model(&SurveyModel{}).Order("id DESC").Offset(offset)
+    if limit > 0 { q = q.Limit(limit) }
+    if err := q.Preload("Questions", orderByPosition).Find(&ms).Error; err != nil {
+        return nil, fmt.Errorf("list surveys: %w", err)
+    }
+    return r.toDomainSurveys(ctx, ms)
+}
+
+func (r *Repo) SetSurveyStatus(ctx context.Context, id uint, status domain.PollStatus) error {
+    res := r.db.WithContext(ctx).Model(&SurveyModel{}).Where("id = ?", id).Update("status", string(status))
+    if res.Error != nil { return fmt.Errorf("set survey status: %w", res.Error) }
+    if res.RowsAffected == 0 { return domain.ErrSurveyNotFound }
+    return nil
+}
+
+func (r *Repo) CreateSurveySubmission(ctx context.Context, sub *domain.SurveySubmission) error {
+    return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        m := SurveySubmissionModel{SurveyID: sub.SurveyID, CreatedAt: sub.CreatedAt}
+        if sub.UserID != "" {
+            var n int64
+            if err := tx.Model(&SurveySubmissionModel{}).Where("survey_id = ? AND user_id = ?", sub.SurveyID, sub.UserID).Count(&n).Error; err != nil {
+                return fmt.Errorf("check submission: %w", err)
+            }
+            if n > 0 { return domain.ErrAlreadySubmitted }
+            m.UserID = &sub.UserID
+        }
+        if err := tx.Create(&m).Error; err != nil { return fmt.Errorf("create submission: %w", err) }
+        for _, a := range sub.Answers {
+            am := SurveyAnswerModel{SubmissionID: m.ID, QuestionID: a.QuestionID, Text: a.Text, CreatedAt: m.CreatedAt}
+            if a.OptionID != 0 { optionID := a.OptionID; am.OptionID = &optionID }
+            if err := tx.Create(&am).Error; err != nil { return fmt.Errorf("create answer: %w", err) }
+        }
+        sub.ID, sub.CreatedAt = m.ID, m.CreatedAt
+        return nil
+    })
+}
+
+// TallySurvey counts answers per question; a submission is complete when it has an answer for
+// every question, which holds because submissions answer each question at most once.
+func (r *Repo) TallySurvey(ctx context.Context, surveyID uint) (app.SurveyTally, error) {
+    db := r.db.WithContext(ctx)
+    var submissions, questions, complete int64
+    if err := db.Model(&SurveySubmissionModel{}).Where("survey_id = ?", surveyID).Count(&submissions).Error; err != nil {
+        return app.SurveyTally{}, fmt.Errorf("count submissions: %w", err)
+    }
+    if err := db.Model(&SurveyQuestionModel{}).Where("survey_id = ?", surveyID).Count(&questions).Error; err != nil {
+        return app.SurveyTally{}, fmt.Errorf("count questions: %w", err)
+    }
+    err := db.Model(&SurveySubmissionModel{}).
+        Where("survey_id = ? AND (SELECT COUNT(*) FROM survey_answer_models a WHERE a.submission_id = survey_submission_models.id) = ?", surveyID, questions).
+        Count(&complete).Error
+    if err != nil { return app.SurveyTally{}, fmt.Errorf("count complete submissions: %w", err) }
+    type row struct{ QuestionID uint; Cnt int }
+    var rows []row
+    err = db.Model(&SurveyAnswerModel{}).
+        Select("question_id, COUNT(*) as cnt").
+        Where("question_id IN (?)", db.Model(&SurveyQuestionModel{}).Select("id").Where("survey_id = ?", surveyID)).
+        Group("question_id").
+        Scan(&rows).Error
+    if err != nil { return app.SurveyTally{}, fmt.Errorf("count answers: %w", err) }
+    t := app.SurveyTally{Submissions: int(submissions), Complete: int(complete), Answered: make(map[uint]int, len(rows))}
+    for _, rw := range rows { t.Answered[rw.QuestionID] = rw.Cnt }
+    return t, nil
+}
+
+func (r *Repo) ListTextAnswers(ctx context.Context, questionID uint, limit int) ([]string, error) {
+    out := []string{}
+    q := r.db.WithContext(ctx).Model(&SurveyAnswerModel{}).Where("question_id = ?", questionID).Order("id DESC")
+    if limit > 0 { q = q.Limit(limit) }
+    if err := q.Pluck("text", &out).Error; err != nil { return nil, fmt.Errorf("list text answers: %w", err) }
+    return out, nil
+}
+
+// toDomainSurveys maps surveys and loads the options of their choice questions in one query.
+func (r *Repo) toDomainSurveys(ctx context.Context, ms []SurveyModel) ([]domain.Survey, error) {
+    var pollIDs []uint
+    for _, m := range ms {
+        for _, q := range m.Questions {
+            if q.PollID != nil { pollIDs = append(pollIDs, *q.PollID) }
+        }
+    }
+    options := map[uint][]domain.Option{}
+    if len(pollIDs) > 0 {
+        var oms []OptionModel
+        if err := orderByPosition(r.db.WithContext(ctx).Where("poll_id IN ?", pollIDs)).Find(&oms).Error; err != nil {
+            return nil, fmt.Errorf("list question options: %w", err)
+        }
+        for _, o := range oms { options[o.PollID] = append(options[o.PollID], toDomainOption(o)) }
+    }
+    out := make([]domain.Survey, 0, len(ms))
+    for _, m := range ms {
+        s := domain.Survey{ID: m.ID, Title: m.Title, Description: m.Description, Status: domain.PollStatus(m.Status), CreatedAt: m.CreatedAt, UpdatedAt: m.UpdatedAt}
+        for _, qm := range m.Questions {
+            q := toDomainQuestion(qm)
+            q.Options = options[q.PollID]
+            s.Questions = append(s.Questions, q)
+        }
+        out = append(out, s)
+    }
+    return out, nil
+}
+
+func toDomainQuestion(m SurveyQuestionModel) domain.Question {
+    q := domain.Question{ID: m.ID, SurveyID: m.SurveyID, Position: m.Position, Kind: domain.QuestionKind(m.Kind), Prompt: m.Prompt, Required: m.Required}
+    if m.PollID != nil { q.PollID = *m.PollID }
+    return q
+}
@@ -0,0 +1,138 @@
+package persistence
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/robjsliwa/pulse/domain"
+    "gorm.io/gorm"
+)
+
+// templateSpec is the JSON form of a template's poll.
+type templateSpec struct {
+    Kind                string           `json:"kind,omitempty"`
+    Language            string           `json:"language,omitempty"`
+    Scale               int              `json:"scale,omitempty"`
+    Title               string           `json:"title"`
+    Description         string           `json:"description,omitempty"`
+    ImageURL            string           `json:"image_url,omitempty"`
+    Threshold           int              `json:"threshold,omitempty"`
+    ThresholdBasis      string           `json:"threshold_basis,omitempty"`
+    Weighted            bool             `json:"weighted,omitempty"`
+    CreditBudget        int              `json:"credit_budget,omitempty"`
+    Anonymous           bool             `json:"anonymous,omitempty"`
+    VoteRatePerMinute   int              `json:"vote_rate_per_minute,omitempty"`
+    VoteBurst           int              `json:"vote_burst,omitempty"`
+    ChallengeDifficulty int              `json:"challenge_difficulty,omitempty"`
+    Options             []templateOption `json:"options,omitempty"`
+}
+
+type templateOption struct {
+    Text        string          `json:"text"`
+    Description string          `json:"description,omitempty"`
+    ImageURL    string          `json:"image_url,omitempty"`
+    Color       string          `json:"color,omitempty"`
+    Metadata    json.RawMessage `json:"metadata,omitempty"`
+}
+
+func (r *Repo) CreateTemplate(ctx context.Context, t *domain.PollTemplate) error {
+    m, err := toTemplateModel(t)
+    if err != nil { return err }
+    if err := r.db.WithContext(ctx).Create(&m).Error; err != nil { return fmt.Errorf("create template: %w", err) }
+    t.ID, t.CreatedAt, t.UpdatedAt = m.ID, m.CreatedAt, m.UpdatedAt
+    return nil
+}
+
+func (r *Repo) GetTemplate(ctx context.Context, id uint) (*domain.PollTemplate, error) {
+    var m PollTemplateModel
+    if err := r.db.WithContext(ctx).First(&m, id).Error; err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) { err = domain.ErrTemplateNotFound }
+        return nil, fmt.Errorf("get template: %w", err)
+    }
+    return toDomainTemplate(m)
+}
+
+func (r *Repo) ListTemplates(ctx context.Context, offset, limit int) ([]domain.PollTemplate, error) {
+    var ms []PollTemplateModel
+    q := r.db.WithContext(ctx).Order("id DESC").Offset(offset)
+    if limit > 0 { q = q.Limit(limit) }
+    if err := q.Find(&ms).Error; err != nil { return nil, fmt.Errorf("list templates: %w", err) }
+    return toDomainTemplates(ms)
+}
+
+func (r *Repo) DeleteTemplate(ctx context.Context, id uint) error {
+    res := r.db.WithContext(ctx).Delete(&PollTemplateModel{}, id)
+    if res.Error != nil { return fmt.Errorf("delete template: %w", res.Error) }
+    if res.RowsAffected == 0 { return domain.ErrTemplateNotFound }
+    return nil
+}
+
+func (r *Repo) DueTemplates(ctx context.Context, now time.Time) ([]domain.PollTemplate, error) {
+    var ms []PollTemplateModel
+    if err := r.db.WithContext(ctx).Where("next_run_at <= ?", now).Order("next_run_at, id").Find(&ms).Error; err != nil {
+        return nil, fmt.Errorf("due templates: %w", err)
+    }
+    return toDomainTemplates(ms)
+}
+
+// AdvanceTemplate is a compare-and-set on next_run_at, so it needs no lock.
+func (r *Repo) AdvanceTemplate(ctx context.Context, id uint, now, next time.Time) (bool, error) {
+    var nextRun *time.Time
+    if !next.IsZero() { nextRun = &next }
+    res := r.db.WithContext(ctx).Model(&PollTemplateModel{}).Where("id = ? AND next_run_at <= ?", id, now).
+        Updates(map[string]any{"next_run_at": nextRun, "last_run_at": now, "updated_at": time.Now()})
+    if res.Error != nil { return false, fmt.Errorf("advance template: %w", res.Error) }
+    return res.RowsAffected > 0, nil
+}
+
+func toTemplateModel(t *domain.PollTemplate) (PollTemplateModel, error) {
+    p := t.Poll
+    spec := templateSpec{
+        Kind: string(p.Kind), Language: p.Language, Scale: p.Scale, Title: p.Title, Description: p.Description, ImageURL: p.ImageURL,
+        Threshold: p.Threshold, ThresholdBasis: string(p.ThresholdBasis), Weighted: p.Weighted, CreditBudget: p.CreditBudget,
+        Anonymous: p.Anonymous, VoteRatePerMinute: p.VoteRatePerMinute, VoteBurst: p.VoteBurst, ChallengeDifficulty: p.ChallengeDifficulty,
+    }
+    for _, o := range p.Options {
+        spec.Options = append(spec.Options, templateOption{Text: o.Text, Description: o.Description, ImageURL: o.ImageURL, Color: o.Color, Metadata: o.Metadata})
+    }
+    specJSON, err := json.Marshal(spec)
+    if err != nil { return PollTemplateModel{}, fmt.Errorf("encode template: %w", err) }
+    m := PollTemplateModel{Name: t.Name, Spec: string(specJSON), Schedule: t.Schedule, Series: t.Series, NextRunAt: t.NextRunAt, LastRunAt: t.LastRunAt}
+    if len(t.Variables) > 0 {
+        vars, err := json.Marshal(t.Variables)
+        if err != nil { return PollTemplateModel{}, fmt.Errorf("encode template: %w", err) }
+        m.Variables = string(vars)
+    }
+    return m, nil
+}
+
+func toDomainTemplate(m PollTemplateModel) (*domain.PollTemplate, error) {
+    var spec templateSpec
+    if err := json.Unmarshal([]byte(m.Spec), &spec); err != nil { return nil, fmt.Errorf("decode template %d: %w", m.ID, err) }
+    t := &domain.PollTemplate{ID: m.ID, Name: m.Name, Schedule: m.Schedule, Series: m.Series, NextRunAt: m.NextRunAt, LastRunAt: m.LastRunAt, CreatedAt: m.CreatedAt, UpdatedAt: m.UpdatedAt}
+    if m.Variables != "" {
+        if err := json.Unmarshal([]byte(m.Variables), &t.Variables); err != nil { return nil, fmt.Errorf("decode template %d: %w", m.ID, err) }
+    }
+    t.Poll = domain.Poll{
+        Kind: domain.PollKind(spec.Kind), Language: spec.Language, Scale: spec.Scale, Title: spec.Title, Description: spec.Description, ImageURL: spec.ImageURL,
+        Threshold: spec.Threshold, ThresholdBasis: domain.ThresholdBasis(spec.ThresholdBasis), Weighted: spec.Weighted, CreditBudget: spec.CreditBudget,
+        Anonymous: spec.Anonymous, VoteRatePerMinute: spec.VoteRatePerMinute, VoteBurst: spec.VoteBurst, ChallengeDifficulty: spec.ChallengeDifficulty,
+    }
+    for _, o := range spec.Options {
+        t.Poll.Options = append(t.Poll.Options, domain.Option{Text: o.Text, Description: o.Description, ImageURL: o.ImageURL, Color: o.Color, Metadata: o.Metadata})
+    }
+    return t, nil
+}
+
+func toDomainTemplates(ms []PollTemplateModel) ([]domain.PollTemplate, error) {
+    out := make([]domain.PollTemplate, 0, len(ms))
+    for _, m := range ms {
+        t, err := toDomainTemplate(m)
+        if err != nil { return nil, err }
+        out = append(out, *t)
+    }
+    return out, nil
+}
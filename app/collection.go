@@ -0,0 +1,419 @@
+package app
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// MaxCollectionPolls caps the polls of one collection.
+const MaxCollectionPolls = 500
+
+// CreateCollection groups polls under shared settings. A collection whose OpensAt lies ahead starts
+// scheduled: its polls take no votes until it opens.
+func (s *Service) CreateCollection(ctx context.Context, in domain.Collection, pollIDs []uint) (*domain.Collection, error) {
+    c := domain.Collection{Name: strings.TrimSpace(in.Name), Description: in.Description, OpensAt: utcTime(in.OpensAt), ClosesAt: utcTime(in.ClosesAt), Access: in.Access, WebhookScope: in.WebhookScope}
+    if c.Access == "" {
+        c.Access = domain.AccessPublic
+    }
+    if c.WebhookScope == "" {
+        c.WebhookScope = domain.WebhookPolls
+    }
+    if err := validateCollection(&c); err != nil {
+        return nil, err
+    }
+    if len(pollIDs) > MaxCollectionPolls {
+        return nil, fmt.Errorf("invalid collection: at most %d polls", MaxCollectionPolls)
+    }
+    c.Status = domain.CollectionOpen
+    if c.OpensAt != nil && c.OpensAt.After(s.now()) {
+        c.Status = domain.CollectionScheduled
+    }
+    err := s.repo.WithTx(ctx, func(tx PollRepository) error {
+        if err := tx.CreateCollection(ctx, &c); err != nil {
+            return fmt.Errorf("create collection: %w", err)
+        }
+        for _, id := range pollIDs {
+            if err := linkPoll(ctx, tx, id, c.ID); err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return s.GetCollection(ctx, c.ID)
+}
+
+func (s *Service) GetCollection(ctx context.Context, id uint) (*domain.Collection, error) {
+    c, err := s.repo.GetCollection(ctx, id)
+    if err != nil {
+        return nil, fmt.Errorf("get collection: %w", err)
+    }
+    return c, nil
+}
+
+func (s *Service) ListCollections(ctx context.Context, offset, limit int) ([]domain.Collection, error) {
+    cs, err := s.repo.ListCollections(ctx, offset, limit)
+    if err != nil {
+        return nil, fmt.Errorf("list collections: %w", err)
+    }
+    return cs, nil
+}
+
+// UpdateCollection applies in. Moving OpensAt or ClosesAt reschedules the collection's next
+// transition; its status only changes when that transition fires, so a scheduled collection
+// keeps an OpensAt.
+func (s *Service) UpdateCollection(ctx context.Context, in domain.CollectionPatch) (*domain.Collection, error) {
+    err := s.repo.WithTx(ctx, func(tx PollRepository) error {
+        c, err := tx.GetCollection(ctx, in.ID)
+        if err != nil {
+            return fmt.Errorf("get collection: %w", err)
+        }
+        if in.Name != nil {
+            c.Name = strings.TrimSpace(*in.Name)
+        }
+        if in.Description != nil {
+            c.Description = *in.Description
+        }
+        if in.OpensAt != nil {
+            c.OpensAt = utcTime(in.OpensAt)
+        }
+        if in.ClosesAt != nil {
+            c.ClosesAt = utcTime(in.ClosesAt)
+        }
+        if in.Access != nil {
+            c.Access = *in.Access
+        }
+        if in.WebhookScope != nil {
+            c.WebhookScope = *in.WebhookScope
+        }
+        if err := validateCollection(c); err != nil {
+            return err
+        }
+        if c.Status == domain.CollectionScheduled && c.OpensAt == nil {
+            return errors.New("invalid collection: a scheduled collection needs opens_at; open it instead")
+        }
+        if err := tx.UpdateCollection(ctx, c); err != nil {
+            return fmt.Errorf("update collection: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return s.GetCollection(ctx, in.ID)
+}
+
+// DeleteCollection removes a collection; its polls stay, on their own again.
+func (s *Service) DeleteCollection(ctx context.Context, id uint) error {
+    if err := s.repo.DeleteCollection(ctx, id); err != nil {
+        return fmt.Errorf("delete collection: %w", err)
+    }
+    return nil
+}
+
+// AddCollectionPoll puts a poll into a collection. A poll belongs to at most one collection, and
+// survey and quiz polls to none.
+func (s *Service) AddCollectionPoll(ctx context.Context, id, pollID uint) (*domain.Collection, error) {
+    err := s.repo.WithTx(ctx, func(tx PollRepository) error {
+        c, err := tx.GetCollection(ctx, id)
+        if err != nil {
+            return fmt.Errorf("get collection: %w", err)
+        }
+        if len(c.PollIDs) >= MaxCollectionPolls {
+            return fmt.Errorf("collection already has %d polls", MaxCollectionPolls)
+        }
+        return linkPoll(ctx, tx, pollID, id)
+    })
+    if err != nil {
+        return nil, err
+    }
+    return s.GetCollection(ctx, id)
+}
+
+// RemoveCollectionPoll takes a poll out of a collection, leaving it as it is otherwise.
+func (s *Service) RemoveCollectionPoll(ctx context.Context, id, pollID uint) (*domain.Collection, error) {
+    err := s.repo.WithTx(ctx, func(tx PollRepository) error {
+        p, err := tx.GetForUpdate(ctx, pollID)
+        if err != nil {
+            return fmt.Errorf("get poll: %w", err)
+        }
+        if p.CollectionID != id {
+            return fmt.Errorf("poll %d is not in collection %d", pollID, id)
+        }
+        p.CollectionID, p.ClosedByCollection = 0, false
+        if err := tx.Update(ctx, p); err != nil {
+            return fmt.Errorf("remove poll: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return s.GetCollection(ctx, id)
+}
+
+// OpenCollection opens a collection now, reopening the polls its closing closed.
+func (s *Service) OpenCollection(ctx context.Context, id uint) (*domain.Collection, error) {
+    c, err := s.repo.GetCollection(ctx, id)
+    if err != nil {
+        return nil, fmt.Errorf("get collection: %w", err)
+    }
+    if _, err := s.transition(ctx, c, domain.CollectionOpen); err != nil {
+        return nil, err
+    }
+    return s.GetCollection(ctx, id)
+}
+
+// CloseCollection closes a collection now, closing every open poll in it.
+func (s *Service) CloseCollection(ctx context.Context, id uint) (*domain.Collection, error) {
+    c, err := s.repo.GetCollection(ctx, id)
+    if err != nil {
+        return nil, fmt.Errorf("get collection: %w", err)
+    }
+    if _, err := s.transition(ctx, c, domain.CollectionClosed); err != nil {
+        return nil, err
+    }
+    return s.GetCollection(ctx, id)
+}
+
+// RunCollectionSchedules opens scheduled collections whose OpensAt and closes open ones whose
+// ClosesAt has come. It returns the collections it moved.
+func (s *Service) RunCollectionSchedules(ctx context.Context) ([]domain.Collection, error) {
+    cs, err := s.repo.DueCollections(ctx, s.now())
+    if err != nil {
+        return nil, fmt.Errorf("due collections: %w", err)
+    }
+    var moved []domain.Collection
+    var errs []error
+    for _, c := range cs {
+        to := domain.CollectionClosed
+        if c.Status == domain.CollectionScheduled {
+            to = domain.CollectionOpen
+        }
+        won, err := s.transition(ctx, &c, to)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("collection %d: %w", c.ID, err))
+        }
+        if won {
+            c.Status = to
+            moved = append(moved, c)
+        }
+    }
+    return moved, errors.Join(errs...)
+}
+
+// transition moves c to status to and brings its polls along. It reports whether this call changed
+// the status; when another caller got there first, the polls are brought along all the same.
+func (s *Service) transition(ctx context.Context, c *domain.Collection, to domain.CollectionStatus) (bool, error) {
+    won := false
+    if c.Status != to {
+        var err error
+        if won, err = s.repo.SetCollectionStatus(ctx, c.ID, c.Status, to); err != nil {
+            return false, fmt.Errorf("set collection status: %w", err)
+        }
+    }
+    ps, err := s.repo.ListCollectionPolls(ctx, c.ID)
+    if err != nil {
+        return won, fmt.Errorf("list collection polls: %w", err)
+    }
+    var errs []error
+    for _, p := range ps {
+        switch {
+        case to == domain.CollectionOpen && p.Status == domain.PollClosed && p.ClosedByCollection:
+            err = s.reopenPoll(ctx, p.ID)
+        case to == domain.CollectionClosed && p.Status == domain.PollOpen:
+            _, err = s.closePoll(ctx, p.ID, 0, true)
+        default:
+            continue
+        }
+        if err != nil {
+            errs = append(errs, fmt.Errorf("poll %d: %w", p.ID, err))
+        }
+    }
+    if won {
+        event := "collection.opened"
+        if to == domain.CollectionClosed {
+            event = "collection.closed"
+        }
+        if c.WebhookScope != domain.WebhookNone {
+            _ = s.webhooks.Dispatch(ctx, event, map[string]any{"collection_id": c.ID, "name": c.Name, "polls": len(ps)})
+        }
+        if s.collections != nil {
+            s.collections.BroadcastCollection(c.ID, domain.CollectionUpdate{CollectionID: c.ID, Status: to})
+        }
+    }
+    return won, errors.Join(errs...)
+}
+
+// reopenPoll opens a poll its collection closed again; a poll closed on its own stays closed.
+func (s *Service) reopenPoll(ctx context.Context, id uint) error {
+    var p *domain.Poll
+    reopened := false
+    err := s.repo.WithTx(ctx, func(tx PollRepository) error {
+        var err error
+        p, err = tx.GetForUpdate(ctx, id)
+        if err != nil {
+            return fmt.Errorf("get poll: %w", err)
+        }
+        if p.Status != domain.PollClosed || !p.ClosedByCollection {
+            return nil
+        }
+        reopened = true
+        p.Status, p.ClosedByCollection = domain.PollOpen, false
+        if err := tx.Update(ctx, p); err != nil {
+            return fmt.Errorf("open poll: %w", err)
+        }
+        return nil
+    })
+    if err != nil || !reopened {
+        return err
+    }
+    s.dispatchPoll(ctx, p, "poll.opened", map[string]any{"poll_id": p.ID, "title": p.Title})
+    return nil
+}
+
+// CollectionStats aggregates the participation in a collection's polls.
+func (s *Service) CollectionStats(ctx context.Context, id uint) (domain.CollectionStats, error) {
+    if _, err := s.repo.GetCollection(ctx, id); err != nil {
+        return domain.CollectionStats{}, fmt.Errorf("get collection: %w", err)
+    }
+    ps, err := s.repo.ListCollectionPolls(ctx, id)
+    if err != nil {
+        return domain.CollectionStats{}, fmt.Errorf("list collection polls: %w", err)
+    }
+    stats := domain.CollectionStats{CollectionID: id, Polls: len(ps)}
+    voters := map[string]bool{}
+    for _, p := range ps {
+        tally, err := s.repo.CountVotesByOption(ctx, p.ID)
+        if err != nil {
+            return domain.CollectionStats{}, fmt.Errorf("count votes: %w", err)
+        }
+        pp := domain.PollParticipation{PollID: p.ID, Title: p.Title, Status: p.Status, Votes: tally.Total}
+        if !p.Anonymous {
+            vs, err := s.repo.ListVotes(ctx, p.ID, domain.VoteCounted)
+            if err != nil {
+                return domain.CollectionStats{}, fmt.Errorf("list votes: %w", err)
+            }
+            // text and quadratic polls may hold several rows per voter
+            seen := map[string]bool{}
+            for _, v := range vs {
+                if v.UserID == "" {
+                    continue
+                }
+                seen[v.UserID], voters[v.UserID] = true, true
+            }
+            pp.Voters = len(seen)
+        }
+        if p.Status == domain.PollOpen {
+            stats.OpenPolls++
+        }
+        stats.Votes += pp.Votes
+        stats.PerPoll = append(stats.PerPoll, pp)
+    }
+    stats.Voters = len(voters)
+    return stats, nil
+}
+
+// checkCollectionAccess applies the status and access mode of a poll's collection to a vote.
+func checkCollectionAccess(ctx context.Context, tx PollRepository, p *domain.Poll, v *domain.Vote) error {
+    c, err := tx.GetCollection(ctx, p.CollectionID)
+    if err != nil {
+        return fmt.Errorf("get collection: %w", err)
+    }
+    if c.Status != domain.CollectionOpen {
+        return fmt.Errorf("%s is %s: %w", c.Name, c.Status, domain.ErrCollectionNotOpen)
+    }
+    if c.Access == domain.AccessIdentified && v.UserID == "" {
+        return fmt.Errorf("user_id required in %s", c.Name)
+    }
+    return nil
+}
+
+// dispatchPoll sends a poll's webhook event within the scope its collection allows, tagged with
+// the collection.
+func (s *Service) dispatchPoll(ctx context.Context, p *domain.Poll, event string, payload map[string]any) {
+    if p.CollectionID != 0 {
+        c, err := s.repo.GetCollection(ctx, p.CollectionID)
+        if err == nil {
+            switch c.WebhookScope {
+            case domain.WebhookNone:
+                return
+            case domain.WebhookCollection:
+                if event != "poll.closed" && event != "poll.opened" {
+                    return
+                }
+            }
+            payload["collection_id"] = c.ID
+        }
+    }
+    _ = s.webhooks.Dispatch(ctx, event, payload)
+}
+
+// publishCollection passes a member poll's results on to its collection's subscribers.
+func (s *Service) publishCollection(ctx context.Context, res domain.Results) {
+    if s.collections == nil {
+        return
+    }
+    p, err := s.repo.GetByID(ctx, res.PollID)
+    if err != nil || p.CollectionID == 0 {
+        return
+    }
+    s.collections.BroadcastCollection(p.CollectionID, domain.CollectionUpdate{CollectionID: p.CollectionID, PollID: p.ID, Results: &res})
+}
+
+// linkPoll puts poll pollID into collection collectionID.
+func linkPoll(ctx context.Context, tx PollRepository, pollID, collectionID uint) error {
+    p, err := tx.GetForUpdate(ctx, pollID)
+    if err != nil {
+        return fmt.Errorf("get poll %d: %w", pollID, err)
+    }
+    if p.CollectionID == collectionID {
+        return nil
+    }
+    if p.CollectionID != 0 {
+        return fmt.Errorf("poll %d already belongs to collection %d", pollID, p.CollectionID)
+    }
+    if p.SurveyID != 0 || p.QuizID != 0 {
+        return fmt.Errorf("poll %d belongs to a survey or quiz", pollID)
+    }
+    p.CollectionID = collectionID
+    if err := tx.Update(ctx, p); err != nil {
+        return fmt.Errorf("add poll %d: %w", pollID, err)
+    }
+    return nil
+}
+
+// utcTime converts t to UTC so stored schedules compare the same on every database; a nil or
+// zero t yields nil.
+func utcTime(t *time.Time) *time.Time {
+    if t == nil || t.IsZero() {
+        return nil
+    }
+    u := t.UTC()
+    return &u
+}
+
+func validateCollection(c *domain.Collection) error {
+    if c.Name == "" {
+        return errors.New("invalid collection: name required")
+    }
+    if c.Access != domain.AccessPublic && c.Access != domain.AccessIdentified {
+        return fmt.Errorf("invalid collection: access must be %q or %q", domain.AccessPublic, domain.AccessIdentified)
+    }
+    switch c.WebhookScope {
+    case domain.WebhookPolls, domain.WebhookCollection, domain.WebhookNone:
+    default:
+        return fmt.Errorf("invalid collection: webhook scope must be %q, %q or %q", domain.WebhookPolls, domain.WebhookCollection, domain.WebhookNone)
+    }
+    if c.OpensAt != nil && c.ClosesAt != nil && !c.ClosesAt.After(*c.OpensAt) {
+        return errors.New("invalid collection: closes_at must be after opens_at")
+    }
+    return nil
+}
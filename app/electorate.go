@@ -0,0 +1,92 @@
+package app
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// MaxElectorateSize caps the number of voters in one poll's electorate.
+const MaxElectorateSize = 100000
+
+// SetElectorate replaces the electorate of a weighted poll. Votes already cast keep the weight
+// their voter had at the time.
+func (s *Service) SetElectorate(ctx context.Context, pollID uint, voters []domain.Voter) ([]domain.Voter, error) {
+    if len(voters) > MaxElectorateSize {
+        return nil, fmt.Errorf("invalid electorate: at most %d voters", MaxElectorateSize)
+    }
+    seen := make(map[string]bool, len(voters))
+    for _, v := range voters {
+        if v.UserID == "" || v.Weight < 1 {
+            return nil, errors.New("invalid electorate: every voter needs a user_id and a weight of at least 1")
+        }
+        if seen[v.UserID] {
+            return nil, fmt.Errorf("invalid electorate: user %q listed twice", v.UserID)
+        }
+        seen[v.UserID] = true
+    }
+    err := s.repo.WithTx(ctx, func(tx PollRepository) error {
+        // serializes with votes, which look up their voter under the poll lock
+        p, err := tx.GetForUpdate(ctx, pollID)
+        if err != nil {
+            return fmt.Errorf("get poll: %w", err)
+        }
+        if !p.Weighted {
+            return errors.New("only weighted polls have an electorate")
+        }
+        if p.Status == domain.PollClosed {
+            return errors.New("poll is closed")
+        }
+        if err := tx.SetElectorate(ctx, pollID, voters); err != nil {
+            return fmt.Errorf("set electorate: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return s.Electorate(ctx, pollID)
+}
+
+// Electorate lists the voters of a weighted poll with their weights.
+func (s *Service) Electorate(ctx context.Context, pollID uint) ([]domain.Voter, error) {
+    if _, err := s.repo.GetByID(ctx, pollID); err != nil {
+        return nil, fmt.Errorf("get poll: %w", err)
+    }
+    voters, err := s.repo.ListElectorate(ctx, pollID)
+    if err != nil {
+        return nil, fmt.Errorf("list electorate: %w", err)
+    }
+    return voters, nil
+}
+
+// weighVote gives a vote in a weighted poll its voter's weight; only members of the electorate
+// may vote, once each. The electorate is the only source of weights: votes carry no verified
+// identity whose token claims could supply one.
+func weighVote(ctx context.Context, tx PollRepository, p *domain.Poll, v *domain.Vote) error {
+    if v.UserID == "" {
+        return errors.New("user_id required for weighted poll")
+    }
+    voter, err := tx.GetVoter(ctx, p.ID, v.UserID)
+    if err != nil {
+        return err
+    }
+    voted, err := tx.HasVoted(ctx, p.ID, v.UserID)
+    if err != nil {
+        return err
+    }
+    if voted {
+        return domain.ErrAlreadyVoted
+    }
+    v.Weight = voter.Weight
+    return nil
+}
+
+func validateThresholdBasis(b domain.ThresholdBasis) error {
+    if b != domain.ThresholdVotes && b != domain.ThresholdWeight {
+        return fmt.Errorf("threshold basis must be %q or %q", domain.ThresholdVotes, domain.ThresholdWeight)
+    }
+    return nil
+}
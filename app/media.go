@@ -0,0 +1,123 @@
+package app
+
+import (
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "mime"
+    "net/http"
+    "path"
+    "strings"
+
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// DefaultMaxMediaBytes caps uploads unless WithMaxMediaBytes says otherwise.
+const DefaultMaxMediaBytes = 10 << 20
+
+// mediaTypes maps the accepted sniffed content types to the extension used in keys.
+var mediaTypes = map[string]string{
+    "image/png":       ".png",
+    "image/jpeg":      ".jpg",
+    "image/gif":       ".gif",
+    "image/webp":      ".webp",
+    "application/pdf": ".pdf",
+}
+
+// UploadMedia stores a file under a key derived from its SHA-256, so identical uploads are stored
+// once. The content type is sniffed from the bytes; the client's claim is ignored. Images also get
+// a thumbnail when a Thumbnailer is configured.
+func (s *Service) UploadMedia(ctx context.Context, r io.Reader) (*domain.Media, error) {
+    if s.blobs == nil {
+        return nil, errors.New("media uploads are not configured")
+    }
+    data, err := io.ReadAll(io.LimitReader(r, s.maxMediaBytes+1))
+    if err != nil {
+        return nil, fmt.Errorf("read upload: %w", err)
+    }
+    if int64(len(data)) > s.maxMediaBytes {
+        return nil, fmt.Errorf("upload exceeds %d bytes: %w", s.maxMediaBytes, domain.ErrMediaTooLarge)
+    }
+    contentType := sniffContentType(data)
+    ext, ok := mediaTypes[contentType]
+    if !ok {
+        return nil, fmt.Errorf("%s: %w", contentType, domain.ErrUnsupportedMedia)
+    }
+    sum := sha256.Sum256(data)
+    digest := hex.EncodeToString(sum[:])
+    key := path.Join("sha256", digest[:2], digest+ext)
+    m := &domain.Media{Key: key, URL: domain.MediaPath + key, ContentType: contentType, Size: len(data)}
+
+    exists, err := s.blobs.Exists(ctx, key)
+    if err != nil {
+        return nil, fmt.Errorf("check media: %w", err)
+    }
+    if exists {
+        m.Deduplicated = true
+    } else if err := s.blobs.Put(ctx, key, bytes.NewReader(data)); err != nil {
+        return nil, fmt.Errorf("store media: %w", err)
+    }
+    if s.thumbnails != nil && strings.HasPrefix(contentType, "image/") {
+        s.thumbnail(ctx, m, data, digest)
+    }
+    return m, nil
+}
+
+// thumbnail fills in the image dimensions and thumbnail of m. Failures are logged rather than
+// returned: the original upload is still usable without a preview.
+func (s *Service) thumbnail(ctx context.Context, m *domain.Media, data []byte, digest string) {
+    th, err := s.thumbnails.Thumbnail(data, m.ContentType)
+    if err != nil {
+        log.Printf("media %s: %v", m.Key, err)
+        return
+    }
+    m.Width, m.Height = th.Width, th.Height
+    key := path.Join("thumbs", digest[:2], digest+mediaTypes[th.ContentType])
+    if exists, err := s.blobs.Exists(ctx, key); err != nil || !exists {
+        if err := s.blobs.Put(ctx, key, bytes.NewReader(th.Data)); err != nil {
+            log.Printf("media %s: store thumbnail: %v", m.Key, err)
+            return
+        }
+    }
+    m.ThumbnailURL = domain.MediaPath + key
+}
+
+// sniffContentType is http.DetectContentType without parameters such as "; charset=utf-8".
+func sniffContentType(data []byte) string {
+    ct, _, _ := strings.Cut(http.DetectContentType(data), ";")
+    return ct
+}
+
+// SetOptionImage uploads an image and points the option's ImageURL at it.
+func (s *Service) SetOptionImage(ctx context.Context, pollID, optionID uint, r io.Reader, expectedVersion int) (*domain.Option, error) {
+    m, err := s.UploadMedia(ctx, r)
+    if err != nil {
+        return nil, err
+    }
+    if !strings.HasPrefix(m.ContentType, "image/") {
+        return nil, fmt.Errorf("option image: %s: %w", m.ContentType, domain.ErrUnsupportedMedia)
+    }
+    return s.UpdateOption(ctx, domain.OptionPatch{ID: optionID, PollID: pollID, ImageURL: &m.URL}, expectedVersion)
+}
+
+// OpenMedia returns an uploaded file and its content type; the error wraps fs.ErrNotExist for
+// unknown keys.
+func (s *Service) OpenMedia(ctx context.Context, key string) (io.ReadCloser, string, error) {
+    if s.blobs == nil {
+        return nil, "", errors.New("media storage is not configured")
+    }
+    rc, err := s.blobs.Open(ctx, key)
+    if err != nil {
+        return nil, "", fmt.Errorf("open media: %w", err)
+    }
+    ct := mime.TypeByExtension(path.Ext(key))
+    if ct == "" {
+        ct = "application/octet-stream"
+    }
+    return rc, ct, nil
+}
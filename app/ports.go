@@ -2,22 +2,221 @@ package app
 
 import (
     "context"
+    "io"
+    "time"
+
     "github.com/robjsliwa/pulse/domain"
 )
 
 // PollRepository defines persistence operations for polls and related aggregates.
 type PollRepository interface {
+    // WithTx runs fn as one unit of work; every call on the repository passed to fn joins the
+    // transaction. Returning an error from fn rolls it back.
+    WithTx(ctx context.Context, fn func(tx PollRepository) error) error
+    // GetForUpdate is GetByID that also locks the poll until the surrounding transaction ends,
+    // so concurrent units of work touching the same poll run one after another.
+    GetForUpdate(ctx context.Context, id uint) (*domain.Poll, error)
+
     Create(ctx context.Context, p *domain.Poll) error
     Update(ctx context.Context, p *domain.Poll) error
+    // Delete moves a poll to the trash; trashed polls are invisible to GetByID and List.
     Delete(ctx context.Context, id uint) error
+    ListDeleted(ctx context.Context, offset, limit int) ([]domain.Poll, error)
+    // Restore takes a poll out of the trash; domain.ErrPollNotFound if it is not trashed.
+    Restore(ctx context.Context, id uint) error
+    // PurgeDeleted permanently removes polls trashed before the cutoff, with their options and votes.
+    PurgeDeleted(ctx context.Context, before time.Time) (int, error)
     GetByID(ctx context.Context, id uint) (*domain.Poll, error)
     List(ctx context.Context, offset, limit int) ([]domain.Poll, error)
+    // ListSeries returns the live instances of a series with their options, oldest first.
+    ListSeries(ctx context.Context, seriesID uint) ([]domain.Poll, error)
+    // ListCollectionPolls returns the live polls of a collection with their options, oldest first.
+    ListCollectionPolls(ctx context.Context, collectionID uint) ([]domain.Poll, error)
 
     AddOption(ctx context.Context, opt *domain.Option) error
+    // ListOptions returns a poll's options ordered by Position.
     ListOptions(ctx context.Context, pollID uint) ([]domain.Option, error)
+    // UpdateOption saves opt's content fields (everything but IDs, Position and timestamps);
+    // domain.ErrOptionNotFound unless the option belongs to opt.PollID.
+    UpdateOption(ctx context.Context, opt *domain.Option) error
+    // DeleteOption removes an option together with its votes and ballots.
+    DeleteOption(ctx context.Context, pollID, optionID uint) error
+    // ReorderOptions sets each option's Position to its index in optionIDs.
+    ReorderOptions(ctx context.Context, pollID uint, optionIDs []uint) error
+    // CountOptionVotes counts an option's votes in any status plus its anonymous ballots.
+    CountOptionVotes(ctx context.Context, pollID, optionID uint) (int, error)
+    // ReassignVotes moves the votes and ballots of option from to option to and returns how many moved.
+    ReassignVotes(ctx context.Context, pollID, from, to uint) (int, error)
 
     CreateVote(ctx context.Context, v *domain.Vote) error
-    CountVotesByOption(ctx context.Context, pollID uint) (map[uint]int, int, error)
+    // CreateParticipation records that userID voted in an anonymous poll; returns domain.ErrAlreadyVoted on a repeat voter.
+    CreateParticipation(ctx context.Context, pollID uint, userID string) error
+    // DeleteParticipation forgets that userID voted, for a ballot that could not be cast.
+    DeleteParticipation(ctx context.Context, pollID uint, userID string) error
+    // CreateBallot stores an anonymous ballot, which names no voter; it fails unless the option belongs to the poll.
+    CreateBallot(ctx context.Context, pollID, optionID uint) error
+    // ListVotes lists a poll's votes; an empty status lists all of them.
+    ListVotes(ctx context.Context, pollID uint, status domain.VoteStatus) ([]domain.Vote, error)
+    // HasVoted reports whether userID has a vote in any status in the poll.
+    HasVoted(ctx context.Context, pollID uint, userID string) (bool, error)
+    // ListVoterVotes lists userID's votes in any status in the poll.
+    ListVoterVotes(ctx context.Context, pollID uint, userID string) ([]domain.Vote, error)
+    RecentVotes(ctx context.Context, pollID uint, since time.Time) ([]domain.Vote, error)
+    // SpendChallenge records a solved challenge until it expires; a wrapped domain.ErrChallengeInvalid
+    // when it was already spent. Records that expired before now are dropped on the way.
+    SpendChallenge(ctx context.Context, key string, expiresAt, now time.Time) error
+    // GetVote returns one of a poll's votes in any status; domain.ErrVoteNotFound otherwise.
+    GetVote(ctx context.Context, pollID, voteID uint) (*domain.Vote, error)
+    SetVoteStatus(ctx context.Context, pollID, voteID uint, status domain.VoteStatus) error
+    DeleteVote(ctx context.Context, pollID, voteID uint) error
+    // CountVotesByOption tallies a poll's counted votes and anonymous ballots per option, by
+    // headcount and by the weight stored with each vote; ballots weigh 1.
+    CountVotesByOption(ctx context.Context, pollID uint) (domain.Tally, error)
+
+    // SetElectorate replaces the electorate of a weighted poll.
+    SetElectorate(ctx context.Context, pollID uint, voters []domain.Voter) error
+    // ListElectorate returns a poll's electorate ordered by UserID.
+    ListElectorate(ctx context.Context, pollID uint) ([]domain.Voter, error)
+    // GetVoter returns a member of a poll's electorate; domain.ErrNotInElectorate if userID is not one.
+    GetVoter(ctx context.Context, pollID uint, userID string) (*domain.Voter, error)
+
+    // CheckConsistency reports orphaned rows; with repair set it also deletes them.
+    CheckConsistency(ctx context.Context, repair bool) (ConsistencyReport, error)
+
+    // CreateSurvey stores a survey with its questions, plus a poll holding the options of each
+    // choice question; the polls get SurveyID set and their IDs are written back to the questions.
+    CreateSurvey(ctx context.Context, s *domain.Survey) error
+    // GetSurvey returns a survey with its questions in Position order, each choice question
+    // carrying its poll's options; domain.ErrSurveyNotFound if it does not exist.
+    GetSurvey(ctx context.Context, id uint) (*domain.Survey, error)
+    // GetSurveyForUpdate is GetSurvey that also locks the survey until the surrounding transaction ends.
+    GetSurveyForUpdate(ctx context.Context, id uint) (*domain.Survey, error)
+    ListSurveys(ctx context.Context, offset, limit int) ([]domain.Survey, error)
+    SetSurveyStatus(ctx context.Context, id uint, status domain.PollStatus) error
+    // CreateSurveySubmission stores a submission and its answers; domain.ErrAlreadySubmitted if
+    // sub.UserID already submitted this survey.
+    CreateSurveySubmission(ctx context.Context, sub *domain.SurveySubmission) error
+    TallySurvey(ctx context.Context, surveyID uint) (SurveyTally, error)
+    // ListTextAnswers returns up to limit answers to a text question, newest first.
+    ListTextAnswers(ctx context.Context, questionID uint, limit int) ([]string, error)
+
+    // CreateQuiz stores a quiz with a poll per question, holding the question's options with
+    // their Correct flags; the polls get QuizID set and their IDs are written back to the questions.
+    CreateQuiz(ctx context.Context, q *domain.Quiz) error
+    // GetQuiz returns a quiz with its questions in Position order, each carrying its poll's title
+    // as Prompt, its options and whether the poll is closed as Revealed; domain.ErrQuizNotFound if
+    // it does not exist.
+    GetQuiz(ctx context.Context, id uint) (*domain.Quiz, error)
+    ListQuizzes(ctx context.Context, offset, limit int) ([]domain.Quiz, error)
+    // OpenQuizQuestion sets OpenedAt of question questionID of quiz quizID; domain.ErrQuizNotFound
+    // if the quiz has no such question.
+    OpenQuizQuestion(ctx context.Context, quizID, questionID uint, at time.Time) error
+
+    CreateTemplate(ctx context.Context, t *domain.PollTemplate) error
+    // GetTemplate returns a template; domain.ErrTemplateNotFound if it does not exist.
+    GetTemplate(ctx context.Context, id uint) (*domain.PollTemplate, error)
+    ListTemplates(ctx context.Context, offset, limit int) ([]domain.PollTemplate, error)
+    // DeleteTemplate removes a template; domain.ErrTemplateNotFound if it does not exist.
+    DeleteTemplate(ctx context.Context, id uint) error
+    // DueTemplates lists the templates whose NextRunAt is at or before now, earliest first.
+    DueTemplates(ctx context.Context, now time.Time) ([]domain.PollTemplate, error)
+    // AdvanceTemplate sets a due template's NextRunAt to next (nil for a zero next) and its
+    // LastRunAt to now. It reports false, changing nothing, unless NextRunAt is at or before now,
+    // so that of several schedulers racing for the same run exactly one wins.
+    AdvanceTemplate(ctx context.Context, id uint, now, next time.Time) (bool, error)
+
+    CreateCollection(ctx context.Context, c *domain.Collection) error
+    // GetCollection returns a collection with the IDs of its live polls; domain.ErrCollectionNotFound
+    // if it does not exist.
+    GetCollection(ctx context.Context, id uint) (*domain.Collection, error)
+    ListCollections(ctx context.Context, offset, limit int) ([]domain.Collection, error)
+    // UpdateCollection saves the settings of c (everything but its status, polls and timestamps).
+    UpdateCollection(ctx context.Context, c *domain.Collection) error
+    // SetCollectionStatus moves a collection from status from to status to, reporting false and
+    // changing nothing if it is not in status from, so that racing transitions happen once.
+    SetCollectionStatus(ctx context.Context, id uint, from, to domain.CollectionStatus) (bool, error)
+    // DueCollections lists scheduled collections whose OpensAt and open ones whose ClosesAt is at
+    // or before now.
+    DueCollections(ctx context.Context, now time.Time) ([]domain.Collection, error)
+    // DeleteCollection removes a collection and takes its polls out of it; domain.ErrCollectionNotFound
+    // if it does not exist.
+    DeleteCollection(ctx context.Context, id uint) error
+}
+
+// SurveyTally counts a survey's submissions, those answering every question, and answers per question ID.
+type SurveyTally struct {
+    Submissions int
+    Complete    int
+    Answered    map[uint]int
+}
+
+// ConsistencyReport counts rows that reference a poll that no longer exists, or (for votes and
+// ballots) an option that is missing or belongs to another poll.
+type ConsistencyReport struct {
+    OrphanOptions       int
+    OrphanVotes         int
+    OrphanBallots       int
+    OrphanParticipation int
+    Repaired            bool // the counted rows have been deleted
+}
+
+// Screening is the outcome of running a vote through a VoteScreener.
+type Screening struct {
+    Score   float64
+    Flagged bool
+    Reasons []string
+}
+
+// VoteScreener scores a vote against recent activity in its poll and decides whether to quarantine it.
+type VoteScreener interface {
+    // Lookback is how far back the service loads recent votes for Screen.
+    Lookback() time.Duration
+    Screen(ctx context.Context, v domain.Vote, recent []domain.Vote) Screening
+}
+
+// ChallengeIssuer issues and checks proof-of-work challenges for polls that require them.
+// It keeps no record of spent tokens; the service spends them through PollRepository.SpendChallenge.
+type ChallengeIssuer interface {
+    Issue(pollID uint, difficulty int) (domain.Challenge, error)
+    // Verify returns the key that identifies a good token and when the token expires, or
+    // domain.ErrChallengeRequired or a wrapped domain.ErrChallengeInvalid.
+    Verify(pollID uint, difficulty int, token, solution string) (key string, expiresAt time.Time, err error)
+}
+
+// BlobStore keeps uploaded files, such as option images, under opaque slash-separated keys.
+type BlobStore interface {
+    Put(ctx context.Context, key string, r io.Reader) error
+    // Open returns an error wrapping fs.ErrNotExist for unknown keys.
+    Open(ctx context.Context, key string) (io.ReadCloser, error)
+    Exists(ctx context.Context, key string) (bool, error)
+}
+
+// Thumbnail is a downscaled preview of an uploaded image plus the source image's dimensions.
+type Thumbnail struct {
+    Data        []byte
+    ContentType string
+    Width       int
+    Height      int
+}
+
+// Thumbnailer renders previews for uploaded images of a sniffed content type.
+type Thumbnailer interface {
+    Thumbnail(data []byte, contentType string) (Thumbnail, error)
+}
+
+// ScheduleParser evaluates the schedule expressions of poll templates.
+type ScheduleParser interface {
+    // Next returns the first time later than after that expr fires; an error if expr is invalid
+    // or never fires.
+    Next(expr string, after time.Time) (time.Time, error)
+}
+
+// TermCounter turns the responses of a text poll into term frequencies for a word cloud.
+type TermCounter interface {
+    // Supports reports whether language (e.g. "en") is known; the empty string means the default.
+    Supports(language string) bool
+    // CountTerms normalizes texts for language and returns the most frequent terms, most frequent first.
+    CountTerms(texts []string, language string) []domain.TermCount
 }
 
 // ResultsStreamer pushes results updates for a poll.
@@ -26,6 +225,33 @@ type ResultsStreamer interface {
     Subscribe(pollID uint) (<-chan domain.Results, func())
 }
 
+// CollectionStreamer pushes updates for a collection.
+type CollectionStreamer interface {
+    BroadcastCollection(collectionID uint, u domain.CollectionUpdate)
+    SubscribeCollection(collectionID uint) (<-chan domain.CollectionUpdate, func())
+}
+
+// LeaderboardStreamer pushes leaderboard updates for a quiz.
+type LeaderboardStreamer interface {
+    BroadcastLeaderboard(quizID uint, lb domain.Leaderboard)
+    SubscribeLeaderboard(quizID uint) (<-chan domain.Leaderboard, func())
+}
+
+// ReactionStreamer pushes reaction bursts for a poll.
+type ReactionStreamer interface {
+    BroadcastReactions(pollID uint, b domain.ReactionBurst)
+    SubscribeReactions(pollID uint) (<-chan domain.ReactionBurst, func())
+}
+
+// ReactionWindow aggregates reactions in memory over a rolling window.
+type ReactionWindow interface {
+    Add(pollID uint, emoji string, at time.Time)
+    Burst(pollID uint, now time.Time) domain.ReactionBurst
+    // Bursts reports every poll with reactions in the window. A poll that has gone quiet is
+    // reported once more, with empty counts, and then forgotten.
+    Bursts(now time.Time) []domain.ReactionBurst
+}
+
 // WebhookDispatcher dispatches signed webhook events.
 type WebhookDispatcher interface {
     Dispatch(ctx context.Context, event string, payload any) error
@@ -0,0 +1,86 @@
+package app
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// MaxCreditBudget caps the credits a quadratic poll gives each voter.
+const MaxCreditBudget = 10000
+
+// Credits reports how a voter has spent their budget in a quadratic poll.
+func (s *Service) Credits(ctx context.Context, pollID uint, userID string) (domain.CreditAccount, error) {
+    p, err := s.repo.GetByID(ctx, pollID)
+    if err != nil {
+        return domain.CreditAccount{}, fmt.Errorf("get poll: %w", err)
+    }
+    if p.CreditBudget == 0 {
+        return domain.CreditAccount{}, errors.New("poll does not use quadratic voting")
+    }
+    vs, err := s.repo.ListVoterVotes(ctx, pollID, userID)
+    if err != nil {
+        return domain.CreditAccount{}, fmt.Errorf("list votes: %w", err)
+    }
+    acc := domain.CreditAccount{PollID: pollID, UserID: userID, Budget: p.CreditBudget, Votes: map[uint]int{}}
+    for _, v := range vs {
+        acc.Votes[v.OptionID] = v.Weight
+        acc.Spent += v.Weight * v.Weight
+    }
+    acc.Remaining = acc.Budget - acc.Spent
+    return acc, nil
+}
+
+// allocateCredits turns v into the voter's allocation of n votes to v.OptionID, replacing any
+// earlier allocation to that option; n = 0 withdraws it. All of the voter's allocations together
+// may cost at most the poll's budget. The caller holds the poll lock.
+func allocateCredits(ctx context.Context, tx PollRepository, p *domain.Poll, v *domain.Vote, n int) error {
+    if v.UserID == "" {
+        return errors.New("user_id required for quadratic poll")
+    }
+    if n < 0 {
+        return fmt.Errorf("votes must not be negative: %w", domain.ErrInvalidVote)
+    }
+    vs, err := tx.ListVoterVotes(ctx, p.ID, v.UserID)
+    if err != nil {
+        return fmt.Errorf("list votes: %w", err)
+    }
+    spent := n * n
+    var prev *domain.Vote
+    for _, other := range vs {
+        if other.OptionID == v.OptionID {
+            prev = &other
+            continue
+        }
+        spent += other.Weight * other.Weight
+    }
+    if spent > p.CreditBudget {
+        return fmt.Errorf("%d votes would cost %d of %d credits: %w", n, spent, p.CreditBudget, domain.ErrInsufficientCredits)
+    }
+    if prev != nil {
+        if err := tx.DeleteVote(ctx, p.ID, prev.ID); err != nil {
+            return fmt.Errorf("replace allocation: %w", err)
+        }
+    }
+    v.Weight = n
+    return nil
+}
+
+// countCredits adds the credits spent per option to the results of a quadratic poll.
+func (s *Service) countCredits(ctx context.Context, p *domain.Poll, res *domain.Results) error {
+    vs, err := s.repo.ListVotes(ctx, p.ID, domain.VoteCounted)
+    if err != nil {
+        return fmt.Errorf("list votes: %w", err)
+    }
+    credits := map[uint]int{}
+    for _, v := range vs {
+        credits[v.OptionID] += v.Weight * v.Weight
+        res.Credits += v.Weight * v.Weight
+    }
+    for i := range res.Options {
+        res.Options[i].Credits = credits[res.Options[i].OptionID]
+    }
+    return nil
+}
@@ -0,0 +1,277 @@
+package app
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// DefaultQuizPoints is what a question is worth when created without Points.
+const DefaultQuizPoints = 1000
+
+// DefaultQuizTimeLimitSeconds applies to questions created without a time limit.
+const DefaultQuizTimeLimitSeconds = 30
+
+// MaxQuizTimeLimitSeconds caps a question's time limit.
+const MaxQuizTimeLimitSeconds = 3600
+
+// CreateQuiz stores a quiz; each question becomes a poll whose options keep their Correct flags.
+func (s *Service) CreateQuiz(ctx context.Context, in domain.Quiz) (*domain.Quiz, error) {
+    if in.Title == "" || len(in.Questions) == 0 {
+        return nil, errors.New("invalid quiz: title and questions required")
+    }
+    q := domain.Quiz{Title: in.Title, Description: in.Description}
+    for i, qq := range in.Questions {
+        if strings.TrimSpace(qq.Prompt) == "" {
+            return nil, fmt.Errorf("invalid quiz: question %d: prompt required", i+1)
+        }
+        if qq.Points < 0 || qq.TimeLimitSeconds < 0 || qq.TimeLimitSeconds > MaxQuizTimeLimitSeconds {
+            return nil, fmt.Errorf("invalid quiz: question %d: points must not be negative and the time limit must be between 0 and %d seconds", i+1, MaxQuizTimeLimitSeconds)
+        }
+        out := domain.QuizQuestion{Prompt: qq.Prompt, Points: qq.Points, TimeLimitSeconds: qq.TimeLimitSeconds}
+        if out.Points == 0 {
+            out.Points = DefaultQuizPoints
+        }
+        if out.TimeLimitSeconds == 0 {
+            out.TimeLimitSeconds = DefaultQuizTimeLimitSeconds
+        }
+        correct := false
+        for _, o := range qq.Options {
+            if err := validateOption(o); err != nil {
+                return nil, fmt.Errorf("question %d: %w", i+1, err)
+            }
+            correct = correct || o.Correct
+            out.Options = append(out.Options, domain.Option{Text: o.Text, Description: o.Description, ImageURL: o.ImageURL, Color: o.Color, Metadata: o.Metadata, Correct: o.Correct})
+        }
+        if !correct {
+            return nil, fmt.Errorf("invalid quiz: question %d: at least one option must be correct", i+1)
+        }
+        q.Questions = append(q.Questions, out)
+    }
+    if err := s.repo.CreateQuiz(ctx, &q); err != nil {
+        return nil, fmt.Errorf("create quiz: %w", err)
+    }
+    return s.GetQuiz(ctx, q.ID)
+}
+
+// GetQuiz returns a quiz with the correct options of unrevealed questions hidden.
+func (s *Service) GetQuiz(ctx context.Context, id uint) (*domain.Quiz, error) {
+    q, err := s.repo.GetQuiz(ctx, id)
+    if err != nil {
+        return nil, fmt.Errorf("get quiz: %w", err)
+    }
+    hideQuizAnswers(q)
+    return q, nil
+}
+
+func (s *Service) ListQuizzes(ctx context.Context, offset, limit int) ([]domain.Quiz, error) {
+    qs, err := s.repo.ListQuizzes(ctx, offset, limit)
+    if err != nil {
+        return nil, fmt.Errorf("list quizzes: %w", err)
+    }
+    for i := range qs {
+        hideQuizAnswers(&qs[i])
+    }
+    return qs, nil
+}
+
+// OpenQuestion starts the clock on a question: answers are taken from now until its time limit.
+func (s *Service) OpenQuestion(ctx context.Context, quizID, questionID uint) (*domain.Quiz, error) {
+    err := s.repo.WithTx(ctx, func(tx PollRepository) error {
+        q, err := tx.GetQuiz(ctx, quizID)
+        if err != nil {
+            return fmt.Errorf("get quiz: %w", err)
+        }
+        qq := findQuizQuestion(q, questionID)
+        if qq == nil {
+            return fmt.Errorf("question %d: %w", questionID, domain.ErrQuizNotFound)
+        }
+        // serializes with answers and reveals, which lock the question's poll
+        if _, err := tx.GetForUpdate(ctx, qq.PollID); err != nil {
+            return fmt.Errorf("get question poll: %w", err)
+        }
+        if qq.Revealed {
+            return errors.New("question already revealed")
+        }
+        if qq.OpenedAt != nil {
+            return errors.New("question already open")
+        }
+        if err := tx.OpenQuizQuestion(ctx, quizID, questionID, s.now()); err != nil {
+            return fmt.Errorf("open question: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return s.GetQuiz(ctx, quizID)
+}
+
+// RevealQuestion closes a question's poll, which stops answers and reveals its correct options
+// in the quiz, the poll's results and the leaderboard scores.
+func (s *Service) RevealQuestion(ctx context.Context, quizID, questionID uint) (*domain.Quiz, error) {
+    q, err := s.repo.GetQuiz(ctx, quizID)
+    if err != nil {
+        return nil, fmt.Errorf("get quiz: %w", err)
+    }
+    qq := findQuizQuestion(q, questionID)
+    if qq == nil {
+        return nil, fmt.Errorf("question %d: %w", questionID, domain.ErrQuizNotFound)
+    }
+    if !qq.Revealed {
+        if _, err := s.ClosePoll(ctx, qq.PollID, 0); err != nil {
+            return nil, err
+        }
+    }
+    return s.GetQuiz(ctx, quizID)
+}
+
+// Leaderboard ranks participants by score, then by the time they took for correct answers.
+func (s *Service) Leaderboard(ctx context.Context, quizID uint) (domain.Leaderboard, error) {
+    q, err := s.repo.GetQuiz(ctx, quizID)
+    if err != nil {
+        return domain.Leaderboard{}, fmt.Errorf("get quiz: %w", err)
+    }
+    lb := domain.Leaderboard{QuizID: quizID, Questions: len(q.Questions), Entries: []domain.LeaderboardEntry{}}
+    entries := map[string]*domain.LeaderboardEntry{}
+    for _, qq := range q.Questions {
+        if qq.Revealed {
+            lb.Revealed++
+        }
+        vs, err := s.repo.ListVotes(ctx, qq.PollID, domain.VoteCounted)
+        if err != nil {
+            return domain.Leaderboard{}, fmt.Errorf("list answers: %w", err)
+        }
+        for _, v := range vs {
+            e := entries[v.UserID]
+            if e == nil {
+                e = &domain.LeaderboardEntry{UserID: v.UserID}
+                entries[v.UserID] = e
+            }
+            e.Answered++
+            if !qq.Revealed || qq.OpenedAt == nil || !isCorrect(qq, v.OptionID) {
+                continue
+            }
+            took := v.CreatedAt.Sub(*qq.OpenedAt)
+            e.Correct++
+            e.Score += quizScore(qq, took)
+            e.ResponseTime += took
+        }
+    }
+    for _, e := range entries {
+        lb.Entries = append(lb.Entries, *e)
+    }
+    sort.Slice(lb.Entries, func(i, j int) bool {
+        a, b := lb.Entries[i], lb.Entries[j]
+        if a.Score != b.Score {
+            return a.Score > b.Score
+        }
+        if a.ResponseTime != b.ResponseTime {
+            return a.ResponseTime < b.ResponseTime
+        }
+        return a.UserID < b.UserID
+    })
+    for i := range lb.Entries {
+        lb.Entries[i].Rank = i + 1
+        if prev := i - 1; prev >= 0 && lb.Entries[prev].Score == lb.Entries[i].Score && lb.Entries[prev].ResponseTime == lb.Entries[i].ResponseTime {
+            lb.Entries[i].Rank = lb.Entries[prev].Rank
+        }
+    }
+    return lb, nil
+}
+
+// checkQuizAnswer enforces the rules of a quiz question: one answer per identified participant,
+// given between opening the question and its time limit.
+func (s *Service) checkQuizAnswer(ctx context.Context, tx PollRepository, p *domain.Poll, v *domain.Vote) error {
+    if v.UserID == "" {
+        return errors.New("user_id required to answer a quiz question")
+    }
+    q, err := tx.GetQuiz(ctx, p.QuizID)
+    if err != nil {
+        return fmt.Errorf("get quiz: %w", err)
+    }
+    var qq *domain.QuizQuestion
+    for i := range q.Questions {
+        if q.Questions[i].PollID == p.ID {
+            qq = &q.Questions[i]
+        }
+    }
+    if qq == nil {
+        return fmt.Errorf("poll %d: %w", p.ID, domain.ErrQuizNotFound)
+    }
+    if qq.OpenedAt == nil || v.CreatedAt.Sub(*qq.OpenedAt) > time.Duration(qq.TimeLimitSeconds)*time.Second {
+        return domain.ErrQuestionNotOpen
+    }
+    answered, err := tx.HasVoted(ctx, p.ID, v.UserID)
+    if err != nil {
+        return err
+    }
+    if answered {
+        return domain.ErrAlreadyVoted
+    }
+    return nil
+}
+
+func (s *Service) publishLeaderboard(ctx context.Context, quizID uint) error {
+    if s.leaderboards == nil {
+        return nil
+    }
+    lb, err := s.Leaderboard(ctx, quizID)
+    if err != nil {
+        return err
+    }
+    s.leaderboards.BroadcastLeaderboard(quizID, lb)
+    return nil
+}
+
+// quizScore awards the question's points for an instant answer, falling linearly to half of them
+// at the time limit.
+func quizScore(qq domain.QuizQuestion, took time.Duration) int {
+    limit := time.Duration(qq.TimeLimitSeconds) * time.Second
+    took = min(max(took, 0), limit)
+    return qq.Points - int(int64(qq.Points)*int64(took)/int64(2*limit))
+}
+
+func isCorrect(qq domain.QuizQuestion, optionID uint) bool {
+    for _, o := range qq.Options {
+        if o.ID == optionID {
+            return o.Correct
+        }
+    }
+    return false
+}
+
+func findQuizQuestion(q *domain.Quiz, id uint) *domain.QuizQuestion {
+    for i := range q.Questions {
+        if q.Questions[i].ID == id {
+            return &q.Questions[i]
+        }
+    }
+    return nil
+}
+
+// hideAnswers clears the Correct flags of a quiz poll's options until the poll is closed.
+func hideAnswers(p *domain.Poll) {
+    if p.QuizID == 0 || p.Status == domain.PollClosed {
+        return
+    }
+    for i := range p.Options {
+        p.Options[i].Correct = false
+    }
+}
+
+func hideQuizAnswers(q *domain.Quiz) {
+    for i := range q.Questions {
+        if q.Questions[i].Revealed {
+            continue
+        }
+        for j := range q.Questions[i].Options {
+            q.Questions[i].Options[j].Correct = false
+        }
+    }
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "slices"
+    "strings"
+
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// DefaultReactionEmojis is the reaction set when none is configured.
+var DefaultReactionEmojis = []string{"👍", "❤️", "😂", "😮", "👏", "🎉"}
+
+// ReactionEmojis lists the accepted reactions; empty when reactions are disabled.
+func (s *Service) ReactionEmojis() []string {
+    if s.reactions == nil {
+        return []string{}
+    }
+    return s.emojis
+}
+
+// React counts an emoji reaction to a poll. Reactions are only aggregated in memory and show up
+// in the poll's next reaction burst.
+func (s *Service) React(ctx context.Context, pollID uint, emoji string) error {
+    if s.reactions == nil {
+        return errors.New("reactions are disabled")
+    }
+    if !slices.Contains(s.emojis, emoji) {
+        return fmt.Errorf("%w %q: use one of %s", domain.ErrUnsupportedReaction, emoji, strings.Join(s.emojis, " "))
+    }
+    if _, err := s.repo.GetByID(ctx, pollID); err != nil {
+        return fmt.Errorf("get poll: %w", err)
+    }
+    s.reactions.Add(pollID, emoji, s.now())
+    return nil
+}
+
+// Reactions reports a poll's reactions over the current window.
+func (s *Service) Reactions(ctx context.Context, pollID uint) (domain.ReactionBurst, error) {
+    if s.reactions == nil {
+        return domain.ReactionBurst{}, errors.New("reactions are disabled")
+    }
+    if _, err := s.repo.GetByID(ctx, pollID); err != nil {
+        return domain.ReactionBurst{}, fmt.Errorf("get poll: %w", err)
+    }
+    return s.reactions.Burst(pollID, s.now()), nil
+}
+
+// PublishReactionBursts broadcasts the burst of every poll with recent reactions, plus a final
+// empty one for polls that went quiet. Call it once a second.
+func (s *Service) PublishReactionBursts() {
+    if s.reactions == nil || s.reactionFeed == nil {
+        return
+    }
+    for _, b := range s.reactions.Bursts(s.now()) {
+        s.reactionFeed.BroadcastReactions(b.PollID, b)
+    }
+}
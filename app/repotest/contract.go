@@ -0,0 +1,732 @@
+// Package repotest holds the behavioural contract every app.PollRepository implementation must satisfy.
+// Call Run from a test in the implementation's package with a factory that returns an empty repository:
+//
+//    func TestRepoContract(t *testing.T) {
+//        repotest.Run(t, func(t *testing.T) app.PollRepository { return newEmptyRepo(t) })
+//    }
+package repotest
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/robjsliwa/pulse/app"
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// Factory returns a fresh, empty repository for one subtest.
+type Factory func(t *testing.T) app.PollRepository
+
+// Run executes the contract as subtests of t.
+func Run(t *testing.T, newRepo Factory) {
+    t.Helper()
+    for _, tc := range []struct {
+        name string
+        fn   func(t *testing.T, r app.PollRepository)
+    }{
+        {"CreateAndGet", testCreateAndGet},
+        {"Update", testUpdate},
+        {"UpdateRejectsStaleVersion", testUpdateRejectsStaleVersion},
+        {"ListNewestFirst", testListNewestFirst},
+        {"Delete", testDelete},
+        {"TrashAndRestore", testTrashAndRestore},
+        {"PurgeDeleted", testPurgeDeleted},
+        {"Options", testOptions},
+        {"OptionEditAndReorder", testOptionEditAndReorder},
+        {"RichOptions", testRichOptions},
+        {"OptionVotesReassignAndDelete", testOptionVotesReassignAndDelete},
+        {"VotesAndCounts", testVotesAndCounts},
+        {"AnonymousVotes", testAnonymousVotes},
+        {"VotesReferenceOptionOfTheirPoll", testVotesReferenceOptionOfTheirPoll},
+        {"VoteModeration", testVoteModeration},
+        {"TextVotes", testTextVotes},
+        {"WeightedVotes", testWeightedVotes},
+        {"QuadraticVotes", testQuadraticVotes},
+        {"Surveys", testSurveys},
+        {"Quizzes", testQuizzes},
+        {"Templates", testTemplates},
+        {"Series", testSeries},
+        {"Collections", testCollections},
+        {"SpentChallenges", testSpentChallenges},
+        {"WithTxRollsBack", testWithTxRollsBack},
+        {"PollLockSerializesCloseAndVote", testPollLockSerializesCloseAndVote},
+        {"NoVoteLandsAfterClose", testNoVoteLandsAfterClose},
+    } {
+        t.Run(tc.name, func(t *testing.T) { tc.fn(t, newRepo(t)) })
+    }
+}
+
+func seedPoll(t *testing.T, r app.PollRepository, title string, opts ...string) *domain.Poll {
+    t.Helper()
+    p := &domain.Poll{Title: title, Status: domain.PollOpen}
+    for _, o := range opts { p.Options = append(p.Options, domain.Option{Text: o}) }
+    if err := r.Create(context.Background(), p); err != nil { t.Fatalf("create: %v", err) }
+    if p.ID == 0 { t.Fatalf("create: no ID assigned") }
+    got, err := r.GetByID(context.Background(), p.ID)
+    if err != nil { t.Fatalf("get: %v", err) }
+    return got
+}
+
+// countVotes is CountVotesByOption by headcount.
+func countVotes(ctx context.Context, r app.PollRepository, pollID uint) (map[uint]int, int, error) {
+    t, err := r.CountVotesByOption(ctx, pollID)
+    return t.Votes, t.Total, err
+}
+
+func testCreateAndGet(t *testing.T, r app.PollRepository) {
+    p := seedPoll(t, r, "lunch", "pizza", "tacos")
+    if p.Title != "lunch" || p.Status != domain.PollOpen { t.Fatalf("got %+v", p) }
+    if len(p.Options) != 2 || p.Options[0].Text != "pizza" || p.Options[1].Text != "tacos" { t.Fatalf("options: %+v", p.Options) }
+    for _, o := range p.Options {
+        if o.ID == 0 || o.PollID != p.ID { t.Fatalf("option not linked: %+v", o) }
+    }
+    if _, err := r.GetByID(context.Background(), p.ID+1000); !errors.Is(err, domain.ErrPollNotFound) { t.Fatalf("missing poll: got %v, want ErrPollNotFound", err) }
+}
+
+func testUpdate(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    p := seedPoll(t, r, "before", "a")
+    p.Title, p.Description, p.ImageURL, p.Threshold, p.Status = "after", "desc", "/media/sha256/ab/ab.png", 5, domain.PollClosed
+    if err := r.Update(ctx, p); err != nil { t.Fatalf("update: %v", err) }
+    got, err := r.GetByID(ctx, p.ID)
+    if err != nil { t.Fatalf("get: %v", err) }
+    if got.Title != "after" || got.Description != "desc" || got.ImageURL != p.ImageURL || got.Threshold != 5 || got.Status != domain.PollClosed {
+        t.Fatalf("update not persisted: %+v", got)
+    }
+}
+
+func testUpdateRejectsStaleVersion(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    p := seedPoll(t, r, "v", "a")
+    if p.Version != 1 { t.Fatalf("new poll version: %d", p.Version) }
+    stale := *p
+    p.Title = "first"
+    if err := r.Update(ctx, p); err != nil { t.Fatalf("update: %v", err) }
+    if p.Version != 2 { t.Fatalf("version after update: %d", p.Version) }
+    stale.Title = "second"
+    if err := r.Update(ctx, &stale); !errors.Is(err, domain.ErrVersionConflict) { t.Fatalf("stale update: got %v, want ErrVersionConflict", err) }
+    got, _ := r.GetByID(ctx, p.ID)
+    if got.Title != "first" || got.Version != 2 { t.Fatalf("after stale update: %+v", got) }
+}
+
+func testListNewestFirst(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    a := seedPoll(t, r, "a", "x")
+    b := seedPoll(t, r, "b", "x")
+    c := seedPoll(t, r, "c", "x")
+    ps, err := r.List(ctx, 0, 0)
+    if err != nil { t.Fatalf("list: %v", err) }
+    if len(ps) != 3 || ps[0].ID != c.ID || ps[1].ID != b.ID || ps[2].ID != a.ID { t.Fatalf("order: %+v", ps) }
+    if len(ps[0].Options) != 1 { t.Fatalf("options not loaded: %+v", ps[0]) }
+    page, err := r.List(ctx, 1, 1)
+    if err != nil { t.Fatalf("list page: %v", err) }
+    if len(page) != 1 || page[0].ID != b.ID { t.Fatalf("page: %+v", page) }
+}
+
+func testDelete(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    p := seedPoll(t, r, "gone", "x")
+    if err := r.Delete(ctx, p.ID); err != nil { t.Fatalf("delete: %v", err) }
+    if _, err := r.GetByID(ctx, p.ID); !errors.Is(err, domain.ErrPollNotFound) { t.Fatalf("after delete: got %v, want ErrPollNotFound", err) }
+}
+
+func testTrashAndRestore(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    kept := seedPoll(t, r, "kept", "x")
+    p := seedPoll(t, r, "trashed", "x")
+    if err := r.Restore(ctx, p.ID); !errors.Is(err, domain.ErrPollNotFound) { t.Fatalf("restore live poll: got %v, want ErrPollNotFound", err) }
+    if err := r.Delete(ctx, p.ID); err != nil { t.Fatalf("delete: %v", err) }
+    live, err := r.List(ctx, 0, 0)
+    if err != nil { t.Fatalf("list: %v", err) }
+    if len(live) != 1 || live[0].ID != kept.ID { t.Fatalf("list after delete: %+v", live) }
+    trash, err := r.ListDeleted(ctx, 0, 0)
+    if err != nil { t.Fatalf("list deleted: %v", err) }
+    if len(trash) != 1 || trash[0].ID != p.ID || trash[0].DeletedAt == nil || len(trash[0].Options) != 1 { t.Fatalf("trash: %+v", trash) }
+    if err := r.Restore(ctx, p.ID); err != nil { t.Fatalf("restore: %v", err) }
+    got, err := r.GetByID(ctx, p.ID)
+    if err != nil { t.Fatalf("get restored: %v", err) }
+    if got.DeletedAt != nil || len(got.Options) != 1 { t.Fatalf("restored: %+v", got) }
+    if trash, _ = r.ListDeleted(ctx, 0, 0); len(trash) != 0 { t.Fatalf("trash after restore: %+v", trash) }
+}
+
+func testPurgeDeleted(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    p := seedPoll(t, r, "purged", "a")
+    keep := seedPoll(t, r, "trashed later", "a")
+    if err := r.CreateVote(ctx, &domain.Vote{PollID: p.ID, OptionID: p.Options[0].ID, UserID: "u1"}); err != nil { t.Fatalf("vote: %v", err) }
+    if err := anonymousVote(ctx, r, p.ID, p.Options[0].ID, "u2"); err != nil { t.Fatalf("anonymous vote: %v", err) }
+    if err := r.Delete(ctx, p.ID); err != nil { t.Fatalf("delete: %v", err) }
+    if n, err := r.PurgeDeleted(ctx, time.Now().Add(-time.Hour)); err != nil || n != 0 { t.Fatalf("purge before retention: n=%d err=%v", n, err) }
+    if err := r.Delete(ctx, keep.ID); err != nil { t.Fatalf("delete: %v", err) }
+    cutoff := time.Now().Add(time.Hour)
+    if n, err := r.PurgeDeleted(ctx, cutoff); err != nil || n != 2 { t.Fatalf("purge: n=%d err=%v", n, err) }
+    if err := r.Restore(ctx, p.ID); !errors.Is(err, domain.ErrPollNotFound) { t.Fatalf("restore purged: got %v, want ErrPollNotFound", err) }
+    votes, err := r.ListVotes(ctx, p.ID, "")
+    if err != nil { t.Fatalf("list votes: %v", err) }
+    if len(votes) != 0 { t.Fatalf("votes survived purge: %+v", votes) }
+    if _, total, err := countVotes(ctx, r, p.ID); err != nil || total != 0 { t.Fatalf("counts after purge: total=%d err=%v", total, err) }
+    if trash, _ := r.ListDeleted(ctx, 0, 0); len(trash) != 0 { t.Fatalf("trash after purge: %+v", trash) }
+}
+
+func testOptions(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    p := seedPoll(t, r, "opts", "a")
+    opt := &domain.Option{PollID: p.ID, Text: "b"}
+    if err := r.AddOption(ctx, opt); err != nil { t.Fatalf("add option: %v", err) }
+    if opt.ID == 0 { t.Fatalf("add option: no ID assigned") }
+    opts, err := r.ListOptions(ctx, p.ID)
+    if err != nil { t.Fatalf("list options: %v", err) }
+    if len(opts) != 2 || opts[1].Text != "b" { t.Fatalf("options: %+v", opts) }
+}
+
+func testOptionEditAndReorder(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    p := seedPoll(t, r, "edit", "a", "bb", "c")
+    other := seedPoll(t, r, "other", "z")
+    for i, o := range p.Options {
+        if o.Position != i { t.Fatalf("option %q position %d, want %d", o.Text, o.Position, i) }
+    }
+    opt := &domain.Option{ID: p.Options[1].ID, PollID: p.ID, Text: "b"}
+    if err := r.UpdateOption(ctx, opt); err != nil { t.Fatalf("update option: %v", err) }
+    if opt.Text != "b" || opt.Position != 1 { t.Fatalf("updated option: %+v", opt) }
+    if err := r.UpdateOption(ctx, &domain.Option{ID: other.Options[0].ID, PollID: p.ID, Text: "x"}); !errors.Is(err, domain.ErrOptionNotFound) { t.Fatalf("update other poll's option: got %v, want ErrOptionNotFound", err) }
+    a, b, c := p.Options[0].ID, p.Options[1].ID, p.Options[2].ID
+    if err := r.ReorderOptions(ctx, p.ID, []uint{c, a, b}); err != nil { t.Fatalf("reorder: %v", err) }
+    opts, err := r.ListOptions(ctx, p.ID)
+    if err != nil { t.Fatalf("list options: %v", err) }
+    if len(opts) != 3 || opts[0].ID != c || opts[1].ID != a || opts[2].Text != "b" { t.Fatalf("reordered options: %+v", opts) }
+    got, err := r.GetByID(ctx, p.ID)
+    if err != nil { t.Fatalf("get: %v", err) }
+    if got.Options[0].ID != c || got.Options[0].Position != 0 { t.Fatalf("poll options not in position order: %+v", got.Options) }
+    if err := r.ReorderOptions(ctx, p.ID, []uint{other.Options[0].ID}); !errors.Is(err, domain.ErrOptionNotFound) { t.Fatalf("reorder with foreign option: got %v, want ErrOptionNotFound", err) }
+}
+
+func testRichOptions(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    rich := domain.Option{Text: "a", Description: "first", ImageURL: "https://img.test/a.png", Color: "#ff0000", Metadata: json.RawMessage(`{"sku":"A-1"}`)}
+    p := &domain.Poll{Title: "rich", Status: domain.PollOpen, Options: []domain.Option{rich, {Text: "plain"}}}
+    if err := r.Create(ctx, p); err != nil { t.Fatalf("create: %v", err) }
+    got, err := r.GetByID(ctx, p.ID)
+    if err != nil { t.Fatalf("get: %v", err) }
+    o := got.Options[0]
+    if o.Description != rich.Description || o.ImageURL != rich.ImageURL || o.Color != rich.Color || string(o.Metadata) != string(rich.Metadata) { t.Fatalf("rich option: %+v", o) }
+    if got.Options[1].Metadata != nil { t.Fatalf("plain option metadata: %q", got.Options[1].Metadata) }
+    o.Description, o.Color, o.Metadata = "changed", "#00f", json.RawMessage(`[1,2]`)
+    if err := r.UpdateOption(ctx, &o); err != nil { t.Fatalf("update option: %v", err) }
+    added := &domain.Option{PollID: p.ID, Text: "c", Position: 2, ImageURL: "/media/options/x.png", Metadata: json.RawMessage(`{"n":3}`)}
+    if err := r.AddOption(ctx, added); err != nil { t.Fatalf("add option: %v", err) }
+    opts, err := r.ListOptions(ctx, p.ID)
+    if err != nil { t.Fatalf("list options: %v", err) }
+    if opts[0].Description != "changed" || opts[0].Color != "#00f" || string(opts[0].Metadata) != "[1,2]" || opts[0].ImageURL != rich.ImageURL { t.Fatalf("updated option: %+v", opts[0]) }
+    if len(opts) != 3 || opts[2].ImageURL != added.ImageURL || string(opts[2].Metadata) != `{"n":3}` { t.Fatalf("added option: %+v", opts) }
+}
+
+func testOptionVotesReassignAndDelete(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    p := seedPoll(t, r, "reassign", "a", "b", "c")
+    a, b, c := p.Options[0].ID, p.Options[1].ID, p.Options[2].ID
+    for _, v := range []domain.Vote{{OptionID: a, UserID: "u1"}, {OptionID: a, UserID: "u2", Status: domain.VoteFlagged}, {OptionID: b, UserID: "u3"}} {
+        v.PollID = p.ID
+        if err := r.CreateVote(ctx, &v); err != nil { t.Fatalf("vote: %v", err) }
+    }
+    if err := anonymousVote(ctx, r, p.ID, a, "u4"); err != nil { t.Fatalf("anonymous vote: %v", err) }
+    if n, err := r.CountOptionVotes(ctx, p.ID, a); err != nil || n != 3 { t.Fatalf("count option votes: n=%d err=%v, want 3", n, err) }
+    if n, err := r.ReassignVotes(ctx, p.ID, a, c); err != nil || n != 3 { t.Fatalf("reassign: n=%d err=%v, want 3", n, err) }
+    if n, _ := r.CountOptionVotes(ctx, p.ID, a); n != 0 { t.Fatalf("votes left on reassigned option: %d", n) }
+    if err := r.DeleteOption(ctx, p.ID, a); err != nil { t.Fatalf("delete option: %v", err) }
+    counts, total, err := countVotes(ctx, r, p.ID)
+    if err != nil { t.Fatalf("count: %v", err) }
+    if total != 3 || counts[c] != 2 || counts[b] != 1 { t.Fatalf("after reassign: counts=%v total=%d", counts, total) }
+    if err := r.DeleteOption(ctx, p.ID, b); err != nil { t.Fatalf("delete option with votes: %v", err) }
+    if counts, total, _ = countVotes(ctx, r, p.ID); total != 2 || counts[b] != 0 { t.Fatalf("after discard: counts=%v total=%d", counts, total) }
+    if err := r.DeleteOption(ctx, p.ID, b); !errors.Is(err, domain.ErrOptionNotFound) { t.Fatalf("delete twice: got %v, want ErrOptionNotFound", err) }
+    if opts, _ := r.ListOptions(ctx, p.ID); len(opts) != 1 || opts[0].ID != c { t.Fatalf("options after delete: %+v", opts) }
+}
+
+func testVotesAndCounts(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    p := seedPoll(t, r, "votes", "a", "b")
+    other := seedPoll(t, r, "other", "z")
+    a, b := p.Options[0].ID, p.Options[1].ID
+    now := time.Now()
+    for _, v := range []domain.Vote{
+        {PollID: p.ID, OptionID: a, UserID: "u1", CreatedAt: now},
+        {PollID: p.ID, OptionID: a, UserID: "u2", CreatedAt: now},
+        {PollID: p.ID, OptionID: b, UserID: "u3", CreatedAt: now},
+        {PollID: p.ID, OptionID: b, UserID: "u4", Status: domain.VoteFlagged, CreatedAt: now},
+        {PollID: other.ID, OptionID: other.Options[0].ID, CreatedAt: now},
+    } {
+        v := v
+        if err := r.CreateVote(ctx, &v); err != nil { t.Fatalf("create vote: %v", err) }
+        if v.ID == 0 { t.Fatalf("create vote: no ID assigned") }
+    }
+    counts, total, err := countVotes(ctx, r, p.ID)
+    if err != nil { t.Fatalf("count: %v", err) }
+    if total != 3 || counts[a] != 2 || counts[b] != 1 { t.Fatalf("counts: %v total %d (flagged votes must not count)", counts, total) }
+
+    all, err := r.ListVotes(ctx, p.ID, "")
+    if err != nil { t.Fatalf("list votes: %v", err) }
+    if len(all) != 4 || all[0].UserID != "u1" || all[0].Status != domain.VoteCounted { t.Fatalf("votes: %+v", all) }
+    recent, err := r.RecentVotes(ctx, p.ID, now.Add(-time.Minute))
+    if err != nil { t.Fatalf("recent votes: %v", err) }
+    if len(recent) != 4 { t.Fatalf("recent: %d votes", len(recent)) }
+    if old, _ := r.RecentVotes(ctx, p.ID, now.Add(time.Minute)); len(old) != 0 { t.Fatalf("recent after now: %d votes", len(old)) }
+    for _, tc := range []struct {
+        pollID uint
+        userID string
+        want   bool
+    }{{p.ID, "u1", true}, {p.ID, "u4", true}, {p.ID, "u5", false}, {other.ID, "u1", false}} {
+        got, err := r.HasVoted(ctx, tc.pollID, tc.userID)
+        if err != nil || got != tc.want { t.Fatalf("HasVoted(%d, %q) = %v, %v; want %v", tc.pollID, tc.userID, got, err, tc.want) }
+        vs, err := r.ListVoterVotes(ctx, tc.pollID, tc.userID)
+        if err != nil { t.Fatalf("list voter votes: %v", err) }
+        if tc.want != (len(vs) == 1) { t.Fatalf("ListVoterVotes(%d, %q) = %+v", tc.pollID, tc.userID, vs) }
+        for _, v := range vs {
+            if v.PollID != tc.pollID || v.UserID != tc.userID { t.Fatalf("ListVoterVotes(%d, %q) = %+v", tc.pollID, tc.userID, vs) }
+        }
+    }
+}
+
+func testAnonymousVotes(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    p := seedPoll(t, r, "secret", "a", "b")
+    a := p.Options[0].ID
+    if err := anonymousVote(ctx, r, p.ID, a, "u1"); err != nil { t.Fatalf("anonymous vote: %v", err) }
+    err := anonymousVote(ctx, r, p.ID, a, "u1")
+    if !errors.Is(err, domain.ErrAlreadyVoted) { t.Fatalf("repeat voter: got %v, want ErrAlreadyVoted", err) }
+    if err := anonymousVote(ctx, r, p.ID, a, "u2"); err != nil { t.Fatalf("anonymous vote: %v", err) }
+    // concurrent first votes of one voter: one ballot, the rest ErrAlreadyVoted
+    errs := make(chan error, 8)
+    var wg sync.WaitGroup
+    for i := 0; i < cap(errs); i++ {
+        wg.Add(1)
+        go func() { defer wg.Done(); errs <- anonymousVote(ctx, r, p.ID, a, "u3") }()
+    }
+    wg.Wait()
+    close(errs)
+    accepted := 0
+    for err := range errs {
+        switch {
+        case err == nil: accepted++
+        case !errors.Is(err, domain.ErrAlreadyVoted): t.Fatalf("concurrent repeat voter: got %v, want ErrAlreadyVoted", err)
+        }
+    }
+    if accepted != 1 { t.Fatalf("concurrent repeat voter: %d ballots accepted", accepted) }
+    counts, total, err := countVotes(ctx, r, p.ID)
+    if err != nil { t.Fatalf("count: %v", err) }
+    if total != 3 || counts[a] != 3 { t.Fatalf("counts: %v total %d", counts, total) }
+    vs, err := r.ListVotes(ctx, p.ID, "")
+    if err != nil { t.Fatalf("list votes: %v", err) }
+    if len(vs) != 0 { t.Fatalf("anonymous ballots exposed per voter: %+v", vs) }
+    // undoing participation lets the voter vote again
+    if err := r.DeleteParticipation(ctx, p.ID, "u2"); err != nil { t.Fatalf("delete participation: %v", err) }
+    if err := r.CreateParticipation(ctx, p.ID, "u2"); err != nil { t.Fatalf("participation after undo: %v", err) }
+    if err := r.CreateParticipation(ctx, p.ID, "u1"); !errors.Is(err, domain.ErrAlreadyVoted) { t.Fatalf("repeat voter after another's undo: got %v", err) }
+}
+
+// anonymousVote records participation and then the ballot, as the service does.
+func anonymousVote(ctx context.Context, r app.PollRepository, pollID, optionID uint, userID string) error {
+    if err := r.CreateParticipation(ctx, pollID, userID); err != nil { return err }
+    return r.CreateBallot(ctx, pollID, optionID)
+}
+
+func testVotesReferenceOptionOfTheirPoll(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    p := seedPoll(t, r, "mine", "a")
+    other := seedPoll(t, r, "theirs", "z")
+    foreign := other.Options[0].ID
+    if err := r.CreateVote(ctx, &domain.Vote{PollID: p.ID, OptionID: foreign, UserID: "u1"}); err == nil { t.Fatalf("vote with another poll's option accepted") }
+    if err := r.CreateVote(ctx, &domain.Vote{PollID: p.ID, OptionID: foreign + 1000, UserID: "u1"}); err == nil { t.Fatalf("vote with unknown option accepted") }
+    if err := r.CreateBallot(ctx, p.ID, foreign); err == nil { t.Fatalf("ballot with another poll's option accepted") }
+    if err := anonymousVote(ctx, r, p.ID, p.Options[0].ID, "u1"); err != nil { t.Fatalf("anonymous vote: %v", err) }
+    rep, err := r.CheckConsistency(ctx, false)
+    if err != nil { t.Fatalf("check consistency: %v", err) }
+    if rep != (app.ConsistencyReport{}) { t.Fatalf("healthy store reported orphans: %+v", rep) }
+}
+
+func testVoteModeration(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    p := seedPoll(t, r, "mod", "a")
+    a := p.Options[0].ID
+    v1 := &domain.Vote{PollID: p.ID, OptionID: a, Status: domain.VoteFlagged, FlagReason: "burst", CreatedAt: time.Now()}
+    v2 := &domain.Vote{PollID: p.ID, OptionID: a, Status: domain.VoteFlagged, CreatedAt: time.Now()}
+    for _, v := range []*domain.Vote{v1, v2} {
+        if err := r.CreateVote(ctx, v); err != nil { t.Fatalf("create vote: %v", err) }
+    }
+    flagged, err := r.ListVotes(ctx, p.ID, domain.VoteFlagged)
+    if err != nil || len(flagged) != 2 || flagged[0].FlagReason != "burst" { t.Fatalf("flagged: %+v %v", flagged, err) }
+    if got, err := r.GetVote(ctx, p.ID, v1.ID); err != nil || got.ID != v1.ID || got.Status != domain.VoteFlagged || got.FlagReason != "burst" { t.Fatalf("get vote: %+v %v", got, err) }
+    if _, err := r.GetVote(ctx, p.ID+1000, v1.ID); !errors.Is(err, domain.ErrVoteNotFound) { t.Fatalf("get vote of another poll: got %v", err) }
+    if err := r.SetVoteStatus(ctx, p.ID, v1.ID, domain.VoteCounted); err != nil { t.Fatalf("accept: %v", err) }
+    if err := r.DeleteVote(ctx, p.ID, v2.ID); err != nil { t.Fatalf("purge: %v", err) }
+    if _, total, _ := countVotes(ctx, r, p.ID); total != 1 { t.Fatalf("total after moderation: %d", total) }
+    if err := r.SetVoteStatus(ctx, p.ID+1000, v1.ID, domain.VoteCounted); !errors.Is(err, domain.ErrVoteNotFound) { t.Fatalf("wrong poll: got %v", err) }
+    if err := r.DeleteVote(ctx, p.ID, v2.ID); !errors.Is(err, domain.ErrVoteNotFound) { t.Fatalf("double purge: got %v", err) }
+}
+
+func testTextVotes(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    if p := seedPoll(t, r, "choice", "a"); p.Kind != domain.PollChoice { t.Fatalf("default kind: %q", p.Kind) }
+    p := &domain.Poll{Title: "words", Kind: domain.PollText, Language: "de", Status: domain.PollOpen}
+    if err := r.Create(ctx, p); err != nil { t.Fatalf("create: %v", err) }
+    got, err := r.GetByID(ctx, p.ID)
+    if err != nil || got.Kind != domain.PollText || got.Language != "de" || len(got.Options) != 0 { t.Fatalf("text poll: %+v %v", got, err) }
+    nps := &domain.Poll{Title: "recommend?", Kind: domain.PollNPS, Scale: 11, Status: domain.PollOpen, Options: []domain.Option{{Text: "0"}, {Text: "1"}}}
+    if err := r.Create(ctx, nps); err != nil { t.Fatalf("create nps: %v", err) }
+    if got, err := r.GetByID(ctx, nps.ID); err != nil || got.Kind != domain.PollNPS || got.Scale != 11 || len(got.Options) != 2 { t.Fatalf("nps poll: %+v %v", got, err) }
+    v1 := &domain.Vote{PollID: p.ID, Text: "gutes Essen", UserID: "u1", CreatedAt: time.Now()}
+    v2 := &domain.Vote{PollID: p.ID, Text: "zu laut", UserID: "u2", CreatedAt: time.Now()}
+    for _, v := range []*domain.Vote{v1, v2} {
+        if err := r.CreateVote(ctx, v); err != nil { t.Fatalf("create text vote: %v", err) }
+    }
+    if err := r.SetVoteStatus(ctx, p.ID, v2.ID, domain.VoteHidden); err != nil { t.Fatalf("hide: %v", err) }
+    shown, err := r.ListVotes(ctx, p.ID, domain.VoteCounted)
+    if err != nil || len(shown) != 1 || shown[0].Text != "gutes Essen" || shown[0].OptionID != 0 { t.Fatalf("counted responses: %+v %v", shown, err) }
+    all, _ := r.ListVotes(ctx, p.ID, "")
+    if len(all) != 2 || all[1].Status != domain.VoteHidden { t.Fatalf("all responses: %+v", all) }
+    if counts, total, _ := countVotes(ctx, r, p.ID); total != 0 || len(counts) != 0 { t.Fatalf("text votes counted as options: %v total %d", counts, total) }
+    rep, err := r.CheckConsistency(ctx, false)
+    if err != nil { t.Fatalf("check consistency: %v", err) }
+    if rep != (app.ConsistencyReport{}) { t.Fatalf("text votes reported as orphans: %+v", rep) }
+}
+
+func testWeightedVotes(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    p := &domain.Poll{Title: "dividend", Status: domain.PollOpen, Weighted: true, ThresholdBasis: domain.ThresholdWeight, Options: []domain.Option{{Text: "yes"}, {Text: "no"}}}
+    if err := r.Create(ctx, p); err != nil { t.Fatalf("create: %v", err) }
+    got, err := r.GetByID(ctx, p.ID)
+    if err != nil || !got.Weighted || got.ThresholdBasis != domain.ThresholdWeight { t.Fatalf("weighted poll: %+v %v", got, err) }
+    yes, no := got.Options[0].ID, got.Options[1].ID
+    if err := r.SetElectorate(ctx, p.ID, []domain.Voter{{UserID: "b", Weight: 30}, {UserID: "a", Weight: 70}}); err != nil { t.Fatalf("set electorate: %v", err) }
+    if err := r.SetElectorate(ctx, p.ID, []domain.Voter{{UserID: "b", Weight: 25}, {UserID: "a", Weight: 70}, {UserID: "c", Weight: 5}}); err != nil { t.Fatalf("replace electorate: %v", err) }
+    vs, err := r.ListElectorate(ctx, p.ID)
+    if err != nil || len(vs) != 3 || vs[0].UserID != "a" || vs[1].Weight != 25 { t.Fatalf("electorate: %+v %v", vs, err) }
+    if v, err := r.GetVoter(ctx, p.ID, "c"); err != nil || v.Weight != 5 { t.Fatalf("get voter: %+v %v", v, err) }
+    if _, err := r.GetVoter(ctx, p.ID, "z"); !errors.Is(err, domain.ErrNotInElectorate) { t.Fatalf("outsider: got %v", err) }
+    for _, v := range []*domain.Vote{{PollID: p.ID, OptionID: yes, UserID: "a", Weight: 70}, {PollID: p.ID, OptionID: no, UserID: "b", Weight: 25}, {PollID: p.ID, OptionID: no}} {
+        if err := r.CreateVote(ctx, v); err != nil { t.Fatalf("create vote: %v", err) }
+    }
+    if err := r.CreateVote(ctx, &domain.Vote{PollID: p.ID, OptionID: yes, UserID: "c", Weight: 5, Status: domain.VoteFlagged}); err != nil { t.Fatalf("create flagged vote: %v", err) }
+    tally, err := r.CountVotesByOption(ctx, p.ID)
+    if err != nil { t.Fatalf("count: %v", err) }
+    if tally.Total != 3 || tally.Votes[no] != 2 || tally.TotalWeight != 96 || tally.Weights[yes] != 70 || tally.Weights[no] != 26 { t.Fatalf("tally: %+v", tally) }
+    if all, _ := r.ListVotes(ctx, p.ID, domain.VoteCounted); len(all) != 3 || all[0].Weight != 70 || all[2].Weight != 1 { t.Fatalf("stored weights: %+v", all) }
+    if err := r.SetElectorate(ctx, p.ID, nil); err != nil { t.Fatalf("clear electorate: %v", err) }
+    if vs, _ := r.ListElectorate(ctx, p.ID); len(vs) != 0 { t.Fatalf("cleared electorate: %+v", vs) }
+}
+
+func testQuadraticVotes(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    p := &domain.Poll{Title: "budget", Status: domain.PollOpen, CreditBudget: 25, Options: []domain.Option{{Text: "parks"}, {Text: "roads"}}}
+    if err := r.Create(ctx, p); err != nil { t.Fatalf("create: %v", err) }
+    got, err := r.GetByID(ctx, p.ID)
+    if err != nil || got.CreditBudget != 25 { t.Fatalf("quadratic poll: %+v %v", got, err) }
+    parks, roads := got.Options[0].ID, got.Options[1].ID
+    first := &domain.Vote{PollID: p.ID, OptionID: parks, UserID: "a", Weight: 3}
+    for _, v := range []*domain.Vote{first, {PollID: p.ID, OptionID: roads, UserID: "a", Weight: 4}} {
+        if err := r.CreateVote(ctx, v); err != nil { t.Fatalf("create vote: %v", err) }
+    }
+    // reallocation replaces the voter's row for the option
+    if err := r.DeleteVote(ctx, p.ID, first.ID); err != nil { t.Fatalf("delete allocation: %v", err) }
+    if err := r.CreateVote(ctx, &domain.Vote{PollID: p.ID, OptionID: parks, UserID: "a", Weight: 2}); err != nil { t.Fatalf("reallocate: %v", err) }
+    tally, err := r.CountVotesByOption(ctx, p.ID)
+    if err != nil || tally.Total != 2 || tally.Weights[parks] != 2 || tally.Weights[roads] != 4 || tally.TotalWeight != 6 { t.Fatalf("tally: %+v %v", tally, err) }
+}
+
+func testSurveys(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    s := &domain.Survey{Title: "retro", Status: domain.PollOpen, Questions: []domain.Question{
+        {Kind: domain.QuestionChoice, Prompt: "mood", Required: true, Options: []domain.Option{{Text: "good"}, {Text: "bad"}}},
+        {Kind: domain.QuestionText, Prompt: "went well"},
+    }}
+    if err := r.CreateSurvey(ctx, s); err != nil { t.Fatalf("create survey: %v", err) }
+    if s.ID == 0 || s.Questions[0].ID == 0 || s.Questions[0].PollID == 0 || s.Questions[1].PollID != 0 { t.Fatalf("created: %+v", s) }
+    got, err := r.GetSurvey(ctx, s.ID)
+    if err != nil { t.Fatalf("get survey: %v", err) }
+    if got.Title != "retro" || got.Status != domain.PollOpen || len(got.Questions) != 2 { t.Fatalf("got %+v", got) }
+    choice, text := got.Questions[0], got.Questions[1]
+    if choice.Prompt != "mood" || !choice.Required || choice.Position != 0 || text.Kind != domain.QuestionText || text.Position != 1 { t.Fatalf("questions: %+v", got.Questions) }
+    if len(choice.Options) != 2 || choice.Options[0].Text != "good" || choice.Options[1].Text != "bad" || len(text.Options) != 0 { t.Fatalf("question options: %+v", got.Questions) }
+    p, err := r.GetByID(ctx, choice.PollID)
+    if err != nil || p.SurveyID != s.ID || p.Title != "mood" || len(p.Options) != 2 { t.Fatalf("question poll: %+v %v", p, err) }
+
+    subs := []*domain.SurveySubmission{
+        {SurveyID: s.ID, UserID: "u1", Answers: []domain.SurveyAnswer{{QuestionID: choice.ID, OptionID: choice.Options[0].ID}, {QuestionID: text.ID, Text: "pairing"}}},
+        {SurveyID: s.ID, UserID: "u2", Answers: []domain.SurveyAnswer{{QuestionID: choice.ID, OptionID: choice.Options[1].ID}}},
+        {SurveyID: s.ID, Answers: []domain.SurveyAnswer{{QuestionID: choice.ID, OptionID: choice.Options[1].ID}}},
+        {SurveyID: s.ID, Answers: []domain.SurveyAnswer{{QuestionID: choice.ID, OptionID: choice.Options[1].ID}, {QuestionID: text.ID, Text: "demos"}}},
+    }
+    for _, sub := range subs {
+        if err := r.CreateSurveySubmission(ctx, sub); err != nil { t.Fatalf("submit: %v", err) }
+        if sub.ID == 0 || sub.CreatedAt.IsZero() { t.Fatalf("submission not stored: %+v", sub) }
+    }
+    again := &domain.SurveySubmission{SurveyID: s.ID, UserID: "u1", Answers: []domain.SurveyAnswer{{QuestionID: choice.ID, OptionID: choice.Options[0].ID}}}
+    if err := r.CreateSurveySubmission(ctx, again); !errors.Is(err, domain.ErrAlreadySubmitted) { t.Fatalf("second submission: got %v, want ErrAlreadySubmitted", err) }
+    tally, err := r.TallySurvey(ctx, s.ID)
+    if err != nil { t.Fatalf("tally: %v", err) }
+    if tally.Submissions != 4 || tally.Complete != 2 || tally.Answered[choice.ID] != 4 || tally.Answered[text.ID] != 2 { t.Fatalf("tally: %+v", tally) }
+    texts, err := r.ListTextAnswers(ctx, text.ID, 1)
+    if err != nil || len(texts) != 1 || texts[0] != "demos" { t.Fatalf("text answers: %v %v", texts, err) }
+
+    if err := r.SetSurveyStatus(ctx, s.ID, domain.PollClosed); err != nil { t.Fatalf("close: %v", err) }
+    if got, _ := r.GetSurveyForUpdate(ctx, s.ID); got == nil || got.Status != domain.PollClosed { t.Fatalf("closed survey: %+v", got) }
+    if list, err := r.ListSurveys(ctx, 0, 10); err != nil || len(list) != 1 || len(list[0].Questions) != 2 { t.Fatalf("list surveys: %+v %v", list, err) }
+    if _, err := r.GetSurvey(ctx, s.ID+1000); !errors.Is(err, domain.ErrSurveyNotFound) { t.Fatalf("missing survey: got %v", err) }
+    if err := r.SetSurveyStatus(ctx, s.ID+1000, domain.PollClosed); !errors.Is(err, domain.ErrSurveyNotFound) { t.Fatalf("close missing survey: got %v", err) }
+}
+
+func testQuizzes(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    q := &domain.Quiz{Title: "trivia", Questions: []domain.QuizQuestion{
+        {Prompt: "2+2", Points: 500, TimeLimitSeconds: 20, Options: []domain.Option{{Text: "4", Correct: true}, {Text: "5"}}},
+        {Prompt: "capital of France", Points: 1000, TimeLimitSeconds: 30, Options: []domain.Option{{Text: "Lyon"}, {Text: "Paris", Correct: true}}},
+    }}
+    if err := r.CreateQuiz(ctx, q); err != nil { t.Fatalf("create quiz: %v", err) }
+    if q.ID == 0 || q.Questions[0].ID == 0 || q.Questions[0].PollID == 0 || q.Questions[1].Position != 1 { t.Fatalf("created: %+v", q) }
+    got, err := r.GetQuiz(ctx, q.ID)
+    if err != nil { t.Fatalf("get quiz: %v", err) }
+    if got.Title != "trivia" || len(got.Questions) != 2 { t.Fatalf("got %+v", got) }
+    first, second := got.Questions[0], got.Questions[1]
+    if first.Prompt != "2+2" || first.Points != 500 || first.TimeLimitSeconds != 20 || first.OpenedAt != nil || first.Revealed { t.Fatalf("first question: %+v", first) }
+    if len(second.Options) != 2 || second.Options[0].Correct || !second.Options[1].Correct || second.Options[1].Text != "Paris" { t.Fatalf("second question options: %+v", second.Options) }
+    p, err := r.GetByID(ctx, first.PollID)
+    if err != nil || p.QuizID != q.ID || p.Title != "2+2" || len(p.Options) != 2 || !p.Options[0].Correct { t.Fatalf("question poll: %+v %v", p, err) }
+
+    at := time.Now().UTC().Truncate(time.Second)
+    if err := r.OpenQuizQuestion(ctx, q.ID, first.ID, at); err != nil { t.Fatalf("open: %v", err) }
+    if err := r.OpenQuizQuestion(ctx, q.ID+1000, first.ID, at); !errors.Is(err, domain.ErrQuizNotFound) { t.Fatalf("open in wrong quiz: got %v", err) }
+    p.Status = domain.PollClosed
+    if err := r.Update(ctx, p); err != nil { t.Fatalf("close question poll: %v", err) }
+    got, _ = r.GetQuiz(ctx, q.ID)
+    if got.Questions[0].OpenedAt == nil || !got.Questions[0].OpenedAt.Equal(at) || !got.Questions[0].Revealed || got.Questions[1].OpenedAt != nil || got.Questions[1].Revealed { t.Fatalf("opened and revealed: %+v", got.Questions) }
+    if list, err := r.ListQuizzes(ctx, 0, 10); err != nil || len(list) != 1 || len(list[0].Questions) != 2 { t.Fatalf("list quizzes: %+v %v", list, err) }
+    if _, err := r.GetQuiz(ctx, q.ID+1000); !errors.Is(err, domain.ErrQuizNotFound) { t.Fatalf("missing quiz: got %v", err) }
+}
+
+func testTemplates(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    now := time.Now().UTC().Truncate(time.Second)
+    due, later := now.Add(-time.Minute), now.Add(time.Hour)
+    meta := json.RawMessage(`{"emoji":"sun"}`)
+    daily := &domain.PollTemplate{Name: "standup", Schedule: "0 9 * * 1", Series: true, NextRunAt: &due, Variables: map[string]string{"team": "core"},
+        Poll: domain.Poll{Title: "{{team}} mood {{date}}", Threshold: 5, Anonymous: true, Options: []domain.Option{{Text: "good", Color: "#0f0", Metadata: meta}, {Text: "bad"}}}}
+    adhoc := &domain.PollTemplate{Name: "nps", Poll: domain.Poll{Kind: domain.PollNPS, Title: "How likely?"}}
+    for _, tp := range []*domain.PollTemplate{daily, adhoc} {
+        if err := r.CreateTemplate(ctx, tp); err != nil { t.Fatalf("create template: %v", err) }
+    }
+    if daily.ID == 0 || daily.CreatedAt.IsZero() { t.Fatalf("created: %+v", daily) }
+    got, err := r.GetTemplate(ctx, daily.ID)
+    if err != nil { t.Fatalf("get template: %v", err) }
+    if got.Name != "standup" || got.Schedule != "0 9 * * 1" || !got.Series || got.Variables["team"] != "core" || got.NextRunAt == nil || !got.NextRunAt.Equal(due) || got.LastRunAt != nil { t.Fatalf("got %+v", got) }
+    if got.Poll.Title != "{{team}} mood {{date}}" || got.Poll.Threshold != 5 || !got.Poll.Anonymous || len(got.Poll.Options) != 2 || got.Poll.Options[0].Color != "#0f0" || string(got.Poll.Options[0].Metadata) != string(meta) { t.Fatalf("template poll: %+v", got.Poll) }
+    if got, _ := r.GetTemplate(ctx, adhoc.ID); got.Poll.Kind != domain.PollNPS || got.NextRunAt != nil || got.Variables != nil { t.Fatalf("unscheduled template: %+v", got) }
+    if list, err := r.ListTemplates(ctx, 0, 10); err != nil || len(list) != 2 || list[0].ID != adhoc.ID { t.Fatalf("list templates: %+v %v", list, err) }
+
+    ds, err := r.DueTemplates(ctx, now)
+    if err != nil || len(ds) != 1 || ds[0].ID != daily.ID { t.Fatalf("due: %+v %v", ds, err) }
+    if ok, err := r.AdvanceTemplate(ctx, daily.ID, now, later); err != nil || !ok { t.Fatalf("advance: %v %v", ok, err) }
+    // a second scheduler racing for the same run loses
+    if ok, err := r.AdvanceTemplate(ctx, daily.ID, now, later); err != nil || ok { t.Fatalf("second advance: %v %v", ok, err) }
+    if ds, _ := r.DueTemplates(ctx, now); len(ds) != 0 { t.Fatalf("due after advance: %+v", ds) }
+    got, _ = r.GetTemplate(ctx, daily.ID)
+    if !got.NextRunAt.Equal(later) || got.LastRunAt == nil || !got.LastRunAt.Equal(now) { t.Fatalf("advanced: %+v", got) }
+    if ok, _ := r.AdvanceTemplate(ctx, daily.ID, later, time.Time{}); !ok { t.Fatal("advance to no next run") }
+    if got, _ := r.GetTemplate(ctx, daily.ID); got.NextRunAt != nil { t.Fatalf("schedule switched off: %+v", got) }
+
+    if err := r.DeleteTemplate(ctx, daily.ID); err != nil { t.Fatalf("delete: %v", err) }
+    if err := r.DeleteTemplate(ctx, daily.ID); !errors.Is(err, domain.ErrTemplateNotFound) { t.Fatalf("delete twice: got %v", err) }
+    if _, err := r.GetTemplate(ctx, daily.ID); !errors.Is(err, domain.ErrTemplateNotFound) { t.Fatalf("deleted template: got %v", err) }
+}
+
+func testSeries(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    first := seedPoll(t, r, "mood", "good", "bad")
+    first.SeriesID = 7
+    if err := r.Update(ctx, first); err != nil { t.Fatalf("link first instance: %v", err) }
+    seedPoll(t, r, "unrelated", "x")
+    second := &domain.Poll{Title: "mood 2", Status: domain.PollOpen, SeriesID: 7, Options: []domain.Option{{Text: "good"}, {Text: "bad"}}}
+    if err := r.Create(ctx, second); err != nil { t.Fatalf("create second instance: %v", err) }
+    if got, _ := r.GetByID(ctx, second.ID); got.SeriesID != 7 { t.Fatalf("series of created poll: %+v", got) }
+    ps, err := r.ListSeries(ctx, 7)
+    if err != nil || len(ps) != 2 || ps[0].ID != first.ID || ps[1].ID != second.ID || len(ps[1].Options) != 2 { t.Fatalf("list series: %+v %v", ps, err) }
+    if err := r.Delete(ctx, first.ID); err != nil { t.Fatalf("trash: %v", err) }
+    if ps, _ := r.ListSeries(ctx, 7); len(ps) != 1 { t.Fatalf("series without trashed instance: %+v", ps) }
+    if ps, err := r.ListSeries(ctx, 8); err != nil || len(ps) != 0 { t.Fatalf("empty series: %+v %v", ps, err) }
+}
+
+func testCollections(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    now := time.Now().UTC().Truncate(time.Second)
+    opens, closes := now.Add(-time.Minute), now.Add(time.Hour)
+    c := &domain.Collection{Name: "devconf", Status: domain.CollectionScheduled, OpensAt: &opens, ClosesAt: &closes, Access: domain.AccessIdentified, WebhookScope: domain.WebhookCollection}
+    if err := r.CreateCollection(ctx, c); err != nil { t.Fatalf("create collection: %v", err) }
+    other := &domain.Collection{Name: "meetup", Status: domain.CollectionOpen, Access: domain.AccessPublic, WebhookScope: domain.WebhookPolls}
+    if err := r.CreateCollection(ctx, other); err != nil { t.Fatalf("create collection: %v", err) }
+    talk1, talk2 := seedPoll(t, r, "talk 1", "a"), seedPoll(t, r, "talk 2", "b")
+    seedPoll(t, r, "elsewhere", "c")
+    for _, p := range []*domain.Poll{talk2, talk1} {
+        p.CollectionID = c.ID
+        if err := r.Update(ctx, p); err != nil { t.Fatalf("add to collection: %v", err) }
+    }
+    got, err := r.GetCollection(ctx, c.ID)
+    if err != nil { t.Fatalf("get collection: %v", err) }
+    if got.Name != "devconf" || got.Status != domain.CollectionScheduled || got.Access != domain.AccessIdentified || got.WebhookScope != domain.WebhookCollection || !got.OpensAt.Equal(opens) || !got.ClosesAt.Equal(closes) { t.Fatalf("got %+v", got) }
+    if len(got.PollIDs) != 2 || got.PollIDs[0] != talk1.ID || got.PollIDs[1] != talk2.ID { t.Fatalf("poll IDs: %v", got.PollIDs) }
+    if ps, err := r.ListCollectionPolls(ctx, c.ID); err != nil || len(ps) != 2 || ps[0].ID != talk1.ID || ps[0].CollectionID != c.ID || len(ps[0].Options) != 1 { t.Fatalf("collection polls: %+v %v", ps, err) }
+    if list, err := r.ListCollections(ctx, 0, 10); err != nil || len(list) != 2 || list[0].ID != other.ID || len(list[1].PollIDs) != 2 { t.Fatalf("list collections: %+v %v", list, err) }
+
+    got.Name, got.Access, got.ClosesAt = "DevConf", domain.AccessPublic, nil
+    if err := r.UpdateCollection(ctx, got); err != nil { t.Fatalf("update collection: %v", err) }
+    if got, _ := r.GetCollection(ctx, c.ID); got.Name != "DevConf" || got.Access != domain.AccessPublic || got.ClosesAt != nil || got.Status != domain.CollectionScheduled { t.Fatalf("updated: %+v", got) }
+
+    ds, err := r.DueCollections(ctx, now)
+    if err != nil || len(ds) != 1 || ds[0].ID != c.ID { t.Fatalf("due: %+v %v", ds, err) }
+    if ok, err := r.SetCollectionStatus(ctx, c.ID, domain.CollectionScheduled, domain.CollectionOpen); err != nil || !ok { t.Fatalf("open: %v %v", ok, err) }
+    if ok, _ := r.SetCollectionStatus(ctx, c.ID, domain.CollectionScheduled, domain.CollectionOpen); ok { t.Fatal("second transition from scheduled succeeded") }
+    if ds, _ := r.DueCollections(ctx, now); len(ds) != 0 { t.Fatalf("due after opening: %+v", ds) }
+
+    talk1.Status, talk1.ClosedByCollection = domain.PollClosed, true
+    if err := r.Update(ctx, talk1); err != nil { t.Fatalf("close by collection: %v", err) }
+    if p, _ := r.GetByID(ctx, talk1.ID); !p.ClosedByCollection { t.Fatalf("closed by collection not kept: %+v", p) }
+
+    if err := r.Delete(ctx, talk2.ID); err != nil { t.Fatalf("trash: %v", err) }
+    if got, _ := r.GetCollection(ctx, c.ID); len(got.PollIDs) != 1 { t.Fatalf("trashed poll still listed: %v", got.PollIDs) }
+    if err := r.DeleteCollection(ctx, c.ID); err != nil { t.Fatalf("delete collection: %v", err) }
+    if err := r.DeleteCollection(ctx, c.ID); !errors.Is(err, domain.ErrCollectionNotFound) { t.Fatalf("delete twice: got %v", err) }
+    if _, err := r.GetCollection(ctx, c.ID); !errors.Is(err, domain.ErrCollectionNotFound) { t.Fatalf("deleted collection: got %v", err) }
+    if p, _ := r.GetByID(ctx, talk1.ID); p.CollectionID != 0 || p.ClosedByCollection { t.Fatalf("poll still in deleted collection: %+v", p) }
+    if err := r.Restore(ctx, talk2.ID); err != nil { t.Fatalf("restore: %v", err) }
+    if p, _ := r.GetByID(ctx, talk2.ID); p.CollectionID != 0 { t.Fatalf("trashed poll still in deleted collection: %+v", p) }
+}
+
+func testSpentChallenges(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    now := time.Now().UTC().Truncate(time.Second)
+    if err := r.SpendChallenge(ctx, "a", now.Add(time.Minute), now); err != nil { t.Fatalf("spend: %v", err) }
+    if err := r.SpendChallenge(ctx, "a", now.Add(time.Minute), now); !errors.Is(err, domain.ErrChallengeInvalid) { t.Fatalf("spend twice: got %v, want ErrChallengeInvalid", err) }
+    // a spend that rolls back leaves the token unspent
+    boom := errors.New("boom")
+    err := r.WithTx(ctx, func(tx app.PollRepository) error {
+        if err := tx.SpendChallenge(ctx, "b", now.Add(time.Minute), now); err != nil { return err }
+        return boom
+    })
+    if !errors.Is(err, boom) { t.Fatalf("WithTx: got %v", err) }
+    if err := r.SpendChallenge(ctx, "b", now.Add(time.Minute), now); err != nil { t.Fatalf("spend after rollback: %v", err) }
+    // once expired a record is swept, by any later spend
+    later := now.Add(2 * time.Minute)
+    if err := r.SpendChallenge(ctx, "c", later.Add(time.Minute), later); err != nil { t.Fatalf("spend: %v", err) }
+    if err := r.SpendChallenge(ctx, "a", later.Add(time.Minute), later); err != nil { t.Fatalf("spend an expired key: %v", err) }
+    // concurrent spends of one token: exactly one wins
+    errs := make(chan error, 8)
+    var wg sync.WaitGroup
+    for i := 0; i < cap(errs); i++ {
+        wg.Add(1)
+        go func() { defer wg.Done(); errs <- r.SpendChallenge(ctx, "d", now.Add(time.Minute), now) }()
+    }
+    wg.Wait()
+    close(errs)
+    spent := 0
+    for err := range errs {
+        switch {
+        case err == nil: spent++
+        case !errors.Is(err, domain.ErrChallengeInvalid): t.Fatalf("concurrent spend: got %v", err)
+        }
+    }
+    if spent != 1 { t.Fatalf("concurrent spend: %d succeeded", spent) }
+}
+
+func testWithTxRollsBack(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    p := seedPoll(t, r, "tx", "a")
+    boom := errors.New("boom")
+    err := r.WithTx(ctx, func(tx app.PollRepository) error {
+        if err := tx.AddOption(ctx, &domain.Option{PollID: p.ID, Text: "b"}); err != nil { return err }
+        return boom
+    })
+    if !errors.Is(err, boom) { t.Fatalf("WithTx: got %v, want fn's error", err) }
+    opts, err := r.ListOptions(ctx, p.ID)
+    if err != nil { t.Fatalf("list options: %v", err) }
+    if len(opts) != 1 { t.Fatalf("rolled back option persisted: %+v", opts) }
+}
+
+// testPollLockSerializesCloseAndVote is the close-vs-vote race: a vote unit of work checks the poll is
+// open and inserts while a close unit of work runs concurrently. The close must wait for the vote.
+func testPollLockSerializesCloseAndVote(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    p := seedPoll(t, r, "race", "a")
+    locked := make(chan struct{})
+    closed := make(chan error, 1)
+    go func() {
+        <-locked
+        closed <- r.WithTx(ctx, func(tx app.PollRepository) error {
+            q, err := tx.GetForUpdate(ctx, p.ID)
+            if err != nil { return err }
+            q.Status = domain.PollClosed
+            return tx.Update(ctx, q)
+        })
+    }()
+    err := r.WithTx(ctx, func(tx app.PollRepository) error {
+        q, err := tx.GetForUpdate(ctx, p.ID)
+        if err != nil { return err }
+        close(locked)
+        time.Sleep(100 * time.Millisecond) // give the closer every chance to interleave
+        again, err := tx.GetByID(ctx, p.ID)
+        if err != nil { return err }
+        if q.Status != domain.PollOpen || again.Status != domain.PollOpen { return errors.New("poll closed while locked") }
+        return tx.CreateVote(ctx, &domain.Vote{PollID: p.ID, OptionID: p.Options[0].ID, CreatedAt: time.Now()})
+    })
+    if err != nil { t.Fatalf("vote unit of work: %v", err) }
+    if err := <-closed; err != nil { t.Fatalf("close unit of work: %v", err) }
+    got, err := r.GetByID(ctx, p.ID)
+    if err != nil || got.Status != domain.PollClosed { t.Fatalf("poll after close: %+v %v", got, err) }
+    if _, total, _ := countVotes(ctx, r, p.ID); total != 1 { t.Fatalf("total: %d", total) }
+}
+
+type nopStream struct{}
+
+func (nopStream) Broadcast(uint, domain.Results)                {}
+func (nopStream) Subscribe(uint) (<-chan domain.Results, func()) { return nil, func() {} }
+
+type nopWebhooks struct{}
+
+func (nopWebhooks) Dispatch(context.Context, string, any) error { return nil }
+
+// testNoVoteLandsAfterClose drives the service: voters keep voting until the poll refuses them while
+// ClosePoll runs. Once ClosePoll has returned the vote count must not move.
+func testNoVoteLandsAfterClose(t *testing.T, r app.PollRepository) {
+    ctx := context.Background()
+    svc := app.NewService(r, nopStream{}, nopWebhooks{})
+    p := seedPoll(t, r, "race", "a", "b")
+    const voters = 8
+    started := make(chan struct{}, voters)
+    errs := make(chan error, voters)
+    var wg sync.WaitGroup
+    for i := 0; i < voters; i++ {
+        wg.Add(1)
+        go func(i int) {
+            defer wg.Done()
+            for n := 0; ; n++ {
+                _, err := svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: p.Options[i%2].ID}, nil)
+                if n == 0 { started <- struct{}{} }
+                if err != nil { errs <- err; return }
+            }
+        }(i)
+    }
+    for i := 0; i < voters; i++ { <-started }
+    if _, err := svc.ClosePoll(ctx, p.ID, 0); err != nil { t.Fatalf("close: %v", err) }
+    _, atClose, err := countVotes(ctx, r, p.ID)
+    if err != nil { t.Fatalf("count: %v", err) }
+    wg.Wait()
+    close(errs)
+    for err := range errs {
+        if err.Error() != "poll is closed" { t.Fatalf("voter stopped on %v, want the closed poll's refusal", err) }
+    }
+    _, total, err := countVotes(ctx, r, p.ID)
+    if err != nil { t.Fatalf("count: %v", err) }
+    if atClose == 0 { t.Fatalf("no votes before close; the race was not exercised") }
+    if total != atClose { t.Fatalf("%d votes landed after the poll closed", total-atClose) }
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+    "fmt"
+    "strconv"
+
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// NPSScale is the number of points of an nps poll: 0 to 10.
+const NPSScale = 11
+
+// DefaultLikertScale applies to likert polls created without a scale.
+const DefaultLikertScale = 5
+
+var likertLabels = map[int][]string{
+    5: {"Strongly disagree", "Disagree", "Neither agree nor disagree", "Agree", "Strongly agree"},
+    7: {"Strongly disagree", "Disagree", "Somewhat disagree", "Neither agree nor disagree", "Somewhat agree", "Agree", "Strongly agree"},
+}
+
+func isScale(k domain.PollKind) bool { return k == domain.PollNPS || k == domain.PollLikert }
+
+// scaleOptions fills in the scale of an nps or likert poll and generates its options, lowest point first.
+func scaleOptions(p *domain.Poll) error {
+    if len(p.Options) > 0 {
+        return fmt.Errorf("invalid poll: %s polls generate their options", p.Kind)
+    }
+    switch p.Kind {
+    case domain.PollNPS:
+        if p.Scale != 0 && p.Scale != NPSScale {
+            return fmt.Errorf("invalid poll: nps polls have a scale of %d", NPSScale)
+        }
+        p.Scale = NPSScale
+        for i := 0; i < NPSScale; i++ {
+            p.Options = append(p.Options, domain.Option{Text: strconv.Itoa(i)})
+        }
+    case domain.PollLikert:
+        if p.Scale == 0 {
+            p.Scale = DefaultLikertScale
+        }
+        labels, ok := likertLabels[p.Scale]
+        if !ok {
+            return fmt.Errorf("invalid poll: likert polls have a scale of 5 or 7, not %d", p.Scale)
+        }
+        for _, l := range labels {
+            p.Options = append(p.Options, domain.Option{Text: l})
+        }
+    }
+    return nil
+}
+
+// scoreScale adds the nps or likert summary to the results of a scale poll, scoring each option
+// by its place in the display order, which the fixed options of these polls cannot change.
+func scoreScale(p *domain.Poll, res *domain.Results) {
+    n := 0
+    for _, o := range res.Options {
+        n += o.Votes
+    }
+    pct := func(votes int) float64 {
+        if n == 0 {
+            return 0
+        }
+        return float64(votes) * 100 / float64(n)
+    }
+    switch p.Kind {
+    case domain.PollNPS:
+        nps := &domain.NPSScore{}
+        for i, o := range res.Options {
+            switch {
+            case i >= 9:
+                nps.Promoters += o.Votes
+            case i >= 7:
+                nps.Passives += o.Votes
+            default:
+                nps.Detractors += o.Votes
+            }
+        }
+        nps.Score = pct(nps.Promoters) - pct(nps.Detractors)
+        res.NPS = nps
+    case domain.PollLikert:
+        lk := &domain.LikertScore{Scale: len(res.Options)}
+        sum, top, topTwo := 0, 0, 0
+        for i, o := range res.Options {
+            sum += (i + 1) * o.Votes
+            if i >= len(res.Options)-2 {
+                topTwo += o.Votes
+            }
+            if i == len(res.Options)-1 {
+                top = o.Votes
+            }
+        }
+        if n > 0 {
+            lk.Mean = float64(sum) / float64(n)
+        }
+        lk.TopBox, lk.TopTwoBox = pct(top), pct(topTwo)
+        res.Likert = lk
+    }
+}
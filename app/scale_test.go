@@ -0,0 +1,53 @@
+package app
+
+import (
+    "testing"
+
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// scaleResults lays out votes per point, lowest point first.
+func scaleResults(votes ...int) *domain.Results {
+    res := &domain.Results{}
+    for _, n := range votes {
+        res.Options = append(res.Options, domain.OptionResult{Votes: n})
+    }
+    return res
+}
+
+func TestScoreScaleNPS(t *testing.T) {
+    for _, tc := range []struct {
+        name  string
+        votes []int
+        want  domain.NPSScore
+    }{
+        {"no votes", []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, domain.NPSScore{}},
+        {"0 to 6 detract", []int{1, 1, 1, 1, 1, 1, 1, 0, 0, 0, 0}, domain.NPSScore{Detractors: 7, Score: -100}},
+        {"7 and 8 are passive", []int{0, 0, 0, 0, 0, 0, 0, 2, 3, 0, 0}, domain.NPSScore{Passives: 5}},
+        {"9 and 10 promote", []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 3}, domain.NPSScore{Promoters: 4, Score: 100}},
+        {"mixed", []int{0, 0, 0, 0, 0, 0, 2, 1, 1, 3, 3}, domain.NPSScore{Promoters: 6, Passives: 2, Detractors: 2, Score: 40}},
+    } {
+        res := scaleResults(tc.votes...)
+        scoreScale(&domain.Poll{Kind: domain.PollNPS}, res)
+        if res.NPS == nil || *res.NPS != tc.want { t.Errorf("%s: nps = %+v, want %+v", tc.name, res.NPS, tc.want) }
+        if res.Likert != nil { t.Errorf("%s: likert summary on an nps poll", tc.name) }
+    }
+}
+
+func TestScoreScaleLikert(t *testing.T) {
+    for _, tc := range []struct {
+        name  string
+        votes []int
+        want  domain.LikertScore
+    }{
+        {"no votes", []int{0, 0, 0, 0, 0}, domain.LikertScore{Scale: 5}},
+        {"all neutral", []int{0, 0, 4, 0, 0}, domain.LikertScore{Scale: 5, Mean: 3}},
+        {"five point", []int{1, 0, 1, 1, 1}, domain.LikertScore{Scale: 5, Mean: 3.25, TopBox: 25, TopTwoBox: 50}},
+        {"seven point", []int{0, 0, 0, 0, 0, 3, 1}, domain.LikertScore{Scale: 7, Mean: 6.25, TopBox: 25, TopTwoBox: 100}},
+    } {
+        res := scaleResults(tc.votes...)
+        scoreScale(&domain.Poll{Kind: domain.PollLikert}, res)
+        if res.Likert == nil || *res.Likert != tc.want { t.Errorf("%s: likert = %+v, want %+v", tc.name, res.Likert, tc.want) }
+        if res.NPS != nil { t.Errorf("%s: nps summary on a likert poll", tc.name) }
+    }
+}
@@ -0,0 +1,125 @@
+package app
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// DefaultSeriesInstances is how many of a series' latest instances SeriesResults compares by default.
+const DefaultSeriesInstances = 20
+
+// SetRecurrence turns a poll into the first instance of a series: it stores a series template
+// with the poll's settings and options that creates the next instance whenever schedule fires,
+// closing the one before. Deleting the template ends the series; its instances are kept.
+func (s *Service) SetRecurrence(ctx context.Context, pollID uint, schedule string) (*domain.PollTemplate, error) {
+    if strings.TrimSpace(schedule) == "" {
+        return nil, errors.New("invalid recurrence: schedule required")
+    }
+    var t *domain.PollTemplate
+    err := s.repo.WithTx(ctx, func(tx PollRepository) error {
+        p, err := tx.GetForUpdate(ctx, pollID)
+        if err != nil {
+            return fmt.Errorf("get poll: %w", err)
+        }
+        if p.SeriesID != 0 {
+            return fmt.Errorf("poll already belongs to series %d", p.SeriesID)
+        }
+        if p.SurveyID != 0 || p.QuizID != 0 {
+            return errors.New("survey and quiz polls cannot recur on their own")
+        }
+        if t, err = s.newTemplate(domain.PollTemplate{Name: p.Title, Poll: *p, Schedule: schedule, Series: true}); err != nil {
+            return err
+        }
+        if err := tx.CreateTemplate(ctx, t); err != nil {
+            return fmt.Errorf("create template: %w", err)
+        }
+        p.SeriesID = t.ID
+        if err := tx.Update(ctx, p); err != nil {
+            return fmt.Errorf("link poll: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return t, nil
+}
+
+// SeriesResults compares the results of the last limit instances of a series (all of them for
+// limit <= 0), with per-option trends matched by option text.
+func (s *Service) SeriesResults(ctx context.Context, seriesID uint, limit int) (domain.SeriesResults, error) {
+    out := domain.SeriesResults{SeriesID: seriesID}
+    t, err := s.repo.GetTemplate(ctx, seriesID)
+    switch {
+    case err == nil:
+        out.Name = t.Name
+    case !errors.Is(err, domain.ErrTemplateNotFound):
+        return out, fmt.Errorf("get template: %w", err)
+    }
+    ps, err := s.repo.ListSeries(ctx, seriesID)
+    if err != nil {
+        return out, fmt.Errorf("list series: %w", err)
+    }
+    if t == nil && len(ps) == 0 {
+        return out, domain.ErrSeriesNotFound
+    }
+    if limit > 0 && len(ps) > limit {
+        ps = ps[len(ps)-limit:]
+    }
+    trends := map[string]*domain.OptionTrend{}
+    var order []string
+    for i, p := range ps {
+        res, err := s.Results(ctx, p.ID)
+        if err != nil {
+            return out, err
+        }
+        in := domain.SeriesInstance{PollID: p.ID, Title: p.Title, Status: p.Status, CreatedAt: p.CreatedAt, Total: res.Total, TotalWeight: res.TotalWeight, Options: res.Options}
+        switch {
+        case res.NPS != nil:
+            in.Score = &res.NPS.Score
+        case res.Likert != nil:
+            in.Score = &res.Likert.Mean
+        }
+        out.Instances = append(out.Instances, in)
+        for _, o := range res.Options {
+            tr, ok := trends[o.Text]
+            if !ok {
+                tr = &domain.OptionTrend{Text: o.Text, Votes: make([]int, len(ps)), Shares: make([]float64, len(ps))}
+                trends[o.Text] = tr
+                order = append(order, o.Text)
+            }
+            tr.Votes[i] += o.Votes
+            if res.Total > 0 {
+                tr.Shares[i] = float64(tr.Votes[i]) * 100 / float64(res.Total)
+            }
+        }
+    }
+    for _, text := range order {
+        tr := trends[text]
+        tr.Change = tr.Shares[len(tr.Shares)-1] - tr.Shares[0]
+        out.Trends = append(out.Trends, *tr)
+    }
+    return out, nil
+}
+
+// closePreviousInstances closes the open instances of latest's series that came before it.
+func (s *Service) closePreviousInstances(ctx context.Context, latest *domain.Poll) error {
+    ps, err := s.repo.ListSeries(ctx, latest.SeriesID)
+    if err != nil {
+        return fmt.Errorf("list series: %w", err)
+    }
+    var errs []error
+    for _, p := range ps {
+        if p.ID >= latest.ID || p.Status != domain.PollOpen {
+            continue
+        }
+        if _, err := s.ClosePoll(ctx, p.ID, 0); err != nil {
+            errs = append(errs, fmt.Errorf("close instance %d: %w", p.ID, err))
+        }
+    }
+    return errors.Join(errs...)
+}
@@ -2,43 +2,167 @@ package app
 
 import (
     "context"
+    "encoding/json"
     "errors"
     "fmt"
+    "net/url"
+    "regexp"
+    "strings"
     "time"
 
     "github.com/robjsliwa/pulse/domain"
 )
 
 type Service struct {
-    repo      PollRepository
-    stream    ResultsStreamer
-    webhooks  WebhookDispatcher
-    now       func() time.Time
+    repo          PollRepository
+    stream        ResultsStreamer
+    webhooks      WebhookDispatcher
+    screener      VoteScreener
+    challenges    ChallengeIssuer
+    blobs         BlobStore
+    thumbnails    Thumbnailer
+    terms         TermCounter
+    leaderboards  LeaderboardStreamer
+    reactions     ReactionWindow
+    reactionFeed  ReactionStreamer
+    emojis        []string
+    schedules     ScheduleParser
+    collections   CollectionStreamer
+    maxMediaBytes int64
+    now           func() time.Time
 }
 
-func NewService(repo PollRepository, stream ResultsStreamer, webhooks WebhookDispatcher) *Service {
-    return &Service{repo: repo, stream: stream, webhooks: webhooks, now: time.Now}
+// ServiceOption configures optional Service collaborators.
+type ServiceOption func(*Service)
+
+// WithVoteScreener runs every non-anonymous vote through sc; flagged votes are quarantined and not counted.
+func WithVoteScreener(sc VoteScreener) ServiceOption { return func(s *Service) { s.screener = sc } }
+
+// WithChallenges enables proof-of-work challenges for polls with a ChallengeDifficulty.
+func WithChallenges(ci ChallengeIssuer) ServiceOption { return func(s *Service) { s.challenges = ci } }
+
+// WithBlobStore enables media uploads.
+func WithBlobStore(bs BlobStore) ServiceOption { return func(s *Service) { s.blobs = bs } }
+
+// WithThumbnailer renders thumbnails for uploaded images.
+func WithThumbnailer(t Thumbnailer) ServiceOption { return func(s *Service) { s.thumbnails = t } }
+
+// WithTermCounter computes word-cloud terms for text polls; without it their results carry no terms.
+func WithTermCounter(tc TermCounter) ServiceOption { return func(s *Service) { s.terms = tc } }
+
+// WithLeaderboards streams quiz leaderboards as answers come in and questions are revealed.
+func WithLeaderboards(ls LeaderboardStreamer) ServiceOption { return func(s *Service) { s.leaderboards = ls } }
+
+// WithReactions accepts reactions from emojis (DefaultReactionEmojis when empty), counts them in w
+// and streams their bursts to rs.
+func WithReactions(w ReactionWindow, rs ReactionStreamer, emojis []string) ServiceOption {
+    return func(s *Service) {
+        s.reactions, s.reactionFeed, s.emojis = w, rs, emojis
+        if len(emojis) == 0 {
+            s.emojis = DefaultReactionEmojis
+        }
+    }
+}
+
+// WithSchedules lets poll templates create polls on a schedule.
+func WithSchedules(sp ScheduleParser) ServiceOption { return func(s *Service) { s.schedules = sp } }
+
+// WithCollections streams collection updates to cs.
+func WithCollections(cs CollectionStreamer) ServiceOption { return func(s *Service) { s.collections = cs } }
+
+// WithMaxMediaBytes overrides DefaultMaxMediaBytes.
+func WithMaxMediaBytes(n int64) ServiceOption { return func(s *Service) { if n > 0 { s.maxMediaBytes = n } } }
+
+func NewService(repo PollRepository, stream ResultsStreamer, webhooks WebhookDispatcher, opts ...ServiceOption) *Service {
+    s := &Service{repo: repo, stream: stream, webhooks: webhooks, maxMediaBytes: DefaultMaxMediaBytes, now: time.Now}
+    for _, o := range opts { o(s) }
+    return s
 }
 
 // Polls
 func (s *Service) CreatePoll(ctx context.Context, p domain.Poll) (*domain.Poll, error) {
+    if err := s.preparePoll(&p); err != nil {
+        return nil, err
+    }
+    if err := s.repo.Create(ctx, &p); err != nil {
+        return nil, fmt.Errorf("create poll: %w", err)
+    }
+    return &p, nil
+}
+
+// preparePoll validates a new poll and fills in its status, defaults and generated options.
+func (s *Service) preparePoll(p *domain.Poll) error {
     p.Status = domain.PollOpen
-    if p.Title == "" || len(p.Options) == 0 {
-        return nil, fmt.Errorf("invalid poll: title and options required")
+    if p.Kind == "" {
+        p.Kind = domain.PollChoice
+    }
+    if err := s.validateKind(p); err != nil {
+        return err
+    }
+    if p.ThresholdBasis == "" {
+        p.ThresholdBasis = domain.ThresholdVotes
+    }
+    if err := validateThresholdBasis(p.ThresholdBasis); err != nil {
+        return fmt.Errorf("invalid poll: %w", err)
+    }
+    if p.Weighted && (p.Anonymous || p.Kind == domain.PollText) {
+        return errors.New("invalid poll: weighted polls can be neither anonymous nor text polls")
+    }
+    if p.CreditBudget < 0 || p.CreditBudget > MaxCreditBudget {
+        return fmt.Errorf("invalid poll: credit budget must be between 0 and %d", MaxCreditBudget)
+    }
+    if p.CreditBudget > 0 && (p.Kind != domain.PollChoice || p.Anonymous || p.Weighted) {
+        return errors.New("invalid poll: quadratic voting needs a choice poll that is neither anonymous nor weighted")
     }
-    // ensure no empty options
     opts := make([]domain.Option, 0, len(p.Options))
     for _, o := range p.Options {
-        if o.Text == "" {
-            return nil, fmt.Errorf("invalid option: text required")
+        if err := validateOption(o); err != nil {
+            return err
         }
-        opts = append(opts, domain.Option{Text: o.Text})
+        opts = append(opts, domain.Option{Text: o.Text, Description: o.Description, ImageURL: o.ImageURL, Color: o.Color, Metadata: o.Metadata})
     }
     p.Options = opts
-    if err := s.repo.Create(ctx, &p); err != nil {
-        return nil, fmt.Errorf("create poll: %w", err)
+    if p.ChallengeDifficulty < 0 || p.ChallengeDifficulty > MaxChallengeDifficulty {
+        return fmt.Errorf("invalid poll: challenge difficulty must be between 0 and %d", MaxChallengeDifficulty)
     }
-    return &p, nil
+    if p.ImageURL != "" {
+        if err := validateImageURL(p.ImageURL); err != nil {
+            return fmt.Errorf("invalid poll: %w", err)
+        }
+    }
+    return nil
+}
+
+// validateKind checks the parts of a new poll that depend on its kind; nps and likert polls get
+// their options generated.
+func (s *Service) validateKind(p *domain.Poll) error {
+    if p.Title == "" {
+        return errors.New("invalid poll: title required")
+    }
+    if p.Scale != 0 && !isScale(p.Kind) {
+        return fmt.Errorf("invalid poll: %s polls take no scale", p.Kind)
+    }
+    switch p.Kind {
+    case domain.PollChoice:
+        if len(p.Options) == 0 {
+            return errors.New("invalid poll: title and options required")
+        }
+    case domain.PollText:
+        if len(p.Options) > 0 {
+            return errors.New("invalid poll: text polls take no options")
+        }
+        if p.Anonymous {
+            return errors.New("invalid poll: text polls cannot be anonymous")
+        }
+        if s.terms != nil && !s.terms.Supports(p.Language) {
+            return fmt.Errorf("invalid poll: unsupported language %q", p.Language)
+        }
+    case domain.PollNPS, domain.PollLikert:
+        return scaleOptions(p)
+    default:
+        return fmt.Errorf("invalid poll: unknown kind %q", p.Kind)
+    }
+    return nil
 }
 
 func (s *Service) GetPoll(ctx context.Context, id uint) (*domain.Poll, error) {
@@ -46,6 +170,7 @@ func (s *Service) GetPoll(ctx context.Context, id uint) (*domain.Poll, error) {
     if err != nil {
         return nil, fmt.Errorf("get poll: %w", err)
     }
+    hideAnswers(p)
     return p, nil
 }
 
@@ -54,68 +179,241 @@ func (s *Service) ListPolls(ctx context.Context, offset, limit int) ([]domain.Po
     if err != nil {
         return nil, fmt.Errorf("list polls: %w", err)
     }
+    for i := range ps {
+        hideAnswers(&ps[i])
+    }
     return ps, nil
 }
 
-func (s *Service) UpdatePoll(ctx context.Context, p domain.Poll) (*domain.Poll, error) {
-    existing, err := s.repo.GetByID(ctx, p.ID)
+// UpdatePoll applies the fields p sets. A non-zero p.Version must match the stored version
+// (optimistic concurrency); otherwise domain.ErrVersionConflict is returned.
+func (s *Service) UpdatePoll(ctx context.Context, p domain.PollPatch) (*domain.Poll, error) {
+    var existing *domain.Poll
+    err := s.repo.WithTx(ctx, func(tx PollRepository) error {
+        var err error
+        existing, err = tx.GetForUpdate(ctx, p.ID)
+        if err != nil {
+            return fmt.Errorf("get poll: %w", err)
+        }
+        if err := checkVersion(existing, p.Version); err != nil {
+            return err
+        }
+        return s.applyPollUpdate(ctx, tx, existing, p)
+    })
     if err != nil {
-        return nil, fmt.Errorf("get poll: %w", err)
+        return nil, err
     }
+    return existing, nil
+}
+
+func (s *Service) applyPollUpdate(ctx context.Context, tx PollRepository, existing *domain.Poll, p domain.PollPatch) error {
     if existing.Status == domain.PollClosed {
-        return nil, fmt.Errorf("cannot update closed poll")
+        return fmt.Errorf("cannot update closed poll")
     }
-    if p.Title != "" {
-        existing.Title = p.Title
+    if existing.SurveyID != 0 {
+        return fmt.Errorf("poll belongs to survey %d", existing.SurveyID)
     }
-    if p.Description != "" {
-        existing.Description = p.Description
+    if p.Title != nil {
+        if *p.Title == "" {
+            return errors.New("title required")
+        }
+        existing.Title = *p.Title
     }
-    if p.Threshold != 0 {
-        existing.Threshold = p.Threshold
+    if p.Description != nil {
+        existing.Description = *p.Description
     }
-    if err := s.repo.Update(ctx, existing); err != nil {
-        return nil, fmt.Errorf("update poll: %w", err)
+    if p.ImageURL != nil {
+        if *p.ImageURL != "" {
+            if err := validateImageURL(*p.ImageURL); err != nil {
+                return err
+            }
+        }
+        existing.ImageURL = *p.ImageURL
     }
-    return existing, nil
+    if p.Threshold != nil {
+        existing.Threshold = *p.Threshold
+    }
+    if p.ThresholdBasis != nil {
+        if err := validateThresholdBasis(*p.ThresholdBasis); err != nil {
+            return err
+        }
+        existing.ThresholdBasis = *p.ThresholdBasis
+    }
+    // 0 removes the override and restores the server default
+    if p.VoteRatePerMinute != nil {
+        if *p.VoteRatePerMinute < 0 {
+            return errors.New("vote rate must not be negative")
+        }
+        existing.VoteRatePerMinute = *p.VoteRatePerMinute
+    }
+    if p.VoteBurst != nil {
+        if *p.VoteBurst < 0 {
+            return errors.New("vote burst must not be negative")
+        }
+        existing.VoteBurst = *p.VoteBurst
+    }
+    if p.ChallengeDifficulty != nil {
+        if *p.ChallengeDifficulty < 0 || *p.ChallengeDifficulty > MaxChallengeDifficulty {
+            return fmt.Errorf("challenge difficulty must be between 0 and %d", MaxChallengeDifficulty)
+        }
+        existing.ChallengeDifficulty = *p.ChallengeDifficulty
+    }
+    if err := tx.Update(ctx, existing); err != nil {
+        return fmt.Errorf("update poll: %w", err)
+    }
+    return nil
+}
+
+// DeletePoll moves a poll to the trash; a non-zero expectedVersion must match the stored version.
+// Polls behind survey questions stay with their survey.
+func (s *Service) DeletePoll(ctx context.Context, id uint, expectedVersion int) error {
+    return s.repo.WithTx(ctx, func(tx PollRepository) error {
+        p, err := tx.GetForUpdate(ctx, id)
+        if errors.Is(err, domain.ErrPollNotFound) && expectedVersion == 0 {
+            return nil // already gone
+        }
+        if err != nil {
+            return fmt.Errorf("get poll: %w", err)
+        }
+        if err := checkVersion(p, expectedVersion); err != nil {
+            return err
+        }
+        if p.SurveyID != 0 {
+            return fmt.Errorf("poll belongs to survey %d", p.SurveyID)
+        }
+        if p.QuizID != 0 {
+            return fmt.Errorf("poll belongs to quiz %d", p.QuizID)
+        }
+        if err := tx.Delete(ctx, id); err != nil {
+            return fmt.Errorf("delete poll: %w", err)
+        }
+        return nil
+    })
 }
 
-func (s *Service) DeletePoll(ctx context.Context, id uint) error {
-    if err := s.repo.Delete(ctx, id); err != nil {
-        return fmt.Errorf("delete poll: %w", err)
+func (s *Service) ListDeletedPolls(ctx context.Context, offset, limit int) ([]domain.Poll, error) {
+    ps, err := s.repo.ListDeleted(ctx, offset, limit)
+    if err != nil {
+        return nil, fmt.Errorf("list deleted polls: %w", err)
     }
-    return nil
+    return ps, nil
 }
 
-func (s *Service) ClosePoll(ctx context.Context, id uint) (*domain.Poll, error) {
-    p, err := s.repo.GetByID(ctx, id)
+func (s *Service) RestorePoll(ctx context.Context, id uint) (*domain.Poll, error) {
+    if err := s.repo.Restore(ctx, id); err != nil {
+        return nil, fmt.Errorf("restore poll: %w", err)
+    }
+    return s.GetPoll(ctx, id)
+}
+
+// PurgeDeletedPolls permanently removes polls that have been in the trash longer than retention.
+func (s *Service) PurgeDeletedPolls(ctx context.Context, retention time.Duration) (int, error) {
+    n, err := s.repo.PurgeDeleted(ctx, s.now().Add(-retention))
     if err != nil {
-        return nil, fmt.Errorf("get poll: %w", err)
+        return 0, fmt.Errorf("purge deleted polls: %w", err)
     }
-    p.Status = domain.PollClosed
-    if err := s.repo.Update(ctx, p); err != nil {
-        return nil, fmt.Errorf("close poll: %w", err)
+    return n, nil
+}
+
+// ClosePoll locks the poll while closing it, so no vote can slip in between.
+// A non-zero expectedVersion must match the stored version.
+func (s *Service) ClosePoll(ctx context.Context, id uint, expectedVersion int) (*domain.Poll, error) {
+    return s.closePoll(ctx, id, expectedVersion, false)
+}
+
+// closePoll closes a poll, recording whether its collection did so.
+func (s *Service) closePoll(ctx context.Context, id uint, expectedVersion int, byCollection bool) (*domain.Poll, error) {
+    var p *domain.Poll
+    err := s.repo.WithTx(ctx, func(tx PollRepository) error {
+        var err error
+        p, err = tx.GetForUpdate(ctx, id)
+        if err != nil {
+            return fmt.Errorf("get poll: %w", err)
+        }
+        if err := checkVersion(p, expectedVersion); err != nil {
+            return err
+        }
+        if p.SurveyID != 0 {
+            return fmt.Errorf("poll belongs to survey %d", p.SurveyID)
+        }
+        p.Status = domain.PollClosed
+        p.ClosedByCollection = byCollection
+        if err := tx.Update(ctx, p); err != nil {
+            return fmt.Errorf("close poll: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
     }
     // webhook event
-    _ = s.webhooks.Dispatch(ctx, "poll.closed", map[string]any{"poll_id": p.ID, "title": p.Title})
+    s.dispatchPoll(ctx, p, "poll.closed", map[string]any{"poll_id": p.ID, "title": p.Title})
+    if p.QuizID != 0 {
+        // closing a quiz question reveals its answers: push them to results and the leaderboard
+        if _, err := s.publishResults(ctx, p.ID); err != nil {
+            return nil, err
+        }
+        if err := s.publishLeaderboard(ctx, p.QuizID); err != nil {
+            return nil, err
+        }
+    }
     return p, nil
 }
 
-// Options
-func (s *Service) AddOption(ctx context.Context, pollID uint, text string) (*domain.Option, error) {
-    p, err := s.repo.GetByID(ctx, pollID)
-    if err != nil {
-        return nil, fmt.Errorf("get poll: %w", err)
-    }
-    if p.Status == domain.PollClosed {
-        return nil, errors.New("poll is closed")
+func checkVersion(p *domain.Poll, expected int) error {
+    if expected != 0 && p.Version != expected {
+        return domain.ErrVersionConflict
     }
-    if text == "" {
-        return nil, fmt.Errorf("option text required")
+    return nil
+}
+
+// Options
+
+// AddOption appends an option and bumps the poll version, since options are part of the poll.
+// A non-zero expectedVersion must match the stored version.
+func (s *Service) AddOption(ctx context.Context, in domain.Option, expectedVersion int) (*domain.Option, error) {
+    if err := validateOption(in); err != nil {
+        return nil, err
     }
-    opt := &domain.Option{PollID: pollID, Text: text}
-    if err := s.repo.AddOption(ctx, opt); err != nil {
-        return nil, fmt.Errorf("add option: %w", err)
+    pollID := in.PollID
+    opt := &domain.Option{PollID: pollID, Text: in.Text, Description: in.Description, ImageURL: in.ImageURL, Color: in.Color, Metadata: in.Metadata}
+    err := s.repo.WithTx(ctx, func(tx PollRepository) error {
+        p, err := tx.GetForUpdate(ctx, pollID)
+        if err != nil {
+            return fmt.Errorf("get poll: %w", err)
+        }
+        if err := checkVersion(p, expectedVersion); err != nil {
+            return err
+        }
+        if p.Status == domain.PollClosed {
+            return errors.New("poll is closed")
+        }
+        if p.SurveyID != 0 {
+            return fmt.Errorf("poll belongs to survey %d; its options are fixed", p.SurveyID)
+        }
+        if p.QuizID != 0 {
+            return fmt.Errorf("poll belongs to quiz %d; its options are fixed", p.QuizID)
+        }
+        if p.Kind == domain.PollText {
+            return errors.New("text polls take no options")
+        }
+        if isScale(p.Kind) {
+            return fmt.Errorf("the options of %s polls are fixed", p.Kind)
+        }
+        opt.Position = len(p.Options)
+        if n := len(p.Options); n > 0 && p.Options[n-1].Position >= opt.Position {
+            opt.Position = p.Options[n-1].Position + 1
+        }
+        if err := tx.AddOption(ctx, opt); err != nil {
+            return fmt.Errorf("add option: %w", err)
+        }
+        if err := tx.Update(ctx, p); err != nil {
+            return fmt.Errorf("bump poll version: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
     }
     return opt, nil
 }
@@ -125,45 +423,642 @@ func (s *Service) ListOptions(ctx context.Context, pollID uint) ([]domain.Option
     if err != nil {
         return nil, fmt.Errorf("list options: %w", err)
     }
+    for _, o := range opts {
+        if !o.Correct {
+            continue
+        }
+        p, err := s.GetPoll(ctx, pollID)
+        if err != nil {
+            return nil, err
+        }
+        return p.Options, nil
+    }
     return opts, nil
 }
 
+// UpdateOption applies in to option in.ID of open poll in.PollID.
+func (s *Service) UpdateOption(ctx context.Context, in domain.OptionPatch, expectedVersion int) (*domain.Option, error) {
+    var opt *domain.Option
+    err := s.optionChange(ctx, in.PollID, expectedVersion, func(tx PollRepository, p *domain.Poll) error {
+        if opt = findOption(p, in.ID); opt == nil {
+            return fmt.Errorf("option %d: %w", in.ID, domain.ErrOptionNotFound)
+        }
+        if in.Text != nil {
+            opt.Text = *in.Text
+        }
+        if in.Description != nil {
+            opt.Description = *in.Description
+        }
+        if in.ImageURL != nil {
+            opt.ImageURL = *in.ImageURL
+        }
+        if in.Color != nil {
+            opt.Color = *in.Color
+        }
+        if in.Metadata != nil {
+            opt.Metadata = in.Metadata
+            if m := strings.TrimSpace(string(in.Metadata)); m == "null" || m == `""` {
+                opt.Metadata = nil
+            }
+        }
+        if err := validateOption(*opt); err != nil {
+            return err
+        }
+        if err := tx.UpdateOption(ctx, opt); err != nil {
+            return fmt.Errorf("update option: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return opt, nil
+}
+
+// DeleteOption removes an option from an open poll. Its votes are handled by policy: reject refuses
+// while there are any, reassign moves them to reassignTo, discard deletes them.
+func (s *Service) DeleteOption(ctx context.Context, pollID, optionID uint, policy domain.OptionDeletePolicy, reassignTo uint, expectedVersion int) error {
+    if policy == "" {
+        policy = domain.OptionDeleteReject
+    }
+    return s.optionChange(ctx, pollID, expectedVersion, func(tx PollRepository, p *domain.Poll) error {
+        if isScale(p.Kind) {
+            return fmt.Errorf("the options of %s polls are fixed", p.Kind)
+        }
+        if !hasOption(p, optionID) {
+            return fmt.Errorf("option %d: %w", optionID, domain.ErrOptionNotFound)
+        }
+        switch policy {
+        case domain.OptionDeleteReject:
+            n, err := tx.CountOptionVotes(ctx, pollID, optionID)
+            if err != nil {
+                return fmt.Errorf("count option votes: %w", err)
+            }
+            if n > 0 {
+                return fmt.Errorf("option %d has %d votes: %w", optionID, n, domain.ErrOptionHasVotes)
+            }
+        case domain.OptionDeleteReassign:
+            if reassignTo == optionID || !hasOption(p, reassignTo) {
+                return fmt.Errorf("reassign to option %d: %w", reassignTo, domain.ErrOptionNotFound)
+            }
+            if _, err := tx.ReassignVotes(ctx, pollID, optionID, reassignTo); err != nil {
+                return fmt.Errorf("reassign votes: %w", err)
+            }
+        case domain.OptionDeleteDiscard:
+        default:
+            return fmt.Errorf("unknown option delete policy %q", policy)
+        }
+        if err := tx.DeleteOption(ctx, pollID, optionID); err != nil {
+            return fmt.Errorf("delete option: %w", err)
+        }
+        return nil
+    })
+}
+
+// ReorderOptions sets the display order of an open poll's options; optionIDs must list each exactly once.
+func (s *Service) ReorderOptions(ctx context.Context, pollID uint, optionIDs []uint, expectedVersion int) ([]domain.Option, error) {
+    err := s.optionChange(ctx, pollID, expectedVersion, func(tx PollRepository, p *domain.Poll) error {
+        if isScale(p.Kind) {
+            return fmt.Errorf("the options of %s polls are fixed", p.Kind)
+        }
+        seen := make(map[uint]bool, len(optionIDs))
+        for _, id := range optionIDs {
+            if seen[id] || !hasOption(p, id) {
+                return fmt.Errorf("option order must list each of the poll's %d options exactly once", len(p.Options))
+            }
+            seen[id] = true
+        }
+        if len(seen) != len(p.Options) {
+            return fmt.Errorf("option order must list each of the poll's %d options exactly once", len(p.Options))
+        }
+        if err := tx.ReorderOptions(ctx, pollID, optionIDs); err != nil {
+            return fmt.Errorf("reorder options: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return s.ListOptions(ctx, pollID)
+}
+
+// optionChange runs fn against the locked poll, bumps the poll version and rebroadcasts results,
+// whose option list and counts may have changed. A closed poll's options are final, as are those
+// of survey and quiz questions.
+func (s *Service) optionChange(ctx context.Context, pollID uint, expectedVersion int, fn func(tx PollRepository, p *domain.Poll) error) error {
+    err := s.repo.WithTx(ctx, func(tx PollRepository) error {
+        p, err := tx.GetForUpdate(ctx, pollID)
+        if err != nil {
+            return fmt.Errorf("get poll: %w", err)
+        }
+        if err := checkVersion(p, expectedVersion); err != nil {
+            return err
+        }
+        if p.Status == domain.PollClosed {
+            return errors.New("poll is closed")
+        }
+        // submissions record answers by option, so a survey question's options never change; a quiz
+        // question's would also give its answer away
+        if p.SurveyID != 0 {
+            return fmt.Errorf("poll belongs to survey %d; its options are fixed", p.SurveyID)
+        }
+        if p.QuizID != 0 {
+            return fmt.Errorf("poll belongs to quiz %d; its options are fixed", p.QuizID)
+        }
+        if err := fn(tx, p); err != nil {
+            return err
+        }
+        if err := tx.Update(ctx, p); err != nil {
+            return fmt.Errorf("bump poll version: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    _, err = s.publishResults(ctx, pollID)
+    return err
+}
+
 // Votes and results
-func (s *Service) Vote(ctx context.Context, pollID, optionID uint, userID string) (*domain.Vote, error) {
+
+// MaxOptionMetadataBytes caps the JSON metadata stored with an option.
+const MaxOptionMetadataBytes = 4 << 10
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+func validateOption(o domain.Option) error {
+    if o.Text == "" {
+        return fmt.Errorf("invalid option: text required")
+    }
+    if o.Color != "" && !hexColor.MatchString(o.Color) {
+        return fmt.Errorf("invalid option: color must be #rgb or #rrggbb")
+    }
+    if o.ImageURL != "" {
+        if err := validateImageURL(o.ImageURL); err != nil {
+            return fmt.Errorf("invalid option: %w", err)
+        }
+    }
+    if o.Metadata != nil {
+        if len(o.Metadata) > MaxOptionMetadataBytes {
+            return fmt.Errorf("invalid option: metadata exceeds %d bytes", MaxOptionMetadataBytes)
+        }
+        if !json.Valid(o.Metadata) {
+            return fmt.Errorf("invalid option: metadata must be valid JSON")
+        }
+    }
+    return nil
+}
+
+// validateImageURL accepts uploaded media paths and absolute http(s) URLs.
+func validateImageURL(raw string) error {
+    if strings.HasPrefix(raw, domain.MediaPath) {
+        return nil
+    }
+    u, err := url.Parse(raw)
+    if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+        return errors.New("image_url must be an http(s) URL or an uploaded media path")
+    }
+    return nil
+}
+
+// findOption returns a copy of the poll's option with the given ID, or nil.
+func findOption(p *domain.Poll, optionID uint) *domain.Option {
+    for _, o := range p.Options {
+        if o.ID == optionID {
+            return &o
+        }
+    }
+    return nil
+}
+
+func hasOption(p *domain.Poll, optionID uint) bool { return findOption(p, optionID) != nil }
+
+// CheckConsistency reports votes, ballots, participation records and options left behind by
+// removed polls or options; with repair set they are deleted.
+func (s *Service) CheckConsistency(ctx context.Context, repair bool) (ConsistencyReport, error) {
+    rep, err := s.repo.CheckConsistency(ctx, repair)
+    if err != nil {
+        return ConsistencyReport{}, fmt.Errorf("check consistency: %w", err)
+    }
+    return rep, nil
+}
+
+// Vote casts in.OptionID for in.PollID. Client metadata on in (UserID, ClientIP, UserAgent) feeds the
+// vote screener; a flagged vote is stored with VoteFlagged status and left out of results.
+// proof must hold a solved challenge when the poll requires one. The status check and the insert
+// run in one unit of work holding the poll lock, so a concurrent ClosePoll cannot interleave.
+// In quadratic polls in.Weight is the number of votes the voter allocates to the option, replacing
+// their earlier allocation to it; 0 withdraws it and returns a vote without ID.
+func (s *Service) Vote(ctx context.Context, in domain.Vote, proof *domain.ChallengeSolution) (*domain.Vote, error) {
+    var p *domain.Poll
+    v := &domain.Vote{PollID: in.PollID, OptionID: in.OptionID, Text: strings.TrimSpace(in.Text), UserID: in.UserID, Status: domain.VoteCounted, ClientIP: in.ClientIP, UserAgent: in.UserAgent, CreatedAt: s.now()}
+    err := s.repo.WithTx(ctx, func(tx PollRepository) error {
+        var err error
+        p, err = tx.GetForUpdate(ctx, in.PollID)
+        if err != nil {
+            return fmt.Errorf("get poll: %w", err)
+        }
+        if p.Status == domain.PollClosed {
+            return errors.New("poll is closed")
+        }
+        if p.SurveyID != 0 {
+            return fmt.Errorf("poll belongs to survey %d; submit the survey instead", p.SurveyID)
+        }
+        if p.CollectionID != 0 {
+            if err := checkCollectionAccess(ctx, tx, p, v); err != nil {
+                return err
+            }
+        }
+        if err := checkVoteFits(p, v); err != nil {
+            return err
+        }
+        if p.QuizID != 0 {
+            if err := s.checkQuizAnswer(ctx, tx, p, v); err != nil {
+                return err
+            }
+        }
+        if p.Weighted {
+            if err := weighVote(ctx, tx, p, v); err != nil {
+                return err
+            }
+        }
+        if err := s.checkChallenge(ctx, tx, p, proof); err != nil {
+            return err
+        }
+        if p.Anonymous {
+            if v.UserID == "" {
+                return errors.New("user_id required for anonymous poll")
+            }
+            if err := tx.CreateParticipation(ctx, v.PollID, v.UserID); err != nil {
+                return fmt.Errorf("create vote: %w", err)
+            }
+            return nil
+        }
+        if p.CreditBudget > 0 {
+            if err := allocateCredits(ctx, tx, p, v, in.Weight); err != nil {
+                return err
+            }
+            if v.Weight == 0 {
+                return nil
+            }
+        }
+        if err := s.screen(ctx, tx, v); err != nil {
+            return err
+        }
+        if err := tx.CreateVote(ctx, v); err != nil {
+            return fmt.Errorf("create vote: %w", err)
+        }
+        return nil
+    })
+    if err == nil && p.Anonymous {
+        err = s.castBallot(ctx, v)
+        // never echo the voter back for anonymous ballots
+        v = &domain.Vote{PollID: v.PollID, OptionID: v.OptionID, Weight: 1, Status: domain.VoteCounted}
+    }
+    if err != nil {
+        return nil, err
+    }
+    if p.CreditBudget > 0 && v.Weight == 0 {
+        // a withdrawn allocation only changes the results
+        if _, err := s.publishResults(ctx, p.ID); err != nil {
+            return nil, err
+        }
+        return v, nil
+    }
+    if v.Status == domain.VoteFlagged {
+        s.dispatchPoll(ctx, p, "vote.flagged", map[string]any{"poll_id": v.PollID, "vote_id": v.ID, "reason": v.FlagReason})
+        return v, nil
+    }
+    if err := s.publishVote(ctx, p, v.OptionID); err != nil {
+        return nil, err
+    }
+    return v, nil
+}
+
+// castBallot writes the ballot of an anonymous vote once the unit of work that recorded the voter
+// has committed, so the ballot and the participation record never share a transaction. It holds
+// the poll lock like Vote, so no ballot lands after the poll closes; when the ballot cannot be cast
+// the voter's participation is undone so they can vote again.
+func (s *Service) castBallot(ctx context.Context, v *domain.Vote) error {
+    err := s.repo.WithTx(ctx, func(tx PollRepository) error {
+        p, err := tx.GetForUpdate(ctx, v.PollID)
+        if err != nil {
+            return fmt.Errorf("get poll: %w", err)
+        }
+        if p.Status == domain.PollClosed {
+            return errors.New("poll is closed")
+        }
+        if err := tx.CreateBallot(ctx, v.PollID, v.OptionID); err != nil {
+            return fmt.Errorf("create vote: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        if uerr := s.repo.DeleteParticipation(ctx, v.PollID, v.UserID); uerr != nil {
+            return errors.Join(err, fmt.Errorf("undo participation: %w", uerr))
+        }
+        return err
+    }
+    return nil
+}
+
+// MaxResponseBytes caps the text of a vote in a text poll.
+const MaxResponseBytes = 1000
+
+// checkVoteFits checks that v answers p the way its kind expects: with one of its options, or with text.
+func checkVoteFits(p *domain.Poll, v *domain.Vote) error {
+    if p.Kind == domain.PollText {
+        if v.OptionID != 0 || v.Text == "" || len(v.Text) > MaxResponseBytes {
+            return fmt.Errorf("text poll needs text of at most %d bytes and no option: %w", MaxResponseBytes, domain.ErrInvalidVote)
+        }
+        return nil
+    }
+    if v.Text != "" {
+        return fmt.Errorf("text answers need a text poll: %w", domain.ErrInvalidVote)
+    }
+    if !hasOption(p, v.OptionID) {
+        return fmt.Errorf("option %d: %w", v.OptionID, domain.ErrOptionNotFound)
+    }
+    return nil
+}
+
+// MaxChallengeDifficulty caps proof-of-work so a poll cannot be made unvotable by accident.
+const MaxChallengeDifficulty = 28
+
+// Challenge issues a proof-of-work challenge for a poll that requires one.
+func (s *Service) Challenge(ctx context.Context, pollID uint) (domain.Challenge, error) {
     p, err := s.repo.GetByID(ctx, pollID)
     if err != nil {
-        return nil, fmt.Errorf("get poll: %w", err)
+        return domain.Challenge{}, fmt.Errorf("get poll: %w", err)
+    }
+    if p.ChallengeDifficulty == 0 || s.challenges == nil {
+        return domain.Challenge{}, errors.New("poll does not require a challenge")
+    }
+    ch, err := s.challenges.Issue(pollID, p.ChallengeDifficulty)
+    if err != nil {
+        return domain.Challenge{}, fmt.Errorf("issue challenge: %w", err)
+    }
+    return ch, nil
+}
+
+// checkChallenge verifies proof and spends it in the vote's unit of work: the repository is shared
+// by all replicas, so a token buys one vote in total, and a vote that fails leaves it unspent for
+// a retry.
+func (s *Service) checkChallenge(ctx context.Context, tx PollRepository, p *domain.Poll, proof *domain.ChallengeSolution) error {
+    if p.ChallengeDifficulty == 0 || s.challenges == nil {
+        return nil
+    }
+    if proof == nil {
+        return domain.ErrChallengeRequired
     }
-    if p.Status == domain.PollClosed {
-        return nil, errors.New("poll is closed")
+    key, exp, err := s.challenges.Verify(p.ID, p.ChallengeDifficulty, proof.Token, proof.Solution)
+    if err != nil {
+        return err
     }
-    v := &domain.Vote{PollID: pollID, OptionID: optionID, UserID: userID, CreatedAt: s.now()}
-    if err := s.repo.CreateVote(ctx, v); err != nil {
-        return nil, fmt.Errorf("create vote: %w", err)
+    return tx.SpendChallenge(ctx, key, exp, s.now())
+}
+
+// screen runs the configured screener and marks v flagged when it says so.
+func (s *Service) screen(ctx context.Context, repo PollRepository, v *domain.Vote) error {
+    if s.screener == nil {
+        return nil
     }
-    // recalc results safely from DB (never trust client totals)
-    counts, total, err := s.repo.CountVotesByOption(ctx, pollID)
+    recent, err := repo.RecentVotes(ctx, v.PollID, v.CreatedAt.Add(-s.screener.Lookback()))
     if err != nil {
-        return nil, fmt.Errorf("count votes: %w", err)
+        return fmt.Errorf("recent votes: %w", err)
+    }
+    if sc := s.screener.Screen(ctx, *v, recent); sc.Flagged {
+        v.Status = domain.VoteFlagged
+        v.FlagReason = strings.Join(sc.Reasons, "; ")
+    }
+    return nil
+}
+
+// publishVote recalculates results, broadcasts them and fires the vote webhooks for a counted vote.
+func (s *Service) publishVote(ctx context.Context, p *domain.Poll, optionID uint) error {
+    res, err := s.publishResults(ctx, p.ID)
+    if err != nil {
+        return err
     }
-    res := domain.Results{PollID: pollID, OptionVotes: counts, Total: total}
-    s.stream.Broadcast(pollID, res)
 
     // webhook vote.created
-    _ = s.webhooks.Dispatch(ctx, "vote.created", map[string]any{"poll_id": pollID, "option_id": optionID})
+    s.dispatchPoll(ctx, p, "vote.created", map[string]any{"poll_id": p.ID, "option_id": optionID})
 
     // threshold check
+    total := res.Total
+    if p.ThresholdBasis == domain.ThresholdWeight {
+        total = res.TotalWeight
+    }
     if p.Threshold > 0 && total >= p.Threshold {
-        _ = s.webhooks.Dispatch(ctx, "poll.threshold_reached", map[string]any{"poll_id": pollID, "threshold": p.Threshold, "total": total})
+        s.dispatchPoll(ctx, p, "poll.threshold_reached", map[string]any{"poll_id": p.ID, "threshold": p.Threshold, "basis": p.ThresholdBasis, "total": total})
     }
-    return v, nil
+    if p.QuizID != 0 {
+        return s.publishLeaderboard(ctx, p.QuizID)
+    }
+    return nil
+}
+
+// publishResults recalculates results from the DB (never trust client totals) and broadcasts them.
+func (s *Service) publishResults(ctx context.Context, pollID uint) (domain.Results, error) {
+    res, err := s.Results(ctx, pollID)
+    if err != nil {
+        return domain.Results{}, err
+    }
+    s.stream.Broadcast(pollID, res)
+    s.publishCollection(ctx, res)
+    return res, nil
 }
 
 func (s *Service) Results(ctx context.Context, pollID uint) (domain.Results, error) {
-    counts, total, err := s.repo.CountVotesByOption(ctx, pollID)
+    tally, err := s.repo.CountVotesByOption(ctx, pollID)
     if err != nil {
         return domain.Results{}, fmt.Errorf("count votes: %w", err)
     }
-    return domain.Results{PollID: pollID, OptionVotes: counts, Total: total}, nil
+    opts, err := s.repo.ListOptions(ctx, pollID)
+    if err != nil {
+        return domain.Results{}, fmt.Errorf("list options: %w", err)
+    }
+    res := domain.Results{PollID: pollID, OptionVotes: tally.Votes, OptionWeights: tally.Weights, Options: make([]domain.OptionResult, 0, len(opts)), Total: tally.Total, TotalWeight: tally.TotalWeight}
+    for _, o := range opts {
+        res.Options = append(res.Options, domain.OptionResult{OptionID: o.ID, Text: o.Text, Description: o.Description, ImageURL: o.ImageURL, Color: o.Color, Metadata: o.Metadata, Votes: tally.Votes[o.ID], Weight: tally.Weights[o.ID]})
+    }
+    p, err := s.repo.GetByID(ctx, pollID)
+    if errors.Is(err, domain.ErrPollNotFound) {
+        // trashed polls keep their plain counts
+        return res, nil
+    }
+    if err != nil {
+        return domain.Results{}, fmt.Errorf("get poll: %w", err)
+    }
+    switch p.Kind {
+    case domain.PollText:
+        if err := s.countTerms(ctx, p, &res); err != nil {
+            return domain.Results{}, err
+        }
+    case domain.PollNPS, domain.PollLikert:
+        scoreScale(p, &res)
+    default:
+        markCorrect(p, opts, &res)
+        if p.CreditBudget > 0 {
+            if err := s.countCredits(ctx, p, &res); err != nil {
+                return domain.Results{}, err
+            }
+        }
+    }
+    return res, nil
+}
+
+// markCorrect flags the correct options of a closed quiz poll; while it is open they stay unmarked.
+func markCorrect(p *domain.Poll, opts []domain.Option, res *domain.Results) {
+    if p.Status != domain.PollClosed {
+        return
+    }
+    correct := map[uint]bool{}
+    for _, o := range opts {
+        if o.Correct {
+            correct[o.ID] = true
+        }
+    }
+    for i := range res.Options {
+        res.Options[i].Correct = correct[res.Options[i].OptionID]
+    }
+}
+
+// countTerms fills in Total and the word cloud of a text poll from its visible responses.
+func (s *Service) countTerms(ctx context.Context, p *domain.Poll, res *domain.Results) error {
+    vs, err := s.repo.ListVotes(ctx, p.ID, domain.VoteCounted)
+    if err != nil {
+        return fmt.Errorf("list responses: %w", err)
+    }
+    texts := make([]string, 0, len(vs))
+    for _, v := range vs {
+        texts = append(texts, v.Text)
+    }
+    res.Total = len(texts)
+    res.Terms = []domain.TermCount{}
+    if s.terms != nil {
+        res.Terms = s.terms.CountTerms(texts, p.Language)
+    }
+    return nil
 }
 
+// ListVotes returns the per-voter breakdown of a poll's counted votes; refused for anonymous polls.
+// Votes awaiting moderation and responses a moderator hid are left out.
+func (s *Service) ListVotes(ctx context.Context, pollID uint) ([]domain.Vote, error) {
+    p, err := s.repo.GetByID(ctx, pollID)
+    if err != nil {
+        return nil, fmt.Errorf("get poll: %w", err)
+    }
+    if p.Anonymous {
+        return nil, domain.ErrAnonymousPoll
+    }
+    vs, err := s.repo.ListVotes(ctx, pollID, domain.VoteCounted)
+    if err != nil {
+        return nil, fmt.Errorf("list votes: %w", err)
+    }
+    return vs, nil
+}
+
+// Moderation of flagged votes
+
+func (s *Service) ListFlaggedVotes(ctx context.Context, pollID uint) ([]domain.Vote, error) {
+    vs, err := s.repo.ListVotes(ctx, pollID, domain.VoteFlagged)
+    if err != nil {
+        return nil, fmt.Errorf("list flagged votes: %w", err)
+    }
+    return vs, nil
+}
+
+// AcceptVote moves a flagged vote into the count and rebroadcasts results. The check that the vote
+// is still flagged and the status change run under the poll lock, so two moderators cannot both act
+// on it.
+func (s *Service) AcceptVote(ctx context.Context, pollID, voteID uint) error {
+    var p *domain.Poll
+    var v *domain.Vote
+    err := s.repo.WithTx(ctx, func(tx PollRepository) error {
+        var err error
+        p, err = tx.GetForUpdate(ctx, pollID)
+        if err != nil {
+            return fmt.Errorf("get poll: %w", err)
+        }
+        v, err = flaggedVote(ctx, tx, pollID, voteID)
+        if err != nil {
+            return err
+        }
+        if err := tx.SetVoteStatus(ctx, pollID, voteID, domain.VoteCounted); err != nil {
+            return fmt.Errorf("accept vote: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    return s.publishVote(ctx, p, v.OptionID)
+}
+
+// PurgeVote permanently removes a flagged vote, under the poll lock like AcceptVote.
+func (s *Service) PurgeVote(ctx context.Context, pollID, voteID uint) error {
+    err := s.repo.WithTx(ctx, func(tx PollRepository) error {
+        if _, err := tx.GetForUpdate(ctx, pollID); err != nil {
+            return fmt.Errorf("get poll: %w", err)
+        }
+        if _, err := flaggedVote(ctx, tx, pollID, voteID); err != nil {
+            return err
+        }
+        if err := tx.DeleteVote(ctx, pollID, voteID); err != nil {
+            return fmt.Errorf("purge vote: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    _, err = s.publishResults(ctx, pollID)
+    return err
+}
+
+// SetResponseHidden hides a text poll response from the results, or shows it again, and
+// rebroadcasts the results.
+func (s *Service) SetResponseHidden(ctx context.Context, pollID, voteID uint, hidden bool) error {
+    vs, err := s.repo.ListVotes(ctx, pollID, "")
+    if err != nil {
+        return fmt.Errorf("list votes: %w", err)
+    }
+    var v *domain.Vote
+    for i := range vs {
+        if vs[i].ID == voteID && vs[i].Text != "" {
+            v = &vs[i]
+        }
+    }
+    if v == nil {
+        return domain.ErrVoteNotFound
+    }
+    if v.Status == domain.VoteFlagged {
+        return errors.New("response is awaiting fraud review")
+    }
+    status := domain.VoteCounted
+    if hidden {
+        status = domain.VoteHidden
+    }
+    if err := s.repo.SetVoteStatus(ctx, pollID, voteID, status); err != nil {
+        return fmt.Errorf("set response status: %w", err)
+    }
+    _, err = s.publishResults(ctx, pollID)
+    return err
+}
+
+// flaggedVote returns the vote if it still awaits moderation; domain.ErrVoteNotFound otherwise.
+func flaggedVote(ctx context.Context, tx PollRepository, pollID, voteID uint) (*domain.Vote, error) {
+    v, err := tx.GetVote(ctx, pollID, voteID)
+    if errors.Is(err, domain.ErrVoteNotFound) {
+        return nil, err
+    }
+    if err != nil {
+        return nil, fmt.Errorf("get vote: %w", err)
+    }
+    if v.Status != domain.VoteFlagged {
+        return nil, domain.ErrVoteNotFound
+    }
+    return v, nil
+}
@@ -0,0 +1,443 @@
+package app_test
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/json"
+    "errors"
+    "math/bits"
+    "strconv"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/robjsliwa/pulse/adapters/memory"
+    "github.com/robjsliwa/pulse/app"
+    "github.com/robjsliwa/pulse/domain"
+    "github.com/robjsliwa/pulse/internal/pow"
+)
+
+// stream records the last results broadcast per poll.
+type stream struct {
+    mu   sync.Mutex
+    last map[uint]domain.Results
+}
+
+func (s *stream) Broadcast(pollID uint, res domain.Results) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.last == nil { s.last = map[uint]domain.Results{} }
+    s.last[pollID] = res
+}
+
+func (s *stream) Subscribe(uint) (<-chan domain.Results, func()) { return nil, func() {} }
+
+// webhooks records dispatched event names.
+type webhooks struct {
+    mu     sync.Mutex
+    events []string
+}
+
+func (w *webhooks) Dispatch(_ context.Context, event string, _ any) error {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    w.events = append(w.events, event)
+    return nil
+}
+
+func (w *webhooks) sent(event string) int {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    n := 0
+    for _, e := range w.events {
+        if e == event { n++ }
+    }
+    return n
+}
+
+func ptr[T any](v T) *T { return &v }
+
+type fixture struct {
+    svc      *app.Service
+    repo     *memory.Repo
+    stream   *stream
+    webhooks *webhooks
+}
+
+func newFixture(opts ...app.ServiceOption) fixture {
+    f := fixture{repo: memory.NewRepo(), stream: &stream{}, webhooks: &webhooks{}}
+    f.svc = app.NewService(f.repo, f.stream, f.webhooks, opts...)
+    return f
+}
+
+func (f fixture) poll(t *testing.T, p domain.Poll) *domain.Poll {
+    t.Helper()
+    if len(p.Options) == 0 { p.Options = []domain.Option{{Text: "yes"}, {Text: "no"}} }
+    if p.Title == "" { p.Title = "Ship it?" }
+    created, err := f.svc.CreatePoll(context.Background(), p)
+    if err != nil { t.Fatalf("create poll: %v", err) }
+    got, err := f.svc.GetPoll(context.Background(), created.ID)
+    if err != nil { t.Fatalf("get poll: %v", err) }
+    return got
+}
+
+func TestVoteCountsAndBroadcasts(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture()
+    p := f.poll(t, domain.Poll{})
+    for _, u := range []string{"u1", "u2"} {
+        if _, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: p.Options[0].ID, UserID: u}, nil); err != nil { t.Fatalf("vote: %v", err) }
+    }
+    if _, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: p.Options[1].ID, UserID: "u3"}, nil); err != nil { t.Fatalf("vote: %v", err) }
+    res, err := f.svc.Results(ctx, p.ID)
+    if err != nil { t.Fatalf("results: %v", err) }
+    if res.Total != 3 || res.OptionVotes[p.Options[0].ID] != 2 || res.OptionVotes[p.Options[1].ID] != 1 { t.Fatalf("results = %+v", res) }
+    if f.stream.last[p.ID].Total != 3 { t.Fatalf("broadcast total = %d, want 3", f.stream.last[p.ID].Total) }
+    if n := f.webhooks.sent("vote.created"); n != 3 { t.Fatalf("vote.created sent %d times, want 3", n) }
+}
+
+func TestVoteRejectsForeignOption(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture()
+    p, other := f.poll(t, domain.Poll{}), f.poll(t, domain.Poll{})
+    _, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: other.Options[0].ID}, nil)
+    if !errors.Is(err, domain.ErrOptionNotFound) { t.Fatalf("vote with another poll's option: %v, want ErrOptionNotFound", err) }
+}
+
+func TestClosedPollTakesNoVotes(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture()
+    p := f.poll(t, domain.Poll{})
+    if _, err := f.svc.ClosePoll(ctx, p.ID, 0); err != nil { t.Fatalf("close: %v", err) }
+    if _, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: p.Options[0].ID}, nil); err == nil { t.Fatalf("vote on closed poll accepted") }
+    if n := f.webhooks.sent("poll.closed"); n != 1 { t.Fatalf("poll.closed sent %d times, want 1", n) }
+}
+
+func TestAnonymousPollOneBallotPerVoter(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture()
+    p := f.poll(t, domain.Poll{Anonymous: true})
+    v, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: p.Options[0].ID, UserID: "u1"}, nil)
+    if err != nil { t.Fatalf("vote: %v", err) }
+    if v.UserID != "" || v.ID != 0 { t.Fatalf("anonymous vote echoed its voter: %+v", v) }
+    _, err = f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: p.Options[1].ID, UserID: "u1"}, nil)
+    if !errors.Is(err, domain.ErrAlreadyVoted) { t.Fatalf("second vote: %v, want ErrAlreadyVoted", err) }
+    if _, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: p.Options[1].ID}, nil); err == nil { t.Fatalf("anonymous vote without user_id accepted") }
+}
+
+// ballotlessRepo fails every ballot, inside units of work too.
+type ballotlessRepo struct{ app.PollRepository }
+
+func (r ballotlessRepo) WithTx(ctx context.Context, fn func(tx app.PollRepository) error) error {
+    return r.PollRepository.WithTx(ctx, func(tx app.PollRepository) error { return fn(ballotlessRepo{tx}) })
+}
+
+func (ballotlessRepo) CreateBallot(context.Context, uint, uint) error { return errors.New("disk full") }
+
+func TestFailedBallotUndoesParticipation(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture()
+    p := f.poll(t, domain.Poll{Anonymous: true})
+    svc := app.NewService(ballotlessRepo{f.repo}, &stream{}, &webhooks{})
+    if _, err := svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: p.Options[0].ID, UserID: "u1"}, nil); err == nil { t.Fatal("vote with a failing ballot accepted") }
+    if err := f.repo.CreateParticipation(ctx, p.ID, "u1"); err != nil { t.Fatalf("participation kept after the ballot failed: %v", err) }
+}
+
+func TestUpdatePollChecksVersion(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture()
+    p := f.poll(t, domain.Poll{})
+    updated, err := f.svc.UpdatePoll(ctx, domain.PollPatch{ID: p.ID, Version: p.Version, Title: ptr("Ship it now?")})
+    if err != nil { t.Fatalf("update: %v", err) }
+    if updated.Title != "Ship it now?" || updated.Version != p.Version+1 { t.Fatalf("updated = %+v", updated) }
+    _, err = f.svc.UpdatePoll(ctx, domain.PollPatch{ID: p.ID, Version: p.Version, Title: ptr("stale")})
+    if !errors.Is(err, domain.ErrVersionConflict) { t.Fatalf("stale update: %v, want ErrVersionConflict", err) }
+}
+
+func TestUpdatePollClearsRateOverride(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture()
+    p := f.poll(t, domain.Poll{VoteRatePerMinute: 600, VoteBurst: 50})
+    updated, err := f.svc.UpdatePoll(ctx, domain.PollPatch{ID: p.ID, VoteRatePerMinute: ptr(0), VoteBurst: ptr(0)})
+    if err != nil { t.Fatalf("update: %v", err) }
+    if updated.VoteRatePerMinute != 0 || updated.VoteBurst != 0 { t.Fatalf("override kept: %+v", updated) }
+    if _, err := f.svc.UpdatePoll(ctx, domain.PollPatch{ID: p.ID, VoteRatePerMinute: ptr(-1)}); err == nil { t.Fatalf("negative vote rate accepted") }
+}
+
+func TestFailedVoteLeavesChallengeUnspent(t *testing.T) {
+    ctx := context.Background()
+    issuer, err := pow.NewIssuer([]byte("secret"), time.Minute)
+    if err != nil { t.Fatalf("new issuer: %v", err) }
+    f := newFixture(app.WithChallenges(issuer))
+    p := f.poll(t, domain.Poll{Anonymous: true, ChallengeDifficulty: 1})
+    if _, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: p.Options[0].ID, UserID: "u1"}, nil); !errors.Is(err, domain.ErrChallengeRequired) { t.Fatalf("vote without proof: %v, want ErrChallengeRequired", err) }
+    ch, err := f.svc.Challenge(ctx, p.ID)
+    if err != nil { t.Fatalf("challenge: %v", err) }
+    proof := &domain.ChallengeSolution{Token: ch.Token, Solution: solve(ch)}
+    // anonymous polls refuse a vote without user_id only after the proof checked out
+    if _, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: p.Options[0].ID}, proof); err == nil { t.Fatalf("anonymous vote without user_id accepted") }
+    if _, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: p.Options[0].ID, UserID: "u1"}, proof); err != nil { t.Fatalf("retry with the same proof: %v", err) }
+    _, err = f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: p.Options[0].ID, UserID: "u2"}, proof)
+    if !errors.Is(err, domain.ErrChallengeInvalid) { t.Fatalf("reused proof: %v, want ErrChallengeInvalid", err) }
+
+    updated, err := f.svc.UpdatePoll(ctx, domain.PollPatch{ID: p.ID, ChallengeDifficulty: ptr(0)})
+    if err != nil { t.Fatalf("update: %v", err) }
+    if updated.ChallengeDifficulty != 0 { t.Fatalf("challenge difficulty = %d, want 0", updated.ChallengeDifficulty) }
+    if _, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: p.Options[0].ID, UserID: "u2"}, nil); err != nil { t.Fatalf("vote once the challenge is off: %v", err) }
+}
+
+// solve brute-forces a hashcash solution for ch.
+func solve(ch domain.Challenge) string {
+    for n := 0; ; n++ {
+        s := strconv.Itoa(n)
+        sum := sha256.Sum256([]byte(ch.Token + s))
+        zeros := 0
+        for _, b := range sum {
+            zeros += bits.LeadingZeros8(b)
+            if b != 0 { break }
+        }
+        if zeros >= ch.Difficulty { return s }
+    }
+}
+
+func TestUpdateOptionClearsFields(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture()
+    p := f.poll(t, domain.Poll{Options: []domain.Option{{Text: "a", Description: "first", ImageURL: "/media/sha256/ab/abc.png", Color: "#fff", Metadata: json.RawMessage(`{"k":1}`)}, {Text: "b"}}})
+    a := p.Options[0].ID
+    opt, err := f.svc.UpdateOption(ctx, domain.OptionPatch{ID: a, PollID: p.ID, Text: ptr("A")}, 0)
+    if err != nil { t.Fatalf("update: %v", err) }
+    if opt.Text != "A" || opt.Description != "first" || opt.Color != "#fff" || string(opt.Metadata) != `{"k":1}` { t.Fatalf("absent fields changed: %+v", opt) }
+    opt, err = f.svc.UpdateOption(ctx, domain.OptionPatch{ID: a, PollID: p.ID, Description: ptr(""), ImageURL: ptr(""), Color: ptr(""), Metadata: json.RawMessage("null")}, 0)
+    if err != nil { t.Fatalf("clear: %v", err) }
+    if opt.Text != "A" || opt.Description != "" || opt.ImageURL != "" || opt.Color != "" || opt.Metadata != nil { t.Fatalf("fields not cleared: %+v", opt) }
+    if _, err := f.svc.UpdateOption(ctx, domain.OptionPatch{ID: a, PollID: p.ID, Text: ptr("")}, 0); err == nil { t.Fatalf("empty option text accepted") }
+}
+
+func TestClosedPollOptionsAreFinal(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture()
+    p := f.poll(t, domain.Poll{})
+    if _, err := f.svc.ClosePoll(ctx, p.ID, 0); err != nil { t.Fatalf("close: %v", err) }
+    a, b := p.Options[0].ID, p.Options[1].ID
+    if _, err := f.svc.UpdateOption(ctx, domain.OptionPatch{ID: a, PollID: p.ID, Text: ptr("maybe")}, 0); err == nil { t.Fatalf("option of a closed poll edited") }
+    if _, err := f.svc.ReorderOptions(ctx, p.ID, []uint{b, a}, 0); err == nil { t.Fatalf("options of a closed poll reordered") }
+    if err := f.svc.DeleteOption(ctx, p.ID, a, domain.OptionDeleteDiscard, 0, 0); err == nil { t.Fatalf("option of a closed poll deleted") }
+    got, err := f.svc.GetPoll(ctx, p.ID)
+    if err != nil { t.Fatalf("get poll: %v", err) }
+    if len(got.Options) != 2 || got.Options[0].ID != a || got.Options[0].Text != "yes" { t.Fatalf("options changed: %+v", got.Options) }
+}
+
+func TestSurveyQuestionOptionsAreFixed(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture()
+    sv, err := f.svc.CreateSurvey(ctx, domain.Survey{Title: "Team", Questions: []domain.Question{{Kind: domain.QuestionChoice, Prompt: "Lunch?", Options: []domain.Option{{Text: "pizza"}, {Text: "sushi"}}}}})
+    if err != nil { t.Fatalf("create survey: %v", err) }
+    q := sv.Questions[0]
+    a, b := q.Options[0].ID, q.Options[1].ID
+    if _, err := f.svc.AddOption(ctx, domain.Option{PollID: q.PollID, Text: "tacos"}, 0); err == nil { t.Fatalf("option added to a survey question") }
+    if _, err := f.svc.UpdateOption(ctx, domain.OptionPatch{ID: a, PollID: q.PollID, Text: ptr("salad")}, 0); err == nil { t.Fatalf("survey option edited") }
+    if _, err := f.svc.ReorderOptions(ctx, q.PollID, []uint{b, a}, 0); err == nil { t.Fatalf("survey options reordered") }
+    if err := f.svc.DeleteOption(ctx, q.PollID, a, domain.OptionDeleteDiscard, 0, 0); err == nil { t.Fatalf("survey option deleted") }
+    got, err := f.svc.GetSurvey(ctx, sv.ID)
+    if err != nil { t.Fatalf("get survey: %v", err) }
+    if opts := got.Questions[0].Options; len(opts) != 2 || opts[0].ID != a || opts[0].Text != "pizza" { t.Fatalf("options changed: %+v", opts) }
+}
+
+func TestSurveyQuestionPollsFollowTheSurvey(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture()
+    sv, err := f.svc.CreateSurvey(ctx, domain.Survey{Title: "Team", Questions: []domain.Question{{Kind: domain.QuestionChoice, Prompt: "Lunch?", Options: []domain.Option{{Text: "pizza"}, {Text: "sushi"}}}}})
+    if err != nil { t.Fatalf("create survey: %v", err) }
+    q := sv.Questions[0]
+    if _, err := f.svc.ClosePoll(ctx, q.PollID, 0); err == nil { t.Fatalf("survey question poll closed on its own") }
+    if _, err := f.svc.UpdatePoll(ctx, domain.PollPatch{ID: q.PollID, Title: ptr("Dinner?")}); err == nil { t.Fatalf("survey question poll updated on its own") }
+    p, err := f.repo.GetByID(ctx, q.PollID)
+    if err != nil { t.Fatalf("get poll: %v", err) }
+    if p.Status == domain.PollClosed || p.Title != "Lunch?" { t.Fatalf("poll changed: %+v", p) }
+    // a question poll closed behind the survey's back takes no more answers
+    p.Status = domain.PollClosed
+    if err := f.repo.Update(ctx, p); err != nil { t.Fatalf("update poll: %v", err) }
+    in := domain.SurveySubmission{SurveyID: sv.ID, Answers: []domain.SurveyAnswer{{QuestionID: q.ID, OptionID: q.Options[0].ID}}}
+    if _, err := f.svc.SubmitSurvey(ctx, in); err == nil { t.Fatalf("submission accepted for a closed question") }
+    vs, err := f.svc.ListVotes(ctx, q.PollID)
+    if err != nil { t.Fatalf("list votes: %v", err) }
+    if len(vs) != 0 { t.Fatalf("votes = %+v, want none", vs) }
+}
+
+func TestListVotesLeavesOutHiddenResponses(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture()
+    p, err := f.svc.CreatePoll(ctx, domain.Poll{Title: "One word for the launch?", Kind: domain.PollText})
+    if err != nil { t.Fatalf("create poll: %v", err) }
+    var ids []uint
+    for _, text := range []string{"ship it", "something rude"} {
+        v, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, Text: text}, nil)
+        if err != nil { t.Fatalf("vote: %v", err) }
+        ids = append(ids, v.ID)
+    }
+    if err := f.svc.SetResponseHidden(ctx, p.ID, ids[1], true); err != nil { t.Fatalf("hide: %v", err) }
+    vs, err := f.svc.ListVotes(ctx, p.ID)
+    if err != nil { t.Fatalf("list votes: %v", err) }
+    if len(vs) != 1 || vs[0].Text != "ship it" { t.Fatalf("votes = %+v, want only the visible response", vs) }
+}
+
+func TestQuizQuestionOptionsAreFixed(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture()
+    qz, err := f.svc.CreateQuiz(ctx, domain.Quiz{Title: "Capitals", Questions: []domain.QuizQuestion{{Prompt: "France?", Options: []domain.Option{{Text: "Lyon"}, {Text: "Paris", Correct: true}}}}})
+    if err != nil { t.Fatalf("create quiz: %v", err) }
+    q := qz.Questions[0]
+    a, b := q.Options[0].ID, q.Options[1].ID
+    if _, err := f.svc.AddOption(ctx, domain.Option{PollID: q.PollID, Text: "Nice"}, 0); err == nil { t.Fatalf("option added to a quiz question") }
+    opt, err := f.svc.UpdateOption(ctx, domain.OptionPatch{ID: b, PollID: q.PollID, Color: ptr("#0f0")}, 0)
+    if err == nil { t.Fatalf("quiz option edited: %+v", opt) }
+    if _, err := f.svc.ReorderOptions(ctx, q.PollID, []uint{b, a}, 0); err == nil { t.Fatalf("quiz options reordered") }
+    if err := f.svc.DeleteOption(ctx, q.PollID, a, domain.OptionDeleteDiscard, 0, 0); err == nil { t.Fatalf("quiz option deleted") }
+    got, err := f.svc.GetQuiz(ctx, qz.ID)
+    if err != nil { t.Fatalf("get quiz: %v", err) }
+    for _, o := range got.Questions[0].Options {
+        if o.Correct { t.Fatalf("unrevealed answer exposed: %+v", o) }
+    }
+    if opts := got.Questions[0].Options; len(opts) != 2 || opts[1].Color != "" { t.Fatalf("options changed: %+v", opts) }
+}
+
+func TestDeleteOptionReassignsVotes(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture()
+    p := f.poll(t, domain.Poll{Options: []domain.Option{{Text: "a"}, {Text: "b"}, {Text: "c"}}})
+    a, b := p.Options[0].ID, p.Options[1].ID
+    for _, u := range []string{"u1", "u2"} {
+        if _, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: a, UserID: u}, nil); err != nil { t.Fatalf("vote: %v", err) }
+    }
+    if err := f.svc.DeleteOption(ctx, p.ID, a, domain.OptionDeleteReassign, b, 0); err != nil { t.Fatalf("delete option: %v", err) }
+    res, err := f.svc.Results(ctx, p.ID)
+    if err != nil { t.Fatalf("results: %v", err) }
+    if len(res.Options) != 2 || res.OptionVotes[b] != 2 || res.Total != 2 { t.Fatalf("results = %+v", res) }
+}
+
+func TestDeletedPollCanBeRestored(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture()
+    p := f.poll(t, domain.Poll{})
+    if err := f.svc.DeletePoll(ctx, p.ID, 0); err != nil { t.Fatalf("delete: %v", err) }
+    if _, err := f.svc.GetPoll(ctx, p.ID); !errors.Is(err, domain.ErrPollNotFound) { t.Fatalf("get deleted poll: %v, want ErrPollNotFound", err) }
+    if _, err := f.svc.RestorePoll(ctx, p.ID); err != nil { t.Fatalf("restore: %v", err) }
+    if _, err := f.svc.GetPoll(ctx, p.ID); err != nil { t.Fatalf("get restored poll: %v", err) }
+}
+
+func TestCollectionTimesAreStoredInUTCAndCanBeCleared(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture()
+    zone := time.FixedZone("UTC+2", 2*3600)
+    opens, closes := time.Now().Add(-time.Hour).In(zone), time.Now().Add(time.Hour).In(zone)
+    c, err := f.svc.CreateCollection(ctx, domain.Collection{Name: "talks", OpensAt: &opens, ClosesAt: &closes}, nil)
+    if err != nil { t.Fatalf("create collection: %v", err) }
+    if c.OpensAt.Location() != time.UTC || c.ClosesAt.Location() != time.UTC || !c.OpensAt.Equal(opens) { t.Fatalf("times = %v, %v, want UTC", c.OpensAt, c.ClosesAt) }
+    later := closes.Add(time.Hour)
+    c, err = f.svc.UpdateCollection(ctx, domain.CollectionPatch{ID: c.ID, ClosesAt: &later})
+    if err != nil { t.Fatalf("move closes_at: %v", err) }
+    if c.ClosesAt.Location() != time.UTC || !c.ClosesAt.Equal(later) || c.Name != "talks" { t.Fatalf("collection = %+v", c) }
+    c, err = f.svc.UpdateCollection(ctx, domain.CollectionPatch{ID: c.ID, OpensAt: &time.Time{}, ClosesAt: &time.Time{}})
+    if err != nil { t.Fatalf("clear times: %v", err) }
+    if c.OpensAt != nil || c.ClosesAt != nil { t.Fatalf("times = %v, %v, want cleared", c.OpensAt, c.ClosesAt) }
+
+    future := time.Now().Add(time.Hour)
+    s, err := f.svc.CreateCollection(ctx, domain.Collection{Name: "later", OpensAt: &future}, nil)
+    if err != nil { t.Fatalf("create scheduled collection: %v", err) }
+    if _, err := f.svc.UpdateCollection(ctx, domain.CollectionPatch{ID: s.ID, OpensAt: &time.Time{}}); err == nil { t.Fatal("cleared opens_at of a scheduled collection") }
+}
+
+func TestOpenCollectionReopensOnlyPollsItClosed(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture()
+    own, shared := f.poll(t, domain.Poll{}), f.poll(t, domain.Poll{})
+    c, err := f.svc.CreateCollection(ctx, domain.Collection{Name: "talks"}, []uint{own.ID, shared.ID})
+    if err != nil { t.Fatalf("create collection: %v", err) }
+    if _, err := f.svc.ClosePoll(ctx, own.ID, 0); err != nil { t.Fatalf("close poll: %v", err) }
+    if _, err := f.svc.CloseCollection(ctx, c.ID); err != nil { t.Fatalf("close collection: %v", err) }
+    if _, err := f.svc.OpenCollection(ctx, c.ID); err != nil { t.Fatalf("open collection: %v", err) }
+    for id, want := range map[uint]domain.PollStatus{own.ID: domain.PollClosed, shared.ID: domain.PollOpen} {
+        p, err := f.svc.GetPoll(ctx, id)
+        if err != nil { t.Fatalf("get poll: %v", err) }
+        if p.Status != want { t.Fatalf("poll %d status = %s, want %s", id, p.Status, want) }
+    }
+}
+
+// flagAll quarantines every vote.
+type flagAll struct{}
+
+func (flagAll) Lookback() time.Duration { return time.Minute }
+func (flagAll) Screen(context.Context, domain.Vote, []domain.Vote) app.Screening {
+    return app.Screening{Flagged: true, Reasons: []string{"test"}}
+}
+
+func TestFlaggedVoteIsModeratedOnce(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture(app.WithVoteScreener(flagAll{}))
+    p := f.poll(t, domain.Poll{})
+    var ids []uint
+    for _, u := range []string{"u1", "u2"} {
+        v, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: p.Options[0].ID, UserID: u}, nil)
+        if err != nil || v.Status != domain.VoteFlagged { t.Fatalf("vote: %+v %v, want flagged", v, err) }
+        ids = append(ids, v.ID)
+    }
+    if err := f.svc.AcceptVote(ctx, p.ID, ids[0]); err != nil { t.Fatalf("accept: %v", err) }
+    if err := f.svc.AcceptVote(ctx, p.ID, ids[0]); !errors.Is(err, domain.ErrVoteNotFound) { t.Fatalf("accept twice: %v, want ErrVoteNotFound", err) }
+    if err := f.svc.PurgeVote(ctx, p.ID, ids[0]); !errors.Is(err, domain.ErrVoteNotFound) { t.Fatalf("purge an accepted vote: %v, want ErrVoteNotFound", err) }
+    if err := f.svc.PurgeVote(ctx, p.ID, ids[1]); err != nil { t.Fatalf("purge: %v", err) }
+    if err := f.svc.AcceptVote(ctx, p.ID, ids[1]); !errors.Is(err, domain.ErrVoteNotFound) { t.Fatalf("accept a purged vote: %v, want ErrVoteNotFound", err) }
+    res, err := f.svc.Results(ctx, p.ID)
+    if err != nil { t.Fatalf("results: %v", err) }
+    if res.Total != 1 { t.Fatalf("total = %d, want the accepted vote only", res.Total) }
+}
+
+func TestQuadraticVotesStayWithinBudget(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture()
+    p := f.poll(t, domain.Poll{CreditBudget: 10})
+    yes, no := p.Options[0].ID, p.Options[1].ID
+    if _, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: yes, UserID: "u1", Weight: 3}, nil); err != nil { t.Fatalf("vote: %v", err) }
+    // 3 votes cost 9, so a second vote on another option would cost 13 of 10 credits
+    _, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: no, UserID: "u1", Weight: 2}, nil)
+    if !errors.Is(err, domain.ErrInsufficientCredits) { t.Fatalf("vote over budget: got %v, want ErrInsufficientCredits", err) }
+    if _, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: no, UserID: "u1", Weight: 1}, nil); err != nil { t.Fatalf("vote: %v", err) }
+    // other voters have budgets of their own
+    if _, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: no, UserID: "u2", Weight: 3}, nil); err != nil { t.Fatalf("vote: %v", err) }
+    acc, err := f.svc.Credits(ctx, p.ID, "u1")
+    if err != nil { t.Fatalf("credits: %v", err) }
+    if acc.Spent != 10 || acc.Remaining != 0 || acc.Votes[yes] != 3 || acc.Votes[no] != 1 { t.Fatalf("account = %+v", acc) }
+}
+
+func TestQuadraticVoteReplacesAllocation(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture()
+    p := f.poll(t, domain.Poll{CreditBudget: 10})
+    yes := p.Options[0].ID
+    if _, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: yes, UserID: "u1", Weight: 3}, nil); err != nil { t.Fatalf("vote: %v", err) }
+    // the replaced allocation's 9 credits are not counted against the new one
+    if _, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: yes, UserID: "u1", Weight: 2}, nil); err != nil { t.Fatalf("replace: %v", err) }
+    acc, err := f.svc.Credits(ctx, p.ID, "u1")
+    if err != nil { t.Fatalf("credits: %v", err) }
+    if acc.Spent != 4 || acc.Remaining != 6 || acc.Votes[yes] != 2 { t.Fatalf("account = %+v", acc) }
+    res, err := f.svc.Results(ctx, p.ID)
+    if err != nil { t.Fatalf("results: %v", err) }
+    if res.Credits != 4 || res.OptionVotes[yes] != 1 { t.Fatalf("results = %+v", res) }
+}
+
+func TestQuadraticVoteOfZeroWithdraws(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture()
+    p := f.poll(t, domain.Poll{CreditBudget: 10})
+    yes, no := p.Options[0].ID, p.Options[1].ID
+    for _, opt := range []uint{yes, no} {
+        if _, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: opt, UserID: "u1", Weight: 2}, nil); err != nil { t.Fatalf("vote: %v", err) }
+    }
+    if _, err := f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: yes, UserID: "u1", Weight: 0}, nil); err != nil { t.Fatalf("withdraw: %v", err) }
+    acc, err := f.svc.Credits(ctx, p.ID, "u1")
+    if err != nil { t.Fatalf("credits: %v", err) }
+    if acc.Spent != 4 || acc.Remaining != 6 || len(acc.Votes) != 1 || acc.Votes[no] != 2 { t.Fatalf("account = %+v", acc) }
+    if f.stream.last[p.ID].Credits != 4 { t.Fatalf("broadcast credits = %d, want 4", f.stream.last[p.ID].Credits) }
+    _, err = f.svc.Vote(ctx, domain.Vote{PollID: p.ID, OptionID: no, UserID: "u1", Weight: -1}, nil)
+    if !errors.Is(err, domain.ErrInvalidVote) { t.Fatalf("negative votes: got %v, want ErrInvalidVote", err) }
+}
@@ -0,0 +1,249 @@
+package app
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// MaxSurveyTextBytes caps a free-text answer.
+const MaxSurveyTextBytes = 2000
+
+// MaxSurveyTextResponses caps the free-text answers listed per question in SurveyResults.
+const MaxSurveyTextResponses = 100
+
+// CreateSurvey stores a survey; each choice question gets a poll holding its options.
+func (s *Service) CreateSurvey(ctx context.Context, in domain.Survey) (*domain.Survey, error) {
+    if in.Title == "" || len(in.Questions) == 0 {
+        return nil, errors.New("invalid survey: title and questions required")
+    }
+    sv := domain.Survey{Title: in.Title, Description: in.Description, Status: domain.PollOpen}
+    for i, q := range in.Questions {
+        if strings.TrimSpace(q.Prompt) == "" {
+            return nil, fmt.Errorf("invalid survey: question %d: prompt required", i+1)
+        }
+        out := domain.Question{Kind: q.Kind, Prompt: q.Prompt, Required: q.Required}
+        switch q.Kind {
+        case domain.QuestionChoice:
+            if len(q.Options) == 0 {
+                return nil, fmt.Errorf("invalid survey: question %d: options required", i+1)
+            }
+            for _, o := range q.Options {
+                if err := validateOption(o); err != nil {
+                    return nil, fmt.Errorf("question %d: %w", i+1, err)
+                }
+                out.Options = append(out.Options, domain.Option{Text: o.Text, Description: o.Description, ImageURL: o.ImageURL, Color: o.Color, Metadata: o.Metadata})
+            }
+        case domain.QuestionText:
+            if len(q.Options) > 0 {
+                return nil, fmt.Errorf("invalid survey: question %d: text questions take no options", i+1)
+            }
+        default:
+            return nil, fmt.Errorf("invalid survey: question %d: unknown kind %q", i+1, q.Kind)
+        }
+        sv.Questions = append(sv.Questions, out)
+    }
+    if err := s.repo.CreateSurvey(ctx, &sv); err != nil {
+        return nil, fmt.Errorf("create survey: %w", err)
+    }
+    return s.GetSurvey(ctx, sv.ID)
+}
+
+func (s *Service) GetSurvey(ctx context.Context, id uint) (*domain.Survey, error) {
+    sv, err := s.repo.GetSurvey(ctx, id)
+    if err != nil {
+        return nil, fmt.Errorf("get survey: %w", err)
+    }
+    return sv, nil
+}
+
+func (s *Service) ListSurveys(ctx context.Context, offset, limit int) ([]domain.Survey, error) {
+    svs, err := s.repo.ListSurveys(ctx, offset, limit)
+    if err != nil {
+        return nil, fmt.Errorf("list surveys: %w", err)
+    }
+    return svs, nil
+}
+
+// CloseSurvey closes the survey together with the polls behind its choice questions.
+func (s *Service) CloseSurvey(ctx context.Context, id uint) (*domain.Survey, error) {
+    err := s.repo.WithTx(ctx, func(tx PollRepository) error {
+        sv, err := tx.GetSurveyForUpdate(ctx, id)
+        if err != nil {
+            return fmt.Errorf("get survey: %w", err)
+        }
+        if err := tx.SetSurveyStatus(ctx, id, domain.PollClosed); err != nil {
+            return fmt.Errorf("close survey: %w", err)
+        }
+        for _, q := range sv.Questions {
+            if q.PollID == 0 {
+                continue
+            }
+            p, err := tx.GetForUpdate(ctx, q.PollID)
+            if err != nil {
+                return fmt.Errorf("get question poll: %w", err)
+            }
+            p.Status = domain.PollClosed
+            if err := tx.Update(ctx, p); err != nil {
+                return fmt.Errorf("close question poll: %w", err)
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    _ = s.webhooks.Dispatch(ctx, "survey.closed", map[string]any{"survey_id": id})
+    return s.GetSurvey(ctx, id)
+}
+
+// SubmitSurvey records a whole submission or nothing: every answer must refer to a question of the
+// survey (at most once), choice answers to one of the question's options, and every required
+// question must be answered; otherwise domain.ErrInvalidSubmission. Choice answers are also cast
+// as votes in the questions' polls, so their results and streams update like any poll's.
+func (s *Service) SubmitSurvey(ctx context.Context, in domain.SurveySubmission) (*domain.SurveySubmission, error) {
+    sub := &domain.SurveySubmission{SurveyID: in.SurveyID, UserID: in.UserID, CreatedAt: s.now()}
+    var polls []uint
+    err := s.repo.WithTx(ctx, func(tx PollRepository) error {
+        sv, err := tx.GetSurveyForUpdate(ctx, in.SurveyID)
+        if err != nil {
+            return fmt.Errorf("get survey: %w", err)
+        }
+        if sv.Status == domain.PollClosed {
+            return errors.New("survey is closed")
+        }
+        if sub.Answers, err = surveyAnswers(sv, in.Answers); err != nil {
+            return err
+        }
+        if err := tx.CreateSurveySubmission(ctx, sub); err != nil {
+            return fmt.Errorf("create submission: %w", err)
+        }
+        for _, a := range sub.Answers {
+            q := findQuestion(sv, a.QuestionID)
+            if q.Kind != domain.QuestionChoice {
+                continue
+            }
+            p, err := tx.GetForUpdate(ctx, q.PollID)
+            if err != nil {
+                return fmt.Errorf("get poll: %w", err)
+            }
+            if p.Status == domain.PollClosed {
+                return fmt.Errorf("question %d is closed", q.ID)
+            }
+            v := &domain.Vote{PollID: q.PollID, OptionID: a.OptionID, UserID: in.UserID, Status: domain.VoteCounted, CreatedAt: sub.CreatedAt}
+            if err := tx.CreateVote(ctx, v); err != nil {
+                return fmt.Errorf("create vote: %w", err)
+            }
+            polls = append(polls, q.PollID)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    for _, pollID := range polls {
+        if _, err := s.publishResults(ctx, pollID); err != nil {
+            return nil, err
+        }
+    }
+    _ = s.webhooks.Dispatch(ctx, "survey.submitted", map[string]any{"survey_id": sub.SurveyID, "submission_id": sub.ID})
+    return sub, nil
+}
+
+// surveyAnswers validates answers against the survey and returns them in question order.
+func surveyAnswers(sv *domain.Survey, answers []domain.SurveyAnswer) ([]domain.SurveyAnswer, error) {
+    byQuestion := make(map[uint]domain.SurveyAnswer, len(answers))
+    for _, a := range answers {
+        q := findQuestion(sv, a.QuestionID)
+        if q == nil {
+            return nil, fmt.Errorf("question %d is not part of survey %d: %w", a.QuestionID, sv.ID, domain.ErrInvalidSubmission)
+        }
+        if _, dup := byQuestion[q.ID]; dup {
+            return nil, fmt.Errorf("question %d answered twice: %w", q.ID, domain.ErrInvalidSubmission)
+        }
+        switch q.Kind {
+        case domain.QuestionChoice:
+            if a.Text != "" || !questionHasOption(q, a.OptionID) {
+                return nil, fmt.Errorf("question %d needs one of its option IDs: %w", q.ID, domain.ErrInvalidSubmission)
+            }
+            byQuestion[q.ID] = domain.SurveyAnswer{QuestionID: q.ID, OptionID: a.OptionID}
+        case domain.QuestionText:
+            text := strings.TrimSpace(a.Text)
+            if a.OptionID != 0 || text == "" || len(text) > MaxSurveyTextBytes {
+                return nil, fmt.Errorf("question %d needs text of at most %d bytes: %w", q.ID, MaxSurveyTextBytes, domain.ErrInvalidSubmission)
+            }
+            byQuestion[q.ID] = domain.SurveyAnswer{QuestionID: q.ID, Text: text}
+        }
+    }
+    out := make([]domain.SurveyAnswer, 0, len(byQuestion))
+    for _, q := range sv.Questions {
+        a, ok := byQuestion[q.ID]
+        if !ok {
+            if q.Required {
+                return nil, fmt.Errorf("question %d is required: %w", q.ID, domain.ErrInvalidSubmission)
+            }
+            continue
+        }
+        out = append(out, a)
+    }
+    return out, nil
+}
+
+// SurveyResults reports overall and per-question completion, the vote results of choice questions
+// and the latest free-text answers.
+func (s *Service) SurveyResults(ctx context.Context, id uint) (domain.SurveyResults, error) {
+    sv, err := s.repo.GetSurvey(ctx, id)
+    if err != nil {
+        return domain.SurveyResults{}, fmt.Errorf("get survey: %w", err)
+    }
+    t, err := s.repo.TallySurvey(ctx, id)
+    if err != nil {
+        return domain.SurveyResults{}, fmt.Errorf("tally survey: %w", err)
+    }
+    res := domain.SurveyResults{SurveyID: id, Submissions: t.Submissions, Complete: t.Complete, CompletionRate: ratio(t.Complete, t.Submissions), Questions: make([]domain.QuestionResult, 0, len(sv.Questions))}
+    for _, q := range sv.Questions {
+        qr := domain.QuestionResult{QuestionID: q.ID, Prompt: q.Prompt, Kind: q.Kind, Required: q.Required, Answered: t.Answered[q.ID], CompletionRate: ratio(t.Answered[q.ID], t.Submissions)}
+        switch q.Kind {
+        case domain.QuestionChoice:
+            pr, err := s.Results(ctx, q.PollID)
+            if err != nil {
+                return domain.SurveyResults{}, err
+            }
+            qr.Options = pr.Options
+        case domain.QuestionText:
+            if qr.Responses, err = s.repo.ListTextAnswers(ctx, q.ID, MaxSurveyTextResponses); err != nil {
+                return domain.SurveyResults{}, fmt.Errorf("list text answers: %w", err)
+            }
+        }
+        res.Questions = append(res.Questions, qr)
+    }
+    return res, nil
+}
+
+func findQuestion(sv *domain.Survey, questionID uint) *domain.Question {
+    for i := range sv.Questions {
+        if sv.Questions[i].ID == questionID {
+            return &sv.Questions[i]
+        }
+    }
+    return nil
+}
+
+func questionHasOption(q *domain.Question, optionID uint) bool {
+    for _, o := range q.Options {
+        if o.ID == optionID {
+            return true
+        }
+    }
+    return false
+}
+
+func ratio(n, of int) float64 {
+    if of == 0 {
+        return 0
+    }
+    return float64(n) / float64(of)
+}
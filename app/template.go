@@ -0,0 +1,257 @@
+package app
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// MaxTemplateVariables caps the custom variables of one template.
+const MaxTemplateVariables = 50
+
+var (
+    templateVar     = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+    templateVarName = regexp.MustCompile(`^\w+$`)
+)
+
+// CreateTemplate stores a template after checking that the polls it describes would be valid.
+// Every custom variable its text uses needs a default in Variables; values given when
+// instantiating override them.
+func (s *Service) CreateTemplate(ctx context.Context, in domain.PollTemplate) (*domain.PollTemplate, error) {
+    t, err := s.newTemplate(in)
+    if err != nil {
+        return nil, err
+    }
+    if err := s.repo.CreateTemplate(ctx, t); err != nil {
+        return nil, fmt.Errorf("create template: %w", err)
+    }
+    return t, nil
+}
+
+// newTemplate validates in and returns the template to store, with its first run scheduled.
+func (s *Service) newTemplate(in domain.PollTemplate) (*domain.PollTemplate, error) {
+    if strings.TrimSpace(in.Name) == "" {
+        return nil, errors.New("invalid template: name required")
+    }
+    if len(in.Variables) > MaxTemplateVariables {
+        return nil, fmt.Errorf("invalid template: at most %d variables", MaxTemplateVariables)
+    }
+    for name := range in.Variables {
+        if !templateVarName.MatchString(name) {
+            return nil, fmt.Errorf("invalid template: bad variable name %q", name)
+        }
+    }
+    if in.Poll.Weighted {
+        return nil, errors.New("invalid template: weighted polls need an electorate; clone one instead")
+    }
+    now := s.now().UTC()
+    t := &domain.PollTemplate{Name: in.Name, Poll: templatePoll(in.Poll), Variables: in.Variables, Schedule: strings.TrimSpace(in.Schedule), Series: in.Series}
+    check, err := expandTemplate(t, now, nil)
+    if err != nil {
+        return nil, fmt.Errorf("invalid template: %w", err)
+    }
+    if err := s.preparePoll(&check); err != nil {
+        return nil, fmt.Errorf("invalid template: %w", err)
+    }
+    if t.Schedule != "" {
+        if s.schedules == nil {
+            return nil, errors.New("invalid template: scheduled templates are not enabled")
+        }
+        next, err := s.schedules.Next(t.Schedule, now)
+        if err != nil {
+            return nil, fmt.Errorf("invalid template: %w", err)
+        }
+        t.NextRunAt = &next
+    }
+    return t, nil
+}
+
+func (s *Service) GetTemplate(ctx context.Context, id uint) (*domain.PollTemplate, error) {
+    t, err := s.repo.GetTemplate(ctx, id)
+    if err != nil {
+        return nil, fmt.Errorf("get template: %w", err)
+    }
+    return t, nil
+}
+
+func (s *Service) ListTemplates(ctx context.Context, offset, limit int) ([]domain.PollTemplate, error) {
+    ts, err := s.repo.ListTemplates(ctx, offset, limit)
+    if err != nil {
+        return nil, fmt.Errorf("list templates: %w", err)
+    }
+    return ts, nil
+}
+
+// DeleteTemplate removes a template; polls created from it stay.
+func (s *Service) DeleteTemplate(ctx context.Context, id uint) error {
+    if err := s.repo.DeleteTemplate(ctx, id); err != nil {
+        return fmt.Errorf("delete template: %w", err)
+    }
+    return nil
+}
+
+// InstantiateTemplate creates a poll from a template, with vars overriding its variables. For a
+// series template the poll becomes the series' latest instance.
+func (s *Service) InstantiateTemplate(ctx context.Context, id uint, vars map[string]string) (*domain.Poll, error) {
+    t, err := s.repo.GetTemplate(ctx, id)
+    if err != nil {
+        return nil, fmt.Errorf("get template: %w", err)
+    }
+    return s.instantiate(ctx, t, s.now().UTC(), vars)
+}
+
+// RunDueTemplates creates a poll for every scheduled template that is due and moves its schedule
+// on. A run missed while the server was down is made up once. It returns the polls created.
+func (s *Service) RunDueTemplates(ctx context.Context) ([]domain.Poll, error) {
+    if s.schedules == nil {
+        return nil, nil
+    }
+    now := s.now().UTC()
+    ts, err := s.repo.DueTemplates(ctx, now)
+    if err != nil {
+        return nil, fmt.Errorf("due templates: %w", err)
+    }
+    var created []domain.Poll
+    var errs []error
+    for _, t := range ts {
+        // a schedule that no longer fires, e.g. after a time zone was removed, is switched off
+        next, _ := s.schedules.Next(t.Schedule, now)
+        won, err := s.repo.AdvanceTemplate(ctx, t.ID, now, next)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("advance template %d: %w", t.ID, err))
+            continue
+        }
+        if !won {
+            continue
+        }
+        p, err := s.instantiate(ctx, &t, now, nil)
+        if p != nil {
+            created = append(created, *p)
+        }
+        if err != nil {
+            errs = append(errs, fmt.Errorf("template %d: %w", t.ID, err))
+        }
+    }
+    return created, errors.Join(errs...)
+}
+
+// instantiate creates a poll from t. A series template links the poll into its series and then
+// closes the instances before it; the new poll is returned even if closing them fails.
+func (s *Service) instantiate(ctx context.Context, t *domain.PollTemplate, now time.Time, vars map[string]string) (*domain.Poll, error) {
+    p, err := expandTemplate(t, now, vars)
+    if err != nil {
+        return nil, fmt.Errorf("instantiate template: %w", err)
+    }
+    if t.Series {
+        p.SeriesID = t.ID
+    }
+    created, err := s.CreatePoll(ctx, p)
+    if err != nil || !t.Series {
+        return created, err
+    }
+    return created, s.closePreviousInstances(ctx, created)
+}
+
+// ClonePoll creates an open poll with the settings and options of poll id, but none of its votes.
+// A weighted poll's clone gets the same electorate.
+func (s *Service) ClonePoll(ctx context.Context, id uint) (*domain.Poll, error) {
+    src, err := s.repo.GetByID(ctx, id)
+    if err != nil {
+        return nil, fmt.Errorf("get poll: %w", err)
+    }
+    if src.SurveyID != 0 || src.QuizID != 0 {
+        return nil, errors.New("survey and quiz polls cannot be cloned on their own")
+    }
+    p := templatePoll(*src)
+    if err := s.preparePoll(&p); err != nil {
+        return nil, err
+    }
+    err = s.repo.WithTx(ctx, func(tx PollRepository) error {
+        if err := tx.Create(ctx, &p); err != nil {
+            return fmt.Errorf("create poll: %w", err)
+        }
+        if !p.Weighted {
+            return nil
+        }
+        voters, err := tx.ListElectorate(ctx, src.ID)
+        if err != nil {
+            return fmt.Errorf("list electorate: %w", err)
+        }
+        for i := range voters {
+            voters[i].PollID = p.ID
+        }
+        if err := tx.SetElectorate(ctx, p.ID, voters); err != nil {
+            return fmt.Errorf("set electorate: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return &p, nil
+}
+
+// templatePoll copies the settings and options that define a poll, leaving out its identity,
+// state and the generated options of scale polls.
+func templatePoll(src domain.Poll) domain.Poll {
+    p := domain.Poll{
+        Kind: src.Kind, Language: src.Language, Scale: src.Scale, Title: src.Title, Description: src.Description,
+        ImageURL: src.ImageURL, Threshold: src.Threshold, ThresholdBasis: src.ThresholdBasis, Weighted: src.Weighted,
+        CreditBudget: src.CreditBudget, Anonymous: src.Anonymous, VoteRatePerMinute: src.VoteRatePerMinute,
+        VoteBurst: src.VoteBurst, ChallengeDifficulty: src.ChallengeDifficulty,
+    }
+    if isScale(src.Kind) {
+        return p
+    }
+    for _, o := range src.Options {
+        p.Options = append(p.Options, domain.Option{Text: o.Text, Description: o.Description, ImageURL: o.ImageURL, Color: o.Color, Metadata: o.Metadata})
+    }
+    return p
+}
+
+// expandTemplate returns the poll t describes with its {{variables}} replaced; vars override the
+// template's variables, which override the built-ins for now. Unknown variables are an error.
+func expandTemplate(t *domain.PollTemplate, now time.Time, vars map[string]string) (domain.Poll, error) {
+    _, week := now.ISOWeek()
+    values := map[string]string{
+        "date":    now.Format("2006-01-02"),
+        "time":    now.Format("15:04"),
+        "weekday": now.Weekday().String(),
+        "week":    strconv.Itoa(week),
+        "month":   now.Month().String(),
+        "year":    strconv.Itoa(now.Year()),
+    }
+    for k, v := range t.Variables {
+        values[k] = v
+    }
+    for k, v := range vars {
+        values[k] = v
+    }
+    var unknown []string
+    expand := func(text string) string {
+        return templateVar.ReplaceAllStringFunc(text, func(m string) string {
+            name := templateVar.FindStringSubmatch(m)[1]
+            v, ok := values[name]
+            if !ok {
+                unknown = append(unknown, name)
+            }
+            return v
+        })
+    }
+    p := templatePoll(t.Poll)
+    p.Title, p.Description = expand(p.Title), expand(p.Description)
+    for i := range p.Options {
+        p.Options[i].Text = expand(p.Options[i].Text)
+        p.Options[i].Description = expand(p.Options[i].Description)
+    }
+    if len(unknown) > 0 {
+        return p, fmt.Errorf("unknown variables: %s", strings.Join(unknown, ", "))
+    }
+    return p, nil
+}
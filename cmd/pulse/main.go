@@ -1,10 +1,13 @@
 package main
 
 import (
+    "context"
     "log"
     "net/http"
     "os"
+    "strconv"
     "strings"
+    "time"
 
     "github.com/gin-contrib/cors"
     "github.com/gin-contrib/requestid"
@@ -16,7 +19,16 @@ import (
     "github.com/robjsliwa/pulse/adapters/persistence"
     "github.com/robjsliwa/pulse/app"
     "github.com/robjsliwa/pulse/data"
+    "github.com/robjsliwa/pulse/internal/blob"
+    "github.com/robjsliwa/pulse/internal/fraud"
+    "github.com/robjsliwa/pulse/internal/idempotency"
+    "github.com/robjsliwa/pulse/internal/media"
+    "github.com/robjsliwa/pulse/internal/pow"
+    "github.com/robjsliwa/pulse/internal/ratelimit"
+    "github.com/robjsliwa/pulse/internal/reactions"
+    "github.com/robjsliwa/pulse/internal/schedule"
     "github.com/robjsliwa/pulse/internal/webhook"
+    "github.com/robjsliwa/pulse/internal/wordcloud"
     _ "github.com/robjsliwa/pulse/docs"
 )
 
@@ -24,61 +36,236 @@ import (
 // @version 0.1.0
 // @description Live polls & reactions service.
 // @BasePath /
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+// @description "Bearer " followed by ADMIN_TOKEN
 
 func main() {
     // Env
     port := getenv("PORT", "8080")
-    dbPath := getenv("DB_PATH", "./pulse.db")
+    dbDriver := getenv("DB_DRIVER", "")
+    // DATABASE_URL wins; DB_PATH is kept for existing SQLite setups.
+    dbDSN := getenv("DATABASE_URL", getenv("DB_PATH", "./pulse.db"))
+    dbCfg := data.Config{Driver: dbDriver, DSN: dbDSN}
+    if len(os.Args) > 1 && os.Args[1] == "migrate" {
+        runMigrate(dbCfg, os.Args[2:])
+        return
+    }
     corsOrigins := getenv("CORS_ORIGINS", "*")
+    // Note: X-Forwarded-For is only believed from these proxies; by default the peer address is the client IP.
+    trustedProxies := splitNonEmpty(getenv("TRUSTED_PROXIES", ""))
     maxRetries := atoi(getenv("WEBHOOK_MAX_RETRIES", "5"))
     webhookTargets := splitNonEmpty(getenv("WEBHOOK_TARGETS", ""))
     // Note: webhook secret is optional; if empty, signature is computed with empty key.
     secret := []byte(os.Getenv("WEBHOOK_SECRET"))
+    voteRate := atoi(getenv("VOTE_RATE_PER_MINUTE", "60"))
+    voteBurst := atoi(getenv("VOTE_RATE_BURST", "10"))
+    fraudScreening := getenv("FRAUD_SCREENING", "false") == "true"
+    fraudThreshold, _ := strconv.ParseFloat(getenv("FRAUD_THRESHOLD", "1"), 64)
+    fraudLookback := time.Duration(atoi(getenv("FRAUD_LOOKBACK_SECONDS", "300"))) * time.Second
+    // Note: without CHALLENGE_SECRET a random key is used, so challenges only verify on the issuing replica.
+    challengeSecret := []byte(os.Getenv("CHALLENGE_SECRET"))
+    voterSecret := []byte(os.Getenv("VOTER_SECRET"))
+    adminToken := os.Getenv("ADMIN_TOKEN")
+    challengeTTL := time.Duration(atoi(getenv("CHALLENGE_TTL_SECONDS", "120"))) * time.Second
+    idempotencyTTL := time.Duration(atoi(getenv("IDEMPOTENCY_TTL_HOURS", "24"))) * time.Hour
+    idempotencyStore := getenv("IDEMPOTENCY_STORE", "db")
+    requireIfMatch := getenv("REQUIRE_IF_MATCH", "false") == "true"
+    mediaStoreKind := getenv("MEDIA_STORE", "fs")
+    mediaDir := getenv("MEDIA_DIR", "./media")
+    mediaMaxBytes := int64(atoi(getenv("MEDIA_MAX_BYTES", strconv.Itoa(app.DefaultMaxMediaBytes))))
+    thumbnailSize := atoi(getenv("MEDIA_THUMBNAIL_SIZE", "320"))
+    trashRetention := time.Duration(atoi(getenv("TRASH_RETENTION_HOURS", "720"))) * time.Hour
+    purgeInterval := time.Duration(atoi(getenv("TRASH_PURGE_INTERVAL_MINUTES", "60"))) * time.Minute
+    wordcloudLanguage := getenv("WORDCLOUD_LANGUAGE", wordcloud.DefaultLanguage)
+    wordcloudMaxTerms := atoi(getenv("WORDCLOUD_MAX_TERMS", "100"))
+    reactionEmojis := splitNonEmpty(getenv("REACTION_EMOJIS", ""))
+    reactionWindow := atoi(getenv("REACTION_WINDOW_SECONDS", strconv.Itoa(reactions.DefaultWindowSeconds)))
+    reactionRate := atoi(getenv("REACTION_RATE_PER_SECOND", "5"))
+    reactionBurst := atoi(getenv("REACTION_BURST", "20"))
 
     // DB
-    db, err := data.Open(dbPath)
+    db, err := data.Open(dbCfg)
     if err != nil { log.Fatalf("db open: %v", err) }
 
     // Adapters
-    repo := persistence.NewRepo(db)
+    if len(voterSecret) == 0 { log.Printf("VOTER_SECRET is not set: anonymous polls will refuse votes") }
+    repo := persistence.NewRepo(db, persistence.WithVoterKey(voterSecret))
     broadcaster := httpadp.NewBroadcaster()
     dispatcher := webhook.NewDispatcher(webhookTargets, secret, maxRetries)
-    svc := app.NewService(repo, broadcaster, dispatcher)
+    issuer, err := pow.NewIssuer(challengeSecret, challengeTTL)
+    if err != nil { log.Fatalf("challenge issuer: %v", err) }
+    var mediaStore app.BlobStore
+    switch mediaStoreKind {
+    case "s3":
+        mediaStore, err = blob.NewS3Store(blob.S3Config{Endpoint: os.Getenv("S3_ENDPOINT"), Bucket: os.Getenv("S3_BUCKET"), Region: os.Getenv("S3_REGION"), AccessKeyID: os.Getenv("S3_ACCESS_KEY_ID"), SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY")})
+    default:
+        mediaStore, err = blob.NewFSStore(mediaDir)
+    }
+    if err != nil { log.Fatalf("media store: %v", err) }
+    svcOpts := []app.ServiceOption{app.WithChallenges(issuer), app.WithBlobStore(mediaStore), app.WithThumbnailer(media.NewThumbnailer(thumbnailSize)), app.WithMaxMediaBytes(mediaMaxBytes), app.WithTermCounter(wordcloud.NewCounter(wordcloudLanguage, wordcloudMaxTerms)), app.WithLeaderboards(broadcaster), app.WithReactions(reactions.NewWindow(reactionWindow), broadcaster, reactionEmojis), app.WithSchedules(schedule.Cron{}), app.WithCollections(broadcaster)}
+    if fraudScreening { svcOpts = append(svcOpts, app.WithVoteScreener(fraud.DefaultPipeline(fraudThreshold, fraudLookback))) }
+    svc := app.NewService(repo, broadcaster, dispatcher, svcOpts...)
+    go purgeTrash(svc, trashRetention, purgeInterval)
+    go publishReactions(svc)
+    go runTemplates(svc)
+    go runCollections(svc)
 
     // HTTP
     r := gin.New()
+    if err := r.SetTrustedProxies(trustedProxies); err != nil { log.Fatalf("trusted proxies: %v", err) }
     r.Use(gin.Recovery())
     r.Use(requestid.New())
     r.Use(corsMiddleware(corsOrigins))
-    r.Use(limitBody(1 << 20)) // 1MB payload limit
+    jsonLimit := limitBody(1 << 20)                   // 1MB payload limit
+    uploadLimit := limitBody(mediaMaxBytes + 64<<10) // room for multipart framing
 
-    h := httpadp.NewHandler(svc, broadcaster)
+    h := httpadp.NewHandler(svc, broadcaster, httpadp.WithRequireIfMatch(requireIfMatch), httpadp.WithReactionStream(broadcaster), httpadp.WithLeaderboardStream(broadcaster), httpadp.WithCollectionStream(broadcaster))
+    voteLimiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.PerMinute(voteRate, voteBurst))
+    reactionLimiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Limit{Rate: float64(reactionRate), Burst: reactionBurst})
+    var idemStore idempotency.Store = persistence.NewIdempotencyStore(db)
+    if idempotencyStore == "memory" { idemStore = idempotency.NewMemoryStore() }
+    idempotent := httpadp.Idempotent(idemStore, idempotencyTTL)
+    if adminToken == "" { log.Printf("ADMIN_TOKEN is not set: /admin and moderation endpoints are disabled") }
+    adminAuth := httpadp.AdminAuth(adminToken)
 
     // Routes
     r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
     r.GET("/healthz", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"ok": true}) })
+    r.GET("/reactions", h.ReactionEmojis)
 
-    polls := r.Group("/polls")
+    polls := r.Group("/polls", jsonLimit)
     {
-        polls.POST("", h.CreatePoll)
+        polls.POST("", idempotent, h.CreatePoll)
         polls.GET("", h.ListPolls)
         polls.GET(":id", h.GetPoll)
         polls.PATCH(":id", h.UpdatePoll)
         polls.DELETE(":id", h.DeletePoll)
+        polls.POST(":id/restore", h.RestorePoll)
         polls.POST(":id/close", h.ClosePoll)
+        polls.POST(":id/clone", idempotent, h.ClonePoll)
+        polls.PUT(":id/recurrence", h.SetRecurrence)
 
         polls.POST(":id/options", h.AddOption)
         polls.GET(":id/options", h.ListOptions)
+        polls.PUT(":id/options/order", h.ReorderOptions)
+        polls.PATCH(":id/options/:optionId", h.UpdateOption)
+        polls.DELETE(":id/options/:optionId", h.DeleteOption)
 
-        polls.POST(":id/votes", h.Vote)
+        polls.PUT(":id/electorate", h.SetElectorate)
+        polls.GET(":id/electorate", h.Electorate)
+        polls.GET(":id/challenge", h.Challenge)
+        polls.GET(":id/credits", h.Credits)
+        polls.POST(":id/votes", idempotent, h.VoteRateLimit(voteLimiter), h.Vote)
+        polls.GET(":id/votes", h.ListVotes)
+        polls.GET(":id/export", h.ExportVotes)
+        polls.POST(":id/reactions", h.ReactionRateLimit(reactionLimiter), h.React)
+        polls.GET(":id/reactions", h.Reactions)
+        polls.GET(":id/flagged-votes", adminAuth, h.ListFlaggedVotes)
+        polls.POST(":id/flagged-votes/:voteId/accept", adminAuth, h.AcceptFlaggedVote)
+        polls.DELETE(":id/flagged-votes/:voteId", adminAuth, h.PurgeFlaggedVote)
+        polls.POST(":id/responses/:voteId/hide", h.HideResponse)
+        polls.POST(":id/responses/:voteId/show", h.ShowResponse)
         polls.GET(":id/results", h.Results)
         polls.GET(":id/results/stream", h.ResultsStream)
     }
 
+    surveys := r.Group("/surveys", jsonLimit)
+    {
+        surveys.POST("", idempotent, h.CreateSurvey)
+        surveys.GET("", h.ListSurveys)
+        surveys.GET(":id", h.GetSurvey)
+        surveys.POST(":id/close", h.CloseSurvey)
+        surveys.POST(":id/submissions", idempotent, h.SubmitSurvey)
+        surveys.GET(":id/results", h.SurveyResults)
+    }
+
+    quizzes := r.Group("/quizzes", jsonLimit)
+    {
+        quizzes.POST("", idempotent, h.CreateQuiz)
+        quizzes.GET("", h.ListQuizzes)
+        quizzes.GET(":id", h.GetQuiz)
+        quizzes.POST(":id/questions/:questionId/open", h.OpenQuizQuestion)
+        quizzes.POST(":id/questions/:questionId/reveal", h.RevealQuizQuestion)
+        quizzes.GET(":id/leaderboard", h.Leaderboard)
+        quizzes.GET(":id/leaderboard/stream", h.LeaderboardStream)
+    }
+
+    templates := r.Group("/templates", jsonLimit)
+    {
+        templates.POST("", idempotent, h.CreateTemplate)
+        templates.GET("", h.ListTemplates)
+        templates.GET(":id", h.GetTemplate)
+        templates.DELETE(":id", h.DeleteTemplate)
+        templates.POST(":id/instantiate", idempotent, h.InstantiateTemplate)
+    }
+
+    r.GET("/series/:id/results", h.SeriesResults)
+
+    collections := r.Group("/collections", jsonLimit)
+    {
+        collections.POST("", idempotent, h.CreateCollection)
+        collections.GET("", h.ListCollections)
+        collections.GET(":id", h.GetCollection)
+        collections.PATCH(":id", h.UpdateCollection)
+        collections.DELETE(":id", h.DeleteCollection)
+        collections.POST(":id/polls", h.AddCollectionPoll)
+        collections.DELETE(":id/polls/:pollId", h.RemoveCollectionPoll)
+        collections.POST(":id/open", h.OpenCollection)
+        collections.POST(":id/close", h.CloseCollection)
+        collections.GET(":id/stats", h.CollectionStats)
+        collections.GET(":id/stream", h.CollectionStream)
+    }
+
+    // Uploads get their own, larger body limit.
+    r.PUT("/polls/:id/options/:optionId/image", uploadLimit, h.SetOptionImage)
+    r.POST("/media", uploadLimit, h.UploadMedia)
+    r.GET("/media/*key", h.GetMedia)
+
+    admin := r.Group("/admin", adminAuth, jsonLimit)
+    {
+        admin.GET("consistency", h.CheckConsistency)
+        admin.POST("consistency/repair", h.RepairConsistency)
+    }
+
     log.Printf("Pulse listening on :%s", port)
     if err := r.Run(":" + port); err != nil { log.Fatalf("server error: %v", err) }
 }
 
+// publishReactions broadcasts reaction bursts once a second.
+func publishReactions(svc *app.Service) {
+    for range time.Tick(time.Second) { svc.PublishReactionBursts() }
+}
+
+// runTemplates creates the polls of scheduled templates as they fall due; schedules have minute resolution.
+func runTemplates(svc *app.Service) {
+    for range time.Tick(time.Minute) {
+        ps, err := svc.RunDueTemplates(context.Background())
+        if err != nil { log.Printf("templates: %v", err) }
+        for _, p := range ps { log.Printf("templates: created poll %d %q", p.ID, p.Title) }
+    }
+}
+
+// runCollections opens and closes collections as their OpensAt and ClosesAt come, to the minute.
+func runCollections(svc *app.Service) {
+    for range time.Tick(time.Minute) {
+        cs, err := svc.RunCollectionSchedules(context.Background())
+        if err != nil { log.Printf("collections: %v", err) }
+        for _, c := range cs { log.Printf("collections: %s collection %d %q", c.Status, c.ID, c.Name) }
+    }
+}
+
+// purgeTrash permanently removes polls that have sat in the trash longer than retention.
+func purgeTrash(svc *app.Service, retention, interval time.Duration) {
+    if interval <= 0 { return }
+    for range time.Tick(interval) {
+        n, err := svc.PurgeDeletedPolls(context.Background(), retention)
+        if err != nil { log.Printf("trash purge: %v", err); continue }
+        if n > 0 { log.Printf("trash purge: removed %d polls", n) }
+    }
+}
+
 func getenv(k, def string) string { if v := os.Getenv(k); v != "" { return v }; return def }
 
 func atoi(s string) int { n := 0; for _, ch := range s { if ch < '0' || ch > '9' { continue }; n = n*10 + int(ch-'0') }; return n }
@@ -98,9 +285,9 @@ func corsMiddleware(origins string) gin.HandlerFunc {
     } else {
         cfg.AllowOrigins = splitNonEmpty(origins)
     }
-    cfg.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"}
-    cfg.ExposeHeaders = []string{"Request-Id"}
-    cfg.AllowMethods = []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"}
+    cfg.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", httpadp.APIKeyHeader, httpadp.IdempotencyKeyHeader, "If-Match", "If-None-Match"}
+    cfg.ExposeHeaders = []string{"Request-Id", "Retry-After", "Idempotent-Replayed", "ETag"}
+    cfg.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
     return cors.New(cfg)
 }
 
@@ -0,0 +1,24 @@
+package main
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/gin-gonic/gin"
+)
+
+func TestCORSPreflightAllowsEveryRouteMethod(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    r := gin.New()
+    r.Use(corsMiddleware("https://app.example.com"))
+    for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+        req := httptest.NewRequest(http.MethodOptions, "/polls/1/options/order", nil)
+        req.Header.Set("Origin", "https://app.example.com")
+        req.Header.Set("Access-Control-Request-Method", method)
+        w := httptest.NewRecorder()
+        r.ServeHTTP(w, req)
+        if w.Code != http.StatusNoContent || !strings.Contains(w.Header().Get("Access-Control-Allow-Methods"), method) { t.Fatalf("preflight for %s: %d, allowed %q", method, w.Code, w.Header().Get("Access-Control-Allow-Methods")) }
+    }
+}
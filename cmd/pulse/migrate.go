@@ -0,0 +1,43 @@
+package main
+
+import (
+    "fmt"
+    "log"
+    "os"
+    "strconv"
+
+    "github.com/robjsliwa/pulse/data"
+)
+
+const migrateUsage = "usage: pulse migrate up|down [steps]|status"
+
+// runMigrate implements `pulse migrate up|down [steps]|status`.
+func runMigrate(cfg data.Config, args []string) {
+    if len(args) == 0 { log.Fatal(migrateUsage) }
+    db, err := data.Connect(cfg)
+    if err != nil { log.Fatalf("db open: %v", err) }
+    switch args[0] {
+    case "up":
+        n, err := data.MigrateUp(db)
+        if err != nil { log.Fatalf("migrate up: %v", err) }
+        fmt.Printf("applied %d migration(s)\n", n)
+    case "down":
+        steps := 1
+        if len(args) > 1 {
+            if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 { log.Fatal(migrateUsage) }
+        }
+        n, err := data.MigrateDown(db, steps)
+        if err != nil { log.Fatalf("migrate down: %v", err) }
+        fmt.Printf("reverted %d migration(s)\n", n)
+    case "status":
+        states, err := data.MigrationStatus(db)
+        if err != nil { log.Fatalf("migrate status: %v", err) }
+        for _, s := range states {
+            applied := "pending"
+            if s.Applied { applied = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05") }
+            fmt.Fprintf(os.Stdout, "%04d_%-30s %s\n", s.Version, s.Name, applied)
+        }
+    default:
+        log.Fatal(migrateUsage)
+    }
+}
@@ -3,29 +3,70 @@ package data
 import (
     "fmt"
     "os"
+    "strings"
 
-    "github.com/robjsliwa/pulse/adapters/persistence"
+    "gorm.io/driver/postgres"
     "gorm.io/driver/sqlite"
     "gorm.io/gorm"
 )
 
-// Open opens the SQLite database and runs automigrations.
-func Open(dbPath string) (*gorm.DB, error) {
-    if dbPath == "" { dbPath = "./pulse.db" }
-    // ensure directory exists
-    if err := os.MkdirAll(dirname(dbPath), 0o755); err != nil { return nil, fmt.Errorf("mkdir db dir: %w", err) }
-    db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
-    if err != nil { return nil, fmt.Errorf("open db: %w", err) }
-    if err := db.AutoMigrate(&persistence.PollModel{}, &persistence.OptionModel{}, &persistence.VoteModel{}); err != nil {
-        return nil, fmt.Errorf("automigrate: %w", err)
+// Supported values for DB_DRIVER.
+const (
+    DriverSQLite   = "sqlite"
+    DriverPostgres = "postgres"
+)
+
+// Config selects the database dialect and connection.
+type Config struct {
+    Driver string // sqlite (default) or postgres; inferred from a postgres:// DSN when empty
+    DSN    string // SQLite file path or PostgreSQL connection URL
+}
+
+// Open connects to the configured database and refuses to continue unless all migrations are applied.
+func Open(cfg Config) (*gorm.DB, error) {
+    db, err := Connect(cfg)
+    if err != nil { return nil, err }
+    if err := CheckSchema(db); err != nil { return nil, err }
+    return db, nil
+}
+
+// Connect opens the configured database without checking its schema; used by the migrate command.
+func Connect(cfg Config) (*gorm.DB, error) {
+    driver := cfg.Driver
+    if driver == "" && (strings.HasPrefix(cfg.DSN, "postgres://") || strings.HasPrefix(cfg.DSN, "postgresql://")) {
+        driver = DriverPostgres
+    }
+    var dialector gorm.Dialector
+    switch driver {
+    case "", DriverSQLite:
+        dbPath := cfg.DSN
+        if dbPath == "" { dbPath = "./pulse.db" }
+        // ensure directory exists
+        if err := os.MkdirAll(dirname(dbPath), 0o755); err != nil { return nil, fmt.Errorf("mkdir db dir: %w", err) }
+        dialector = sqlite.Open(withSQLiteParams(dbPath))
+    case DriverPostgres:
+        if cfg.DSN == "" { return nil, fmt.Errorf("open db: DATABASE_URL required for postgres") }
+        dialector = postgres.Open(cfg.DSN)
+    default:
+        return nil, fmt.Errorf("open db: unsupported driver %q", driver)
     }
+    db, err := gorm.Open(dialector, &gorm.Config{})
+    if err != nil { return nil, fmt.Errorf("open db: %w", err) }
     return db, nil
 }
 
+// withSQLiteParams makes every transaction take the write lock up front (BEGIN IMMEDIATE) so a
+// read-check-write unit of work cannot interleave with another writer, waits instead of failing
+// when the lock is held, and turns on foreign key enforcement, which SQLite leaves off by default.
+func withSQLiteParams(dbPath string) string {
+    sep := "?"
+    if strings.Contains(dbPath, "?") { sep = "&" }
+    return dbPath + sep + "_txlock=immediate&_busy_timeout=5000&_foreign_keys=1"
+}
+
 func dirname(path string) string {
     i := len(path) - 1
     for i >= 0 && path[i] != '/' { i-- }
     if i <= 0 { return "." }
     return path[:i]
 }
-
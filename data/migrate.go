@@ -0,0 +1,186 @@
+package data
+
+import (
+    "embed"
+    "errors"
+    "fmt"
+    "io/fs"
+    "path"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "gorm.io/gorm"
+)
+
+// Migrations live in migrations/<dialect>/NNNN_name.{up,down}.sql and are embedded in the binary.
+//
+//go:embed migrations
+var migrationsFS embed.FS
+
+// ErrSchemaOutdated is returned by CheckSchema when migrations are pending.
+var ErrSchemaOutdated = errors.New("database schema is not up to date; run `pulse migrate up`")
+
+type Migration struct {
+    Version int
+    Name    string
+    Up      string
+    Down    string
+}
+
+type MigrationState struct {
+    Version   int
+    Name      string
+    Applied   bool
+    AppliedAt time.Time
+}
+
+type schemaMigration struct {
+    Version   int       `gorm:"primaryKey;autoIncrement:false"`
+    Name      string    `gorm:"not null"`
+    AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string { return "schema_migrations" }
+
+// Migrations returns the embedded migrations for a dialect in version order.
+func Migrations(dialect string) ([]Migration, error) {
+    dir := path.Join("migrations", dialect)
+    entries, err := fs.ReadDir(migrationsFS, dir)
+    if err != nil { return nil, fmt.Errorf("no migrations for dialect %q: %w", dialect, err) }
+    byVersion := map[int]*Migration{}
+    for _, e := range entries {
+        name := e.Name()
+        var up bool
+        switch {
+        case strings.HasSuffix(name, ".up.sql"): up = true
+        case strings.HasSuffix(name, ".down.sql"):
+        default: continue
+        }
+        base := strings.TrimSuffix(strings.TrimSuffix(name, ".up.sql"), ".down.sql")
+        num, label, ok := strings.Cut(base, "_")
+        v, err := strconv.Atoi(num)
+        if !ok || err != nil { return nil, fmt.Errorf("bad migration file name %q", name) }
+        body, err := fs.ReadFile(migrationsFS, path.Join(dir, name))
+        if err != nil { return nil, fmt.Errorf("read %s: %w", name, err) }
+        m, ok := byVersion[v]
+        if !ok { m = &Migration{Version: v, Name: label}; byVersion[v] = m }
+        if up { m.Up = string(body) } else { m.Down = string(body) }
+    }
+    out := make([]Migration, 0, len(byVersion))
+    for _, m := range byVersion {
+        if m.Up == "" || m.Down == "" { return nil, fmt.Errorf("migration %04d_%s needs both up and down scripts", m.Version, m.Name) }
+        out = append(out, *m)
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+    return out, nil
+}
+
+// MigrateUp applies all pending migrations, each in its own transaction, and returns how many ran.
+func MigrateUp(db *gorm.DB) (int, error) {
+    ms, applied, err := load(db)
+    if err != nil { return 0, err }
+    n := 0
+    err = withoutForeignKeys(db, func(db *gorm.DB) error {
+        for _, m := range ms {
+            if _, ok := applied[m.Version]; ok { continue }
+            err := db.Transaction(func(tx *gorm.DB) error {
+                if err := execScript(tx, m.Up); err != nil { return err }
+                return tx.Create(&schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now().UTC()}).Error
+            })
+            if err != nil { return fmt.Errorf("migration %04d_%s up: %w", m.Version, m.Name, err) }
+            n++
+        }
+        return nil
+    })
+    return n, err
+}
+
+// MigrateDown rolls back the latest steps applied migrations and returns how many were reverted.
+func MigrateDown(db *gorm.DB, steps int) (int, error) {
+    ms, applied, err := load(db)
+    if err != nil { return 0, err }
+    n := 0
+    err = withoutForeignKeys(db, func(db *gorm.DB) error {
+        for i := len(ms) - 1; i >= 0 && n < steps; i-- {
+            m := ms[i]
+            if _, ok := applied[m.Version]; !ok { continue }
+            err := db.Transaction(func(tx *gorm.DB) error {
+                if err := execScript(tx, m.Down); err != nil { return err }
+                return tx.Delete(&schemaMigration{}, m.Version).Error
+            })
+            if err != nil { return fmt.Errorf("migration %04d_%s down: %w", m.Version, m.Name, err) }
+            n++
+        }
+        return nil
+    })
+    return n, err
+}
+
+// withoutForeignKeys runs fn on a single SQLite connection with foreign key enforcement off, which
+// SQLite requires for rebuilding tables that take part in foreign keys. The pragma is a no-op
+// inside a transaction, hence the pinned connection. Other dialects run fn as is.
+func withoutForeignKeys(db *gorm.DB, fn func(db *gorm.DB) error) error {
+    if db.Dialector.Name() != DriverSQLite { return fn(db) }
+    return db.Connection(func(conn *gorm.DB) error {
+        if err := conn.Exec("PRAGMA foreign_keys = OFF").Error; err != nil { return err }
+        defer conn.Exec("PRAGMA foreign_keys = ON")
+        return fn(conn)
+    })
+}
+
+// MigrationStatus reports every known migration and whether it has been applied.
+func MigrationStatus(db *gorm.DB) ([]MigrationState, error) {
+    ms, applied, err := load(db)
+    if err != nil { return nil, err }
+    out := make([]MigrationState, 0, len(ms))
+    for _, m := range ms {
+        st := MigrationState{Version: m.Version, Name: m.Name}
+        if a, ok := applied[m.Version]; ok { st.Applied, st.AppliedAt = true, a.AppliedAt }
+        out = append(out, st)
+    }
+    return out, nil
+}
+
+// CheckSchema returns ErrSchemaOutdated unless every embedded migration has been applied.
+func CheckSchema(db *gorm.DB) error {
+    states, err := MigrationStatus(db)
+    if err != nil { return err }
+    var pending []string
+    for _, s := range states {
+        if !s.Applied { pending = append(pending, fmt.Sprintf("%04d_%s", s.Version, s.Name)) }
+    }
+    if len(pending) > 0 { return fmt.Errorf("%w (pending: %s)", ErrSchemaOutdated, strings.Join(pending, ", ")) }
+    return nil
+}
+
+func load(db *gorm.DB) ([]Migration, map[int]schemaMigration, error) {
+    ms, err := Migrations(db.Dialector.Name())
+    if err != nil { return nil, nil, err }
+    if err := db.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at TIMESTAMP NOT NULL)").Error; err != nil {
+        return nil, nil, fmt.Errorf("create schema_migrations: %w", err)
+    }
+    var rows []schemaMigration
+    if err := db.Order("version").Find(&rows).Error; err != nil { return nil, nil, fmt.Errorf("read schema_migrations: %w", err) }
+    applied := make(map[int]schemaMigration, len(rows))
+    for _, r := range rows { applied[r.Version] = r }
+    return ms, applied, nil
+}
+
+// execScript runs a migration script statement by statement. Statements end with ';' at end of line.
+func execScript(tx *gorm.DB, script string) error {
+    var stmt strings.Builder
+    for _, line := range strings.Split(script, "\n") {
+        trimmed := strings.TrimSpace(line)
+        if trimmed == "" || strings.HasPrefix(trimmed, "--") { continue }
+        stmt.WriteString(line)
+        stmt.WriteString("\n")
+        if strings.HasSuffix(trimmed, ";") {
+            if err := tx.Exec(stmt.String()).Error; err != nil { return err }
+            stmt.Reset()
+        }
+    }
+    if strings.TrimSpace(stmt.String()) != "" { return tx.Exec(stmt.String()).Error }
+    return nil
+}
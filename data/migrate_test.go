@@ -0,0 +1,75 @@
+package data_test
+
+import (
+    "context"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "github.com/robjsliwa/pulse/adapters/persistence"
+    "github.com/robjsliwa/pulse/data"
+    "gorm.io/gorm"
+)
+
+// The models as the AutoMigrate of the last release before migrations created them.
+type PollModel struct {
+    ID          uint      `gorm:"primaryKey"`
+    Title       string    `gorm:"not null"`
+    Description string
+    Status      string    `gorm:"index;not null"`
+    Threshold   int       `gorm:"default:0"`
+    CreatedAt   time.Time
+    UpdatedAt   time.Time
+    Options     []OptionModel `gorm:"foreignKey:PollID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+type OptionModel struct {
+    ID        uint      `gorm:"primaryKey"`
+    PollID    uint      `gorm:"index;not null"`
+    Text      string    `gorm:"not null"`
+    CreatedAt time.Time
+    UpdatedAt time.Time
+}
+
+type VoteModel struct {
+    ID        uint      `gorm:"primaryKey"`
+    PollID    uint      `gorm:"index;not null"`
+    OptionID  uint      `gorm:"index;not null"`
+    UserID    string    `gorm:"index"`
+    CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func TestMigrateUpAdoptsAutoMigratedDatabase(t *testing.T) {
+    ctx := context.Background()
+    cfg := data.Config{Driver: data.DriverSQLite, DSN: filepath.Join(t.TempDir(), "pulse.db")}
+    db, err := data.Connect(cfg)
+    if err != nil { t.Fatalf("connect: %v", err) }
+    if err := db.AutoMigrate(&PollModel{}, &OptionModel{}, &VoteModel{}); err != nil { t.Fatalf("automigrate: %v", err) }
+    p := PollModel{Title: "Lunch?", Status: "open", Options: []OptionModel{{Text: "pizza"}, {Text: "sushi"}}}
+    if err := db.Create(&p).Error; err != nil { t.Fatalf("seed poll: %v", err) }
+    for _, u := range []string{"u1", "u2"} {
+        if err := db.Create(&VoteModel{PollID: p.ID, OptionID: p.Options[0].ID, UserID: u}).Error; err != nil { t.Fatalf("seed vote: %v", err) }
+    }
+
+    if _, err := data.MigrateUp(db); err != nil { t.Fatalf("migrate up: %v", err) }
+    if err := data.CheckSchema(db); err != nil { t.Fatalf("check schema: %v", err) }
+    assertSeeded(ctx, t, db, p.ID, p.Options[0].ID)
+
+    // every down script undoes its up script
+    ms, err := data.Migrations(data.DriverSQLite)
+    if err != nil { t.Fatalf("migrations: %v", err) }
+    if n, err := data.MigrateDown(db, len(ms)-1); err != nil || n != len(ms)-1 { t.Fatalf("migrate down: %d, %v", n, err) }
+    if _, err := data.MigrateUp(db); err != nil { t.Fatalf("migrate up again: %v", err) }
+    assertSeeded(ctx, t, db, p.ID, p.Options[0].ID)
+}
+
+func assertSeeded(ctx context.Context, t *testing.T, db *gorm.DB, pollID, optionID uint) {
+    t.Helper()
+    repo := persistence.NewRepo(db)
+    got, err := repo.GetByID(ctx, pollID)
+    if err != nil { t.Fatalf("get poll: %v", err) }
+    if got.Title != "Lunch?" || len(got.Options) != 2 { t.Fatalf("poll = %+v", got) }
+    tally, err := repo.CountVotesByOption(ctx, pollID)
+    if err != nil { t.Fatalf("count votes: %v", err) }
+    if tally.Total != 2 || tally.Votes[optionID] != 2 { t.Fatalf("tally = %+v", tally) }
+}
@@ -0,0 +1,33 @@
+-- Baseline schema, matching the SQLite baseline; everything added since comes in the migrations
+-- after this one.
+CREATE TABLE IF NOT EXISTS poll_models (
+    id bigserial PRIMARY KEY,
+    title text NOT NULL,
+    description text,
+    status text NOT NULL,
+    threshold bigint DEFAULT 0,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_poll_models_status ON poll_models (status);
+
+CREATE TABLE IF NOT EXISTS option_models (
+    id bigserial PRIMARY KEY,
+    poll_id bigint NOT NULL,
+    text text NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_poll_models_options FOREIGN KEY (poll_id) REFERENCES poll_models (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_option_models_poll_id ON option_models (poll_id);
+
+CREATE TABLE IF NOT EXISTS vote_models (
+    id bigserial PRIMARY KEY,
+    poll_id bigint NOT NULL,
+    option_id bigint NOT NULL,
+    user_id text,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_vote_models_poll_id ON vote_models (poll_id);
+CREATE INDEX IF NOT EXISTS idx_vote_models_option_id ON vote_models (option_id);
+CREATE INDEX IF NOT EXISTS idx_vote_models_user_id ON vote_models (user_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS ballot_models;
+DROP TABLE IF EXISTS participation_models;
+ALTER TABLE poll_models DROP COLUMN anonymous;
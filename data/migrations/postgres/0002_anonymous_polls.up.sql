@@ -0,0 +1,17 @@
+-- Anonymous polls keep participation and ballots in separate tables that share no key. Participation
+-- has no serial ID or timestamp; the voter hash is its key, and ballots are keyed by a random ID.
+ALTER TABLE poll_models ADD COLUMN anonymous boolean DEFAULT false;
+
+CREATE TABLE participation_models (
+    poll_id bigint NOT NULL,
+    voter_hash text NOT NULL,
+    PRIMARY KEY (poll_id, voter_hash)
+);
+
+CREATE TABLE ballot_models (
+    id varchar(32) PRIMARY KEY,
+    poll_id bigint NOT NULL,
+    option_id bigint NOT NULL
+);
+CREATE INDEX idx_ballot_models_poll_id ON ballot_models (poll_id);
+CREATE INDEX idx_ballot_models_option_id ON ballot_models (option_id);
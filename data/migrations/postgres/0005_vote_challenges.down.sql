@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS spent_challenge_models;
+ALTER TABLE poll_models DROP COLUMN challenge_difficulty;
@@ -0,0 +1,9 @@
+-- Polls may require a proof-of-work challenge of this many leading zero bits with every vote.
+ALTER TABLE poll_models ADD COLUMN challenge_difficulty bigint DEFAULT 0;
+
+-- Solved challenge tokens are spent here until they expire, so a token buys one vote on any replica.
+CREATE TABLE spent_challenge_models (
+    challenge_key varchar(64) PRIMARY KEY,
+    expires_at timestamptz NOT NULL
+);
+CREATE INDEX idx_spent_challenge_models_expires_at ON spent_challenge_models (expires_at);
@@ -0,0 +1,11 @@
+-- Responses stored per Idempotency-Key, replayed to retries until they expire.
+CREATE TABLE idempotency_models (
+    idempotency_key varchar(255) PRIMARY KEY,
+    request_hash text NOT NULL,
+    completed boolean NOT NULL DEFAULT false,
+    status bigint,
+    body bytea,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz
+);
+CREATE INDEX idx_idempotency_models_expires_at ON idempotency_models (expires_at);
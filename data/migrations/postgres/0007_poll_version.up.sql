@@ -0,0 +1 @@
+ALTER TABLE poll_models ADD COLUMN version bigint NOT NULL DEFAULT 1;
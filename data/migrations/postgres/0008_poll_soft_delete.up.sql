@@ -0,0 +1,2 @@
+ALTER TABLE poll_models ADD COLUMN deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_poll_models_deleted_at ON poll_models (deleted_at);
@@ -0,0 +1,6 @@
+ALTER TABLE participation_models DROP CONSTRAINT IF EXISTS fk_participation_models_poll;
+ALTER TABLE ballot_models DROP CONSTRAINT IF EXISTS fk_ballot_models_option;
+ALTER TABLE ballot_models DROP CONSTRAINT IF EXISTS fk_ballot_models_poll;
+ALTER TABLE vote_models DROP CONSTRAINT IF EXISTS fk_vote_models_option;
+ALTER TABLE vote_models DROP CONSTRAINT IF EXISTS fk_vote_models_poll;
+ALTER TABLE option_models DROP CONSTRAINT IF EXISTS uq_option_models_id_poll;
@@ -0,0 +1,9 @@
+-- Votes, ballots and participation reference their poll, and votes/ballots reference an option of
+-- that same poll. NOT VALID enforces the constraints for new rows without failing on rows orphaned
+-- earlier; the consistency checker repairs those and then validates the constraints.
+ALTER TABLE option_models ADD CONSTRAINT uq_option_models_id_poll UNIQUE (id, poll_id);
+ALTER TABLE vote_models ADD CONSTRAINT fk_vote_models_poll FOREIGN KEY (poll_id) REFERENCES poll_models (id) ON DELETE CASCADE NOT VALID;
+ALTER TABLE vote_models ADD CONSTRAINT fk_vote_models_option FOREIGN KEY (option_id, poll_id) REFERENCES option_models (id, poll_id) ON DELETE CASCADE NOT VALID;
+ALTER TABLE ballot_models ADD CONSTRAINT fk_ballot_models_poll FOREIGN KEY (poll_id) REFERENCES poll_models (id) ON DELETE CASCADE NOT VALID;
+ALTER TABLE ballot_models ADD CONSTRAINT fk_ballot_models_option FOREIGN KEY (option_id, poll_id) REFERENCES option_models (id, poll_id) ON DELETE CASCADE NOT VALID;
+ALTER TABLE participation_models ADD CONSTRAINT fk_participation_models_poll FOREIGN KEY (poll_id) REFERENCES poll_models (id) ON DELETE CASCADE NOT VALID;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_option_models_poll_position;
+ALTER TABLE option_models DROP COLUMN position;
@@ -0,0 +1,4 @@
+ALTER TABLE option_models ADD COLUMN position bigint NOT NULL DEFAULT 0;
+-- existing options keep their insertion order
+UPDATE option_models SET position = (SELECT COUNT(*) FROM option_models o WHERE o.poll_id = option_models.poll_id AND o.id < option_models.id);
+CREATE INDEX IF NOT EXISTS idx_option_models_poll_position ON option_models (poll_id, position);
@@ -0,0 +1,4 @@
+ALTER TABLE option_models ADD COLUMN description text;
+ALTER TABLE option_models ADD COLUMN image_url text;
+ALTER TABLE option_models ADD COLUMN color text;
+ALTER TABLE option_models ADD COLUMN metadata text;
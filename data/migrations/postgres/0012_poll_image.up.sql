@@ -0,0 +1 @@
+ALTER TABLE poll_models ADD COLUMN image_url text;
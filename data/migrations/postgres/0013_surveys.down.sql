@@ -0,0 +1,5 @@
+ALTER TABLE poll_models DROP COLUMN survey_id;
+DROP TABLE IF EXISTS survey_answer_models;
+DROP TABLE IF EXISTS survey_submission_models;
+DROP TABLE IF EXISTS survey_question_models;
+DROP TABLE IF EXISTS survey_models;
@@ -0,0 +1,50 @@
+-- Surveys group ordered questions. Choice questions are backed by a poll (poll_models.survey_id
+-- points back at the survey); answers to every question are kept per submission.
+CREATE TABLE survey_models (
+    id bigserial PRIMARY KEY,
+    title text NOT NULL,
+    description text,
+    status text NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE INDEX idx_survey_models_status ON survey_models (status);
+
+CREATE TABLE survey_question_models (
+    id bigserial PRIMARY KEY,
+    survey_id bigint NOT NULL,
+    position bigint NOT NULL DEFAULT 0,
+    kind text NOT NULL,
+    prompt text NOT NULL,
+    required boolean NOT NULL DEFAULT false,
+    poll_id bigint,
+    CONSTRAINT fk_survey_question_models_survey FOREIGN KEY (survey_id) REFERENCES survey_models (id) ON DELETE CASCADE,
+    CONSTRAINT fk_survey_question_models_poll FOREIGN KEY (poll_id) REFERENCES poll_models (id)
+);
+CREATE INDEX idx_survey_question_models_survey_id ON survey_question_models (survey_id);
+CREATE INDEX idx_survey_question_models_poll_id ON survey_question_models (poll_id);
+
+CREATE TABLE survey_submission_models (
+    id bigserial PRIMARY KEY,
+    survey_id bigint NOT NULL,
+    user_id text,
+    created_at timestamptz,
+    CONSTRAINT fk_survey_submission_models_survey FOREIGN KEY (survey_id) REFERENCES survey_models (id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_survey_submission_user ON survey_submission_models (survey_id, user_id);
+
+CREATE TABLE survey_answer_models (
+    id bigserial PRIMARY KEY,
+    submission_id bigint NOT NULL,
+    question_id bigint NOT NULL,
+    option_id bigint,
+    text text,
+    created_at timestamptz,
+    CONSTRAINT fk_survey_answer_models_submission FOREIGN KEY (submission_id) REFERENCES survey_submission_models (id) ON DELETE CASCADE,
+    CONSTRAINT fk_survey_answer_models_question FOREIGN KEY (question_id) REFERENCES survey_question_models (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_survey_answer_models_submission_id ON survey_answer_models (submission_id);
+CREATE INDEX idx_survey_answer_models_question_id ON survey_answer_models (question_id);
+
+ALTER TABLE poll_models ADD COLUMN survey_id bigint;
+CREATE INDEX idx_poll_models_survey_id ON poll_models (survey_id);
@@ -0,0 +1,6 @@
+-- Text responses cannot be represented without an option and are dropped.
+DELETE FROM vote_models WHERE option_id IS NULL;
+ALTER TABLE vote_models DROP COLUMN text;
+ALTER TABLE vote_models ALTER COLUMN option_id SET NOT NULL;
+ALTER TABLE poll_models DROP COLUMN language;
+ALTER TABLE poll_models DROP COLUMN kind;
@@ -0,0 +1,6 @@
+-- Polls get a kind: choice (the default) or text. Votes in text polls carry text instead of an
+-- option. The composite option foreign key does not apply to rows whose option_id is NULL.
+ALTER TABLE poll_models ADD COLUMN kind text NOT NULL DEFAULT 'choice';
+ALTER TABLE poll_models ADD COLUMN language text;
+ALTER TABLE vote_models ALTER COLUMN option_id DROP NOT NULL;
+ALTER TABLE vote_models ADD COLUMN text text;
@@ -0,0 +1,4 @@
+ALTER TABLE poll_models DROP COLUMN quiz_id;
+ALTER TABLE option_models DROP COLUMN correct;
+DROP TABLE IF EXISTS quiz_question_models;
+DROP TABLE IF EXISTS quiz_models;
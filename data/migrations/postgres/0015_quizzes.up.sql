@@ -0,0 +1,27 @@
+-- Quizzes group polls whose options are marked correct or not. Each question carries its scoring
+-- rules and the time it was opened; poll_models.quiz_id points back at the quiz.
+CREATE TABLE quiz_models (
+    id bigserial PRIMARY KEY,
+    title text NOT NULL,
+    description text,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+
+CREATE TABLE quiz_question_models (
+    id bigserial PRIMARY KEY,
+    quiz_id bigint NOT NULL,
+    position bigint NOT NULL DEFAULT 0,
+    poll_id bigint NOT NULL,
+    points bigint NOT NULL,
+    time_limit_seconds bigint NOT NULL,
+    opened_at timestamptz,
+    CONSTRAINT fk_quiz_question_models_quiz FOREIGN KEY (quiz_id) REFERENCES quiz_models (id) ON DELETE CASCADE,
+    CONSTRAINT fk_quiz_question_models_poll FOREIGN KEY (poll_id) REFERENCES poll_models (id)
+);
+CREATE INDEX idx_quiz_question_models_quiz_id ON quiz_question_models (quiz_id);
+CREATE INDEX idx_quiz_question_models_poll_id ON quiz_question_models (poll_id);
+
+ALTER TABLE option_models ADD COLUMN correct boolean NOT NULL DEFAULT false;
+ALTER TABLE poll_models ADD COLUMN quiz_id bigint;
+CREATE INDEX idx_poll_models_quiz_id ON poll_models (quiz_id);
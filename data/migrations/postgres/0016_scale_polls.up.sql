@@ -0,0 +1,2 @@
+-- NPS and Likert polls record how many points their generated scale has.
+ALTER TABLE poll_models ADD COLUMN scale integer NOT NULL DEFAULT 0;
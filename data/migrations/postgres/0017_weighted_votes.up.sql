@@ -0,0 +1,15 @@
+-- Weighted polls take votes only from their electorate; each vote keeps the weight its voter had
+-- when it was cast. Thresholds count either votes or weight.
+CREATE TABLE voter_models (
+    poll_id bigint NOT NULL,
+    user_id text NOT NULL,
+    weight bigint NOT NULL,
+    PRIMARY KEY (poll_id, user_id),
+    CONSTRAINT fk_voter_models_poll FOREIGN KEY (poll_id) REFERENCES poll_models (id) ON DELETE CASCADE
+);
+
+ALTER TABLE vote_models ADD COLUMN weight bigint NOT NULL DEFAULT 1;
+ALTER TABLE poll_models ADD COLUMN weighted boolean NOT NULL DEFAULT false;
+ALTER TABLE poll_models ADD COLUMN threshold_basis text NOT NULL DEFAULT 'votes';
+-- a voter's vote is looked up on every vote to a weighted poll
+CREATE INDEX idx_vote_models_poll_user ON vote_models (poll_id, user_id);
@@ -0,0 +1,2 @@
+-- Quadratic polls give every voter a credit budget; a vote's weight holds the votes it allocates.
+ALTER TABLE poll_models ADD COLUMN credit_budget integer NOT NULL DEFAULT 0;
@@ -0,0 +1,14 @@
+-- Poll templates describe polls to create on demand or on a cron schedule; spec and variables
+-- are JSON text.
+CREATE TABLE poll_template_models (
+    id bigserial PRIMARY KEY,
+    name text NOT NULL,
+    spec text NOT NULL,
+    variables text,
+    schedule text,
+    next_run_at timestamptz,
+    last_run_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE INDEX idx_poll_template_models_next_run_at ON poll_template_models (next_run_at);
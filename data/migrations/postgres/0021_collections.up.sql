@@ -0,0 +1,20 @@
+-- Collections group polls under shared settings: a schedule that opens and closes them together,
+-- an access mode for their votes and the scope of their webhooks.
+CREATE TABLE collection_models (
+    id bigserial PRIMARY KEY,
+    name text NOT NULL,
+    description text,
+    status text NOT NULL,
+    opens_at timestamptz,
+    closes_at timestamptz,
+    access text NOT NULL DEFAULT 'public',
+    webhook_scope text NOT NULL DEFAULT 'polls',
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE INDEX idx_collection_models_status ON collection_models (status);
+
+ALTER TABLE poll_models ADD COLUMN collection_id bigint;
+-- opening a collection reopens only the polls its closing closed, not those closed on their own
+ALTER TABLE poll_models ADD COLUMN closed_by_collection boolean NOT NULL DEFAULT false;
+CREATE INDEX idx_poll_models_collection_id ON poll_models (collection_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `vote_models`;
+DROP TABLE IF EXISTS `option_models`;
+DROP TABLE IF EXISTS `poll_models`;
@@ -0,0 +1,12 @@
+-- Baseline schema, as the AutoMigrate of earlier releases created it. IF NOT EXISTS lets those
+-- databases adopt it; everything added since comes in the migrations after this one.
+CREATE TABLE IF NOT EXISTS `poll_models` (`id` integer PRIMARY KEY AUTOINCREMENT,`title` text NOT NULL,`description` text,`status` text NOT NULL,`threshold` integer DEFAULT 0,`created_at` datetime,`updated_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_poll_models_status` ON `poll_models`(`status`);
+
+CREATE TABLE IF NOT EXISTS `option_models` (`id` integer PRIMARY KEY AUTOINCREMENT,`poll_id` integer NOT NULL,`text` text NOT NULL,`created_at` datetime,`updated_at` datetime,CONSTRAINT `fk_poll_models_options` FOREIGN KEY (`poll_id`) REFERENCES `poll_models`(`id`) ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS `idx_option_models_poll_id` ON `option_models`(`poll_id`);
+
+CREATE TABLE IF NOT EXISTS `vote_models` (`id` integer PRIMARY KEY AUTOINCREMENT,`poll_id` integer NOT NULL,`option_id` integer NOT NULL,`user_id` text,`created_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_vote_models_poll_id` ON `vote_models`(`poll_id`);
+CREATE INDEX IF NOT EXISTS `idx_vote_models_option_id` ON `vote_models`(`option_id`);
+CREATE INDEX IF NOT EXISTS `idx_vote_models_user_id` ON `vote_models`(`user_id`);
@@ -0,0 +1,11 @@
+-- Anonymous polls keep participation and ballots in separate tables that share no key. Neither has
+-- an autoincrement ID or a timestamp, and both are WITHOUT ROWID so they are stored in key order:
+-- the voter hash for participation, the random ID for ballots. Neither keeps the order in which
+-- votes arrived.
+ALTER TABLE `poll_models` ADD COLUMN `anonymous` numeric DEFAULT false;
+
+CREATE TABLE `participation_models` (`poll_id` integer NOT NULL,`voter_hash` text NOT NULL,PRIMARY KEY (`poll_id`,`voter_hash`)) WITHOUT ROWID;
+
+CREATE TABLE `ballot_models` (`id` text NOT NULL,`poll_id` integer NOT NULL,`option_id` integer NOT NULL,PRIMARY KEY (`id`)) WITHOUT ROWID;
+CREATE INDEX `idx_ballot_models_poll_id` ON `ballot_models`(`poll_id`);
+CREATE INDEX `idx_ballot_models_option_id` ON `ballot_models`(`option_id`);
@@ -0,0 +1,2 @@
+ALTER TABLE `poll_models` DROP COLUMN `vote_burst`;
+ALTER TABLE `poll_models` DROP COLUMN `vote_rate_per_minute`;
@@ -0,0 +1,3 @@
+-- Per-poll overrides of the vote rate limit; 0 uses the server default.
+ALTER TABLE `poll_models` ADD COLUMN `vote_rate_per_minute` integer DEFAULT 0;
+ALTER TABLE `poll_models` ADD COLUMN `vote_burst` integer DEFAULT 0;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS `idx_vote_models_status`;
+ALTER TABLE `vote_models` DROP COLUMN `user_agent`;
+ALTER TABLE `vote_models` DROP COLUMN `client_ip`;
+ALTER TABLE `vote_models` DROP COLUMN `flag_reason`;
+ALTER TABLE `vote_models` DROP COLUMN `status`;
@@ -0,0 +1,6 @@
+-- Screened votes are counted or flagged; the client metadata feeds the screener.
+ALTER TABLE `vote_models` ADD COLUMN `status` text NOT NULL DEFAULT 'counted';
+ALTER TABLE `vote_models` ADD COLUMN `flag_reason` text;
+ALTER TABLE `vote_models` ADD COLUMN `client_ip` text;
+ALTER TABLE `vote_models` ADD COLUMN `user_agent` text;
+CREATE INDEX `idx_vote_models_status` ON `vote_models`(`status`);
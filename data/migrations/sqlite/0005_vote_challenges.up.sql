@@ -0,0 +1,6 @@
+-- Polls may require a proof-of-work challenge of this many leading zero bits with every vote.
+ALTER TABLE `poll_models` ADD COLUMN `challenge_difficulty` integer DEFAULT 0;
+
+-- Solved challenge tokens are spent here until they expire, so a token buys one vote on any replica.
+CREATE TABLE `spent_challenge_models` (`challenge_key` text NOT NULL,`expires_at` datetime NOT NULL,PRIMARY KEY (`challenge_key`));
+CREATE INDEX `idx_spent_challenge_models_expires_at` ON `spent_challenge_models`(`expires_at`);
@@ -0,0 +1,3 @@
+-- Responses stored per Idempotency-Key, replayed to retries until they expire.
+CREATE TABLE `idempotency_models` (`idempotency_key` text,`request_hash` text NOT NULL,`completed` numeric NOT NULL DEFAULT false,`status` integer,`body` blob,`expires_at` datetime NOT NULL,`created_at` datetime,PRIMARY KEY (`idempotency_key`));
+CREATE INDEX `idx_idempotency_models_expires_at` ON `idempotency_models`(`expires_at`);
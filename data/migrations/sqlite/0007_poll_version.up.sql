@@ -0,0 +1 @@
+ALTER TABLE `poll_models` ADD COLUMN `version` integer NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS `idx_poll_models_deleted_at`;
+ALTER TABLE `poll_models` DROP COLUMN `deleted_at`;
@@ -0,0 +1,26 @@
+-- Votes, ballots and participation reference their poll, and votes/ballots reference an option of
+-- that same poll. SQLite cannot add constraints in place, so the tables are rebuilt. MigrateUp
+-- turns foreign key enforcement off while rebuilding, so rows orphaned before this migration are
+-- kept for the consistency checker (`GET /admin/consistency`) to report and repair.
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_option_models_id_poll` ON `option_models`(`id`,`poll_id`);
+
+CREATE TABLE `vote_models_new` (`id` integer PRIMARY KEY AUTOINCREMENT,`poll_id` integer NOT NULL,`option_id` integer NOT NULL,`user_id` text,`status` text NOT NULL DEFAULT 'counted',`flag_reason` text,`client_ip` text,`user_agent` text,`created_at` datetime,CONSTRAINT `fk_vote_models_poll` FOREIGN KEY (`poll_id`) REFERENCES `poll_models`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_vote_models_option` FOREIGN KEY (`option_id`,`poll_id`) REFERENCES `option_models`(`id`,`poll_id`) ON DELETE CASCADE);
+INSERT INTO `vote_models_new` SELECT `id`,`poll_id`,`option_id`,`user_id`,`status`,`flag_reason`,`client_ip`,`user_agent`,`created_at` FROM `vote_models`;
+DROP TABLE `vote_models`;
+ALTER TABLE `vote_models_new` RENAME TO `vote_models`;
+CREATE INDEX IF NOT EXISTS `idx_vote_models_poll_id` ON `vote_models`(`poll_id`);
+CREATE INDEX IF NOT EXISTS `idx_vote_models_option_id` ON `vote_models`(`option_id`);
+CREATE INDEX IF NOT EXISTS `idx_vote_models_user_id` ON `vote_models`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_vote_models_status` ON `vote_models`(`status`);
+
+CREATE TABLE `ballot_models_new` (`id` text NOT NULL,`poll_id` integer NOT NULL,`option_id` integer NOT NULL,PRIMARY KEY (`id`),CONSTRAINT `fk_ballot_models_poll` FOREIGN KEY (`poll_id`) REFERENCES `poll_models`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_ballot_models_option` FOREIGN KEY (`option_id`,`poll_id`) REFERENCES `option_models`(`id`,`poll_id`) ON DELETE CASCADE) WITHOUT ROWID;
+INSERT INTO `ballot_models_new` SELECT `id`,`poll_id`,`option_id` FROM `ballot_models`;
+DROP TABLE `ballot_models`;
+ALTER TABLE `ballot_models_new` RENAME TO `ballot_models`;
+CREATE INDEX IF NOT EXISTS `idx_ballot_models_poll_id` ON `ballot_models`(`poll_id`);
+CREATE INDEX IF NOT EXISTS `idx_ballot_models_option_id` ON `ballot_models`(`option_id`);
+
+CREATE TABLE `participation_models_new` (`poll_id` integer NOT NULL,`voter_hash` text NOT NULL,PRIMARY KEY (`poll_id`,`voter_hash`),CONSTRAINT `fk_participation_models_poll` FOREIGN KEY (`poll_id`) REFERENCES `poll_models`(`id`) ON DELETE CASCADE) WITHOUT ROWID;
+INSERT INTO `participation_models_new` SELECT `poll_id`,`voter_hash` FROM `participation_models`;
+DROP TABLE `participation_models`;
+ALTER TABLE `participation_models_new` RENAME TO `participation_models`;
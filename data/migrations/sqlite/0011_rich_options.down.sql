@@ -0,0 +1,4 @@
+ALTER TABLE `option_models` DROP COLUMN `metadata`;
+ALTER TABLE `option_models` DROP COLUMN `color`;
+ALTER TABLE `option_models` DROP COLUMN `image_url`;
+ALTER TABLE `option_models` DROP COLUMN `description`;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS `idx_poll_models_survey_id`;
+ALTER TABLE `poll_models` DROP COLUMN `survey_id`;
+DROP TABLE IF EXISTS `survey_answer_models`;
+DROP TABLE IF EXISTS `survey_submission_models`;
+DROP TABLE IF EXISTS `survey_question_models`;
+DROP TABLE IF EXISTS `survey_models`;
@@ -0,0 +1,12 @@
+-- Text responses cannot be represented without an option and are dropped.
+CREATE TABLE `vote_models_old` (`id` integer PRIMARY KEY AUTOINCREMENT,`poll_id` integer NOT NULL,`option_id` integer NOT NULL,`user_id` text,`status` text NOT NULL DEFAULT 'counted',`flag_reason` text,`client_ip` text,`user_agent` text,`created_at` datetime,CONSTRAINT `fk_vote_models_poll` FOREIGN KEY (`poll_id`) REFERENCES `poll_models`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_vote_models_option` FOREIGN KEY (`option_id`,`poll_id`) REFERENCES `option_models`(`id`,`poll_id`) ON DELETE CASCADE);
+INSERT INTO `vote_models_old` SELECT `id`,`poll_id`,`option_id`,`user_id`,`status`,`flag_reason`,`client_ip`,`user_agent`,`created_at` FROM `vote_models` WHERE `option_id` IS NOT NULL;
+DROP TABLE `vote_models`;
+ALTER TABLE `vote_models_old` RENAME TO `vote_models`;
+CREATE INDEX IF NOT EXISTS `idx_vote_models_poll_id` ON `vote_models`(`poll_id`);
+CREATE INDEX IF NOT EXISTS `idx_vote_models_option_id` ON `vote_models`(`option_id`);
+CREATE INDEX IF NOT EXISTS `idx_vote_models_user_id` ON `vote_models`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_vote_models_status` ON `vote_models`(`status`);
+
+ALTER TABLE `poll_models` DROP COLUMN `language`;
+ALTER TABLE `poll_models` DROP COLUMN `kind`;
@@ -0,0 +1,14 @@
+-- Polls get a kind: choice (the default) or text. Votes in text polls carry text instead of an
+-- option, so vote_models.option_id becomes nullable; SQLite needs a rebuild for that. The
+-- composite option foreign key does not apply to rows whose option_id is NULL.
+ALTER TABLE `poll_models` ADD COLUMN `kind` text NOT NULL DEFAULT 'choice';
+ALTER TABLE `poll_models` ADD COLUMN `language` text;
+
+CREATE TABLE `vote_models_new` (`id` integer PRIMARY KEY AUTOINCREMENT,`poll_id` integer NOT NULL,`option_id` integer,`text` text,`user_id` text,`status` text NOT NULL DEFAULT 'counted',`flag_reason` text,`client_ip` text,`user_agent` text,`created_at` datetime,CONSTRAINT `fk_vote_models_poll` FOREIGN KEY (`poll_id`) REFERENCES `poll_models`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_vote_models_option` FOREIGN KEY (`option_id`,`poll_id`) REFERENCES `option_models`(`id`,`poll_id`) ON DELETE CASCADE);
+INSERT INTO `vote_models_new` (`id`,`poll_id`,`option_id`,`user_id`,`status`,`flag_reason`,`client_ip`,`user_agent`,`created_at`) SELECT `id`,`poll_id`,`option_id`,`user_id`,`status`,`flag_reason`,`client_ip`,`user_agent`,`created_at` FROM `vote_models`;
+DROP TABLE `vote_models`;
+ALTER TABLE `vote_models_new` RENAME TO `vote_models`;
+CREATE INDEX IF NOT EXISTS `idx_vote_models_poll_id` ON `vote_models`(`poll_id`);
+CREATE INDEX IF NOT EXISTS `idx_vote_models_option_id` ON `vote_models`(`option_id`);
+CREATE INDEX IF NOT EXISTS `idx_vote_models_user_id` ON `vote_models`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_vote_models_status` ON `vote_models`(`status`);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS `idx_poll_models_quiz_id`;
+ALTER TABLE `poll_models` DROP COLUMN `quiz_id`;
+ALTER TABLE `option_models` DROP COLUMN `correct`;
+DROP TABLE IF EXISTS `quiz_question_models`;
+DROP TABLE IF EXISTS `quiz_models`;
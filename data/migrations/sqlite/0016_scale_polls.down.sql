@@ -0,0 +1,3 @@
+-- Scale polls keep their options and votes but read back as plain choice polls.
+UPDATE `poll_models` SET `kind` = 'choice' WHERE `kind` IN ('nps','likert');
+ALTER TABLE `poll_models` DROP COLUMN `scale`;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS `idx_vote_models_poll_user`;
+ALTER TABLE `poll_models` DROP COLUMN `threshold_basis`;
+ALTER TABLE `poll_models` DROP COLUMN `weighted`;
+ALTER TABLE `vote_models` DROP COLUMN `weight`;
+DROP TABLE IF EXISTS `voter_models`;
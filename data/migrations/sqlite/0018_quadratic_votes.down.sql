@@ -0,0 +1 @@
+ALTER TABLE `poll_models` DROP COLUMN `credit_budget`;
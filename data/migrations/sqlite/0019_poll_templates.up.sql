@@ -0,0 +1,4 @@
+-- Poll templates describe polls to create on demand or on a cron schedule; spec and variables
+-- are JSON text.
+CREATE TABLE `poll_template_models` (`id` integer PRIMARY KEY AUTOINCREMENT,`name` text NOT NULL,`spec` text NOT NULL,`variables` text,`schedule` text,`next_run_at` datetime,`last_run_at` datetime,`created_at` datetime,`updated_at` datetime);
+CREATE INDEX `idx_poll_template_models_next_run_at` ON `poll_template_models`(`next_run_at`);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS `idx_poll_models_series_id`;
+ALTER TABLE `poll_models` DROP COLUMN `series_id`;
+ALTER TABLE `poll_template_models` DROP COLUMN `series`;
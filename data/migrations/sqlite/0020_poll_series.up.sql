@@ -0,0 +1,4 @@
+-- Series templates link the polls they create; each new instance closes the previous one.
+ALTER TABLE `poll_template_models` ADD COLUMN `series` numeric NOT NULL DEFAULT false;
+ALTER TABLE `poll_models` ADD COLUMN `series_id` integer;
+CREATE INDEX `idx_poll_models_series_id` ON `poll_models`(`series_id`);
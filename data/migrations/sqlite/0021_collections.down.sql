@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS `idx_poll_models_collection_id`;
+ALTER TABLE `poll_models` DROP COLUMN `closed_by_collection`;
+ALTER TABLE `poll_models` DROP COLUMN `collection_id`;
+DROP TABLE IF EXISTS `collection_models`;
@@ -0,0 +1,9 @@
+-- Collections group polls under shared settings: a schedule that opens and closes them together,
+-- an access mode for their votes and the scope of their webhooks.
+CREATE TABLE `collection_models` (`id` integer PRIMARY KEY AUTOINCREMENT,`name` text NOT NULL,`description` text,`status` text NOT NULL,`opens_at` datetime,`closes_at` datetime,`access` text NOT NULL DEFAULT 'public',`webhook_scope` text NOT NULL DEFAULT 'polls',`created_at` datetime,`updated_at` datetime);
+CREATE INDEX `idx_collection_models_status` ON `collection_models`(`status`);
+
+ALTER TABLE `poll_models` ADD COLUMN `collection_id` integer;
+-- opening a collection reopens only the polls its closing closed, not those closed on their own
+ALTER TABLE `poll_models` ADD COLUMN `closed_by_collection` numeric NOT NULL DEFAULT false;
+CREATE INDEX `idx_poll_models_collection_id` ON `poll_models`(`collection_id`);
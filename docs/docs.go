@@ -15,15 +15,88 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/polls": {
+        "/admin/consistency": {
             "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Counts votes, ballots, participation records and options whose poll is gone, or whose option is missing or belongs to another poll.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "polls"
+                    "admin"
                 ],
-                "summary": "List polls",
+                "summary": "Report orphaned vote data",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/app.ConsistencyReport"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "503": {
+                        "description": "ADMIN_TOKEN is not set",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/consistency/repair": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete orphaned vote data",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/app.ConsistencyReport"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "503": {
+                        "description": "ADMIN_TOKEN is not set",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/collections": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "collections"
+                ],
+                "summary": "List poll collections",
                 "parameters": [
                     {
                         "type": "integer",
@@ -44,13 +117,14 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/domain.Poll"
+                                "$ref": "#/definitions/domain.Collection"
                             }
                         }
                     }
                 }
             },
             "post": {
+                "description": "Groups polls under one schedule, access mode and webhook scope. A collection with opens_at in the future starts scheduled and its polls take no votes until it opens; at closes_at its polls close. A poll belongs to at most one collection; survey and quiz polls cannot join one.",
                 "consumes": [
                     "application/json"
                 ],
@@ -58,25 +132,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "polls"
+                    "collections"
                 ],
-                "summary": "Create a poll",
+                "summary": "Create a poll collection",
                 "parameters": [
                     {
-                        "description": "Poll",
+                        "description": "Collection",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/adapters_http.CreatePollRequest"
+                            "$ref": "#/definitions/adapters_http.CreateCollectionRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the original response for retried requests",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/domain.Poll"
+                            "$ref": "#/definitions/domain.Collection"
                         }
                     },
                     "400": {
@@ -84,23 +164,29 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/gin.H"
                         }
+                    },
+                    "404": {
+                        "description": "A poll in poll_ids does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
                     }
                 }
             }
         },
-        "/polls/{id}": {
+        "/collections/{id}": {
             "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "polls"
+                    "collections"
                 ],
-                "summary": "Get a poll",
+                "summary": "Get a poll collection",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Poll ID",
+                        "description": "Collection ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -110,7 +196,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/domain.Poll"
+                            "$ref": "#/definitions/domain.Collection"
                         }
                     },
                     "404": {
@@ -122,14 +208,15 @@ const docTemplate = `{
                 }
             },
             "delete": {
+                "description": "Its polls are kept, each on its own again.",
                 "tags": [
-                    "polls"
+                    "collections"
                 ],
-                "summary": "Delete a poll",
+                "summary": "Delete a poll collection",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Poll ID",
+                        "description": "Collection ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -138,10 +225,17 @@ const docTemplate = `{
                 "responses": {
                     "204": {
                         "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
                     }
                 }
             },
             "patch": {
+                "description": "Moving opens_at or closes_at reschedules the next transition; null clears closes_at, or opens_at of a collection that is not scheduled. Use the open and close endpoints to move a collection at once.",
                 "consumes": [
                     "application/json"
                 ],
@@ -149,24 +243,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "polls"
+                    "collections"
                 ],
-                "summary": "Update a poll",
+                "summary": "Update a poll collection's settings",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Poll ID",
+                        "description": "Collection ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Poll",
+                        "description": "Settings to change",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/adapters_http.UpdatePollRequest"
+                            "$ref": "#/definitions/adapters_http.UpdateCollectionRequest"
                         }
                     }
                 ],
@@ -174,7 +268,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/domain.Poll"
+                            "$ref": "#/definitions/domain.Collection"
                         }
                     },
                     "400": {
@@ -182,20 +276,30 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/gin.H"
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
                     }
                 }
             }
         },
-        "/polls/{id}/close": {
+        "/collections/{id}/close": {
             "post": {
+                "description": "Closes every open poll in the collection. Safe to retry when some polls failed to close.",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "polls"
+                    "collections"
                 ],
-                "summary": "Close a poll",
+                "summary": "Close a collection now",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Poll ID",
+                        "description": "Collection ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -205,25 +309,38 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/domain.Poll"
+                            "$ref": "#/definitions/domain.Collection"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
                         }
                     }
                 }
             }
         },
-        "/polls/{id}/options": {
-            "get": {
+        "/collections/{id}/open": {
+            "post": {
+                "description": "Reopens the polls that closing the collection closed; polls closed on their own stay closed. Safe to retry when some polls failed to open.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "options"
+                    "collections"
                 ],
-                "summary": "List options for a poll",
+                "summary": "Open a collection now",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Poll ID",
+                        "description": "Collection ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -233,14 +350,25 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/domain.Option"
-                            }
+                            "$ref": "#/definitions/domain.Collection"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/collections/{id}/polls": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -249,50 +377,111 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "options"
+                    "collections"
                 ],
-                "summary": "Add an option to poll",
+                "summary": "Add a poll to a collection",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Poll ID",
+                        "description": "Collection ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Option",
+                        "description": "Poll",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/adapters_http.CreateOption"
+                            "$ref": "#/definitions/adapters_http.CollectionPollRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/domain.Option"
+                            "$ref": "#/definitions/domain.Collection"
+                        }
+                    },
+                    "400": {
+                        "description": "The poll is in another collection, a survey or a quiz",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
                         }
                     }
                 }
             }
         },
-        "/polls/{id}/results": {
-            "get": {
+        "/collections/{id}/polls/{pollId}": {
+            "delete": {
+                "description": "The poll keeps its status and votes.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "results"
+                    "collections"
                 ],
-                "summary": "Current poll results",
+                "summary": "Remove a poll from a collection",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Collection ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "integer",
                         "description": "Poll ID",
+                        "name": "pollId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Collection"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/collections/{id}/stats": {
+            "get": {
+                "description": "Voters counts distinct user IDs; ballots in anonymous polls and votes without user_id add to votes only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "collections"
+                ],
+                "summary": "Participation across a collection's polls",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Collection ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -302,25 +491,32 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/domain.Results"
+                            "$ref": "#/definitions/domain.CollectionStats"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
                         }
                     }
                 }
             }
         },
-        "/polls/{id}/results/stream": {
+        "/collections/{id}/stream": {
             "get": {
+                "description": "Sends the collection's status on connect, then an update whenever a member poll's results or the collection's status change.",
                 "produces": [
                     "text/event-stream"
                 ],
                 "tags": [
-                    "results"
+                    "collections"
                 ],
-                "summary": "Stream poll results via SSE",
+                "summary": "Stream a collection via SSE",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Poll ID",
+                        "description": "Collection ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -329,136 +525,3894 @@ const docTemplate = `{
                 "responses": {}
             }
         },
-        "/polls/{id}/votes": {
+        "/media": {
             "post": {
+                "description": "Accepts PNG, JPEG, GIF, WebP and PDF, sniffed from the content. Files are stored under their SHA-256, so re-uploading the same bytes returns the existing media. Images get a thumbnail.",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "votes"
+                    "media"
                 ],
-                "summary": "Cast a vote",
+                "summary": "Upload media",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Poll ID",
-                        "name": "id",
-                        "in": "path",
+                        "type": "file",
+                        "description": "File",
+                        "name": "file",
+                        "in": "formData",
                         "required": true
-                    },
-                    {
-                        "description": "Vote",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/adapters_http.VoteRequest"
-                        }
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "Already stored",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Media"
+                        }
+                    },
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/domain.Vote"
+                            "$ref": "#/definitions/domain.Media"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "adapters_http.CreateOption": {
-            "type": "object",
-            "required": [
-                "text"
-            ],
-            "properties": {
+        },
+        "/media/{key}": {
+            "get": {
+                "tags": [
+                    "media"
+                ],
+                "summary": "Download uploaded media",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Media key",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "polls"
+                ],
+                "summary": "List polls",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "List the trash instead of live polls",
+                        "name": "deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.Poll"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "polls"
+                ],
+                "summary": "Create a poll",
+                "parameters": [
+                    {
+                        "description": "Poll",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/adapters_http.CreatePollRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the original response for retried requests",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Poll"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency key reused with a different payload",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "polls"
+                ],
+                "summary": "Get a poll",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Poll"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Poll version"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "polls"
+                ],
+                "summary": "Move a poll to the trash",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the poll being deleted",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Fields present in the body are applied as given, zero values included: vote_rate_per_minute 0 removes the poll's override and restores the server default, and challenge_difficulty 0 stops requiring a challenge.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "polls"
+                ],
+                "summary": "Update a poll",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Poll",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/adapters_http.UpdatePollRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the poll being edited",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Poll"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Poll version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls/{id}/challenge": {
+            "get": {
+                "description": "Solve by finding a string s such that sha256(token + s) has Difficulty leading zero bits, then send token and s as challenge/solution with the vote.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "votes"
+                ],
+                "summary": "Issue a proof-of-work challenge",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Challenge"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls/{id}/clone": {
+            "post": {
+                "description": "Creates an open poll with the source poll's title, description, settings and options, without its votes. A weighted poll's clone gets the same electorate. Survey and quiz polls cannot be cloned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "polls"
+                ],
+                "summary": "Clone a poll",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the original response for retried requests",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Poll"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Poll version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls/{id}/close": {
+            "post": {
+                "tags": [
+                    "polls"
+                ],
+                "summary": "Close a poll",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the poll being closed",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Poll"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls/{id}/credits": {
+            "get": {
+                "description": "Budget, credits spent and remaining, and the votes allocated per option ID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "votes"
+                ],
+                "summary": "A voter's credits in a quadratic poll",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Voter",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.CreditAccount"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls/{id}/electorate": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "electorate"
+                ],
+                "summary": "List a weighted poll's electorate",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.Voter"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Only listed voters may vote, once each, and their votes count with their weight. Votes already cast keep the weight they were cast with; an empty list removes everyone.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "electorate"
+                ],
+                "summary": "Replace a weighted poll's electorate",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Voters and weights",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/adapters_http.ElectorateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.Voter"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls/{id}/export": {
+            "get": {
+                "description": "One row per counted vote, like GET /polls/{id}/votes; refused with 403 for anonymous polls.",
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "votes"
+                ],
+                "summary": "Export votes as CSV",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls/{id}/flagged-votes": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Each vote carries the reason it was flagged; client IPs and user agents stay in the database.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "List votes quarantined by fraud screening",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/adapters_http.VoteResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls/{id}/flagged-votes/{voteId}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Permanently remove a flagged vote",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Vote ID",
+                        "name": "voteId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls/{id}/flagged-votes/{voteId}/accept": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Accept a flagged vote into the results",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Vote ID",
+                        "name": "voteId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls/{id}/options": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "options"
+                ],
+                "summary": "List options for a poll",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.Option"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "options"
+                ],
+                "summary": "Add an option to poll",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Option",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/adapters_http.CreateOption"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the poll being extended",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Option"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls/{id}/options/order": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "options"
+                ],
+                "summary": "Reorder a poll's options",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Every option ID of the poll, in the new order",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/adapters_http.ReorderOptionsRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the poll being edited",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.Option"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls/{id}/options/{optionId}": {
+            "delete": {
+                "description": "policy decides what happens to the option's votes: reject (default) refuses while it has any, reassign moves them to reassign_to, discard deletes them.",
+                "tags": [
+                    "options"
+                ],
+                "summary": "Delete an option",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Option ID",
+                        "name": "optionId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "reject",
+                            "reassign",
+                            "discard"
+                        ],
+                        "type": "string",
+                        "description": "reject, reassign or discard",
+                        "name": "policy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Option receiving the votes when policy=reassign",
+                        "name": "reassign_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the poll being edited",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "409": {
+                        "description": "Option has votes and policy is reject",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Fields present in the body are applied as given: an empty description, image_url or color, or null metadata, clears it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "options"
+                ],
+                "summary": "Edit an option",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Option ID",
+                        "name": "optionId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Option",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/adapters_http.UpdateOptionRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the poll being edited",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Option"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls/{id}/options/{optionId}/image": {
+            "put": {
+                "description": "Stores the image like POST /media (PNG, JPEG, GIF or WebP, sniffed from the content) and sets the option's image_url to it.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "options"
+                ],
+                "summary": "Upload an option image",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Option ID",
+                        "name": "optionId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Image",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the poll being edited",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Option"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls/{id}/reactions": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reactions"
+                ],
+                "summary": "Current reaction rates of a poll",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ReactionBurst"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Reactions are counted in memory over a rolling window, not stored, and reach viewers as bursts on the results stream.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reactions"
+                ],
+                "summary": "React to a poll with an emoji",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reaction",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/adapters_http.ReactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "422": {
+                        "description": "Emoji not in the configured set",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls/{id}/recurrence": {
+            "put": {
+                "description": "Turns the poll into the first instance of a series: a series template with its settings and options creates the next instance whenever the schedule fires and closes the previous one. Delete the returned template to end the series.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "series"
+                ],
+                "summary": "Make a poll recur",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Schedule",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/adapters_http.RecurrenceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/domain.PollTemplate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls/{id}/responses/{voteId}/hide": {
+            "post": {
+                "description": "The response stays listed under /polls/{id}/votes with status hidden; its terms leave the word cloud.",
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Hide a text poll response from the results",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Vote ID",
+                        "name": "voteId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls/{id}/responses/{voteId}/show": {
+            "post": {
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Show a hidden text poll response again",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Vote ID",
+                        "name": "voteId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls/{id}/restore": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "polls"
+                ],
+                "summary": "Restore a poll from the trash",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Poll"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Poll version"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/polls/{id}/results": {
+            "get": {
+                "description": "Text polls add the terms of their word cloud; nps and likert polls add their NPS or mean and top-box scores.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "results"
+                ],
+                "summary": "Current poll results",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of previously fetched results",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Results"
+                        }
+                    },
+                    "304": {
+                        "description": "Results unchanged"
+                    }
+                }
+            }
+        },
+        "/polls/{id}/results/stream": {
+            "get": {
+                "description": "Results arrive as \"message\" events. While people react, \"reactions\" events carry a domain.ReactionBurst every second, and one with empty counts once they stop.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "results"
+                ],
+                "summary": "Stream poll results via SSE",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/polls/{id}/votes": {
+            "get": {
+                "description": "Counted votes only: flagged votes and hidden text responses are left out. Refused with 403 for anonymous polls.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "votes"
+                ],
+                "summary": "Per-voter breakdown of a poll",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/adapters_http.VoteResponse"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "votes"
+                ],
+                "summary": "Cast a vote",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Poll ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Vote",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/adapters_http.VoteRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the original response for retried requests",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Quadratic allocation withdrawn",
+                        "schema": {
+                            "$ref": "#/definitions/adapters_http.VoteResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/adapters_http.VoteResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Vote flagged for review",
+                        "schema": {
+                            "$ref": "#/definitions/adapters_http.VoteResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing or invalid proof-of-work solution, or the voter is not in a weighted poll's electorate",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "409": {
+                        "description": "Already voted, the quiz question is not open for answers, not enough credits left, or the poll's collection is not open",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "422": {
+                        "description": "Option not in this poll, text sent to a choice poll (or missing for a text poll), or idempotency key reused with a different payload",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/quizzes": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quizzes"
+                ],
+                "summary": "List quizzes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.Quiz"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Each question becomes a poll; participants answer by voting in it with a user_id. Correct options are hidden until the question is revealed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quizzes"
+                ],
+                "summary": "Create a quiz",
+                "parameters": [
+                    {
+                        "description": "Quiz",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/adapters_http.CreateQuizRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the original response for retried requests",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Quiz"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/quizzes/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quizzes"
+                ],
+                "summary": "Get a quiz with its questions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Quiz"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/quizzes/{id}/leaderboard": {
+            "get": {
+                "description": "Scores count revealed questions only; ties on score are broken by the time taken for correct answers.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quizzes"
+                ],
+                "summary": "Quiz leaderboard",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Leaderboard"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/quizzes/{id}/leaderboard/stream": {
+            "get": {
+                "description": "Sends the leaderboard on connect and again after every answer and reveal.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "quizzes"
+                ],
+                "summary": "Stream a quiz leaderboard via SSE",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/quizzes/{id}/questions/{questionId}/open": {
+            "post": {
+                "description": "Starts the clock: answers are accepted until the question's time limit and score more the sooner they arrive.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quizzes"
+                ],
+                "summary": "Open a question for answers",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Question ID",
+                        "name": "questionId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Quiz"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/quizzes/{id}/questions/{questionId}/reveal": {
+            "post": {
+                "description": "Closes the question's poll, so no more answers are taken, and scores it on the leaderboard.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quizzes"
+                ],
+                "summary": "Reveal a question's correct options",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Question ID",
+                        "name": "questionId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Quiz"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/reactions": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reactions"
+                ],
+                "summary": "List the accepted reaction emojis",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/adapters_http.ReactionEmojisResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/series/{id}/results": {
+            "get": {
+                "description": "The series ID is the ID of its template. Reports each instance's results, oldest first, and per-option trends (votes and shares per instance, matched by option text). NPS and Likert instances carry their score or mean.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "series"
+                ],
+                "summary": "Compare the instances of a series",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Series ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Latest instances to compare (default 20, 0 for all)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SeriesResults"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/surveys": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "surveys"
+                ],
+                "summary": "List surveys",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.Survey"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Each choice question is backed by a poll holding its options; text questions take free-text answers.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "surveys"
+                ],
+                "summary": "Create a survey",
+                "parameters": [
+                    {
+                        "description": "Survey",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/adapters_http.CreateSurveyRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the original response for retried requests",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Survey"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/surveys/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "surveys"
+                ],
+                "summary": "Get a survey with its questions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Survey ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Survey"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/surveys/{id}/close": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "surveys"
+                ],
+                "summary": "Close a survey and the polls behind its questions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Survey ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Survey"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/surveys/{id}/results": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "surveys"
+                ],
+                "summary": "Survey results and completion rates",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Survey ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SurveyResults"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/surveys/{id}/submissions": {
+            "post": {
+                "description": "The submission is stored as a whole or not at all. Choice answers count as votes in the question's poll.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "surveys"
+                ],
+                "summary": "Submit answers to a survey",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Survey ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Answers",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/adapters_http.SubmitSurveyRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the original response for retried requests",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SurveySubmission"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "409": {
+                        "description": "user_id already submitted this survey",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "422": {
+                        "description": "Required question unanswered, or an answer does not fit its question",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/templates": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "List poll templates",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.PollTemplate"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Every custom variable the poll uses needs a default in variables. With a schedule, a poll is created each time the cron expression fires (UTC unless prefixed with TZ=zone).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Create a poll template",
+                "parameters": [
+                    {
+                        "description": "Template",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/adapters_http.CreateTemplateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the original response for retried requests",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/domain.PollTemplate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/templates/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Get a poll template",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.PollTemplate"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Polls already created from the template are kept.",
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Delete a poll template",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/templates/{id}/instantiate": {
+            "post": {
+                "description": "Variables given here override the template's. A series template's poll becomes the series' latest instance and closes the open ones before it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Create a poll from a template",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Variables",
+                        "name": "payload",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/adapters_http.InstantiateTemplateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the original response for retried requests",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Poll"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Poll version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "adapters_http.CollectionPollRequest": {
+            "type": "object",
+            "required": [
+                "poll_id"
+            ],
+            "properties": {
+                "poll_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "adapters_http.CreateCollectionRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "access": {
+                    "type": "string",
+                    "enum": [
+                        "public",
+                        "identified"
+                    ]
+                },
+                "closes_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 200
+                },
+                "opens_at": {
+                    "type": "string"
+                },
+                "poll_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "webhook_scope": {
+                    "type": "string",
+                    "enum": [
+                        "polls",
+                        "collection",
+                        "none"
+                    ]
+                }
+            }
+        },
+        "adapters_http.CreateOption": {
+            "type": "object",
+            "required": [
+                "text"
+            ],
+            "properties": {
+                "color": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 2000
+                },
+                "image_url": {
+                    "description": "http(s) URL or /media/... path; checked by the service",
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object"
+                },
+                "text": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "minLength": 1
+                }
+            }
+        },
+        "adapters_http.CreatePollRequest": {
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "anonymous": {
+                    "type": "boolean"
+                },
+                "challenge_difficulty": {
+                    "type": "integer"
+                },
+                "credit_budget": {
+                    "description": "CreditBudget turns on quadratic voting: each voter spends up to this many credits, n votes for an option costing n².",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "description": {
+                    "type": "string"
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "Kind is choice (default), text, nps or likert. Text polls take free-text votes instead of\noptions; nps and likert polls generate theirs.",
+                    "type": "string",
+                    "enum": [
+                        "choice",
+                        "text",
+                        "nps",
+                        "likert"
+                    ]
+                },
+                "language": {
+                    "description": "text polls: stopword language for results terms",
+                    "type": "string"
+                },
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/adapters_http.CreateOption"
+                    }
+                },
+                "scale": {
+                    "description": "likert polls: 5 (default) or 7 points",
+                    "type": "integer"
+                },
+                "threshold": {
+                    "type": "integer"
+                },
+                "threshold_basis": {
+                    "description": "votes (default) or weight",
+                    "type": "string",
+                    "enum": [
+                        "votes",
+                        "weight"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "minLength": 1
+                },
+                "vote_burst": {
+                    "type": "integer"
+                },
+                "vote_rate_per_minute": {
+                    "type": "integer"
+                },
+                "weighted": {
+                    "description": "Weighted restricts voting to the poll's electorate (PUT /polls/{id}/electorate) and weighs each vote.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "adapters_http.CreateQuestionRequest": {
+            "type": "object",
+            "required": [
+                "kind",
+                "prompt"
+            ],
+            "properties": {
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "choice",
+                        "text"
+                    ]
+                },
+                "options": {
+                    "description": "choice questions only",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/adapters_http.CreateOption"
+                    }
+                },
+                "prompt": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "minLength": 1
+                },
+                "required": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "adapters_http.CreateQuizQuestionRequest": {
+            "type": "object",
+            "required": [
+                "options",
+                "prompt"
+            ],
+            "properties": {
+                "options": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/adapters_http.QuizOptionRequest"
+                    }
+                },
+                "points": {
+                    "description": "default 1000",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "prompt": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "minLength": 1
+                },
+                "time_limit_seconds": {
+                    "description": "default 30",
+                    "type": "integer",
+                    "maximum": 3600,
+                    "minimum": 0
+                }
+            }
+        },
+        "adapters_http.CreateQuizRequest": {
+            "type": "object",
+            "required": [
+                "questions",
+                "title"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "questions": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/adapters_http.CreateQuizQuestionRequest"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "minLength": 1
+                }
+            }
+        },
+        "adapters_http.CreateSurveyRequest": {
+            "type": "object",
+            "required": [
+                "questions",
+                "title"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "questions": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/adapters_http.CreateQuestionRequest"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "minLength": 1
+                }
+            }
+        },
+        "adapters_http.CreateTemplateRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 200
+                },
+                "poll": {
+                    "$ref": "#/definitions/adapters_http.CreatePollRequest"
+                },
+                "schedule": {
+                    "description": "optional cron expression or recurrence rule, e.g. \"0 9 * * 1\", \"FREQ=WEEKLY;BYDAY=MO;BYHOUR=9\", optionally prefixed with \"TZ=Europe/Warsaw \"",
+                    "type": "string"
+                },
+                "series": {
+                    "description": "Series links the polls created from the template into a series, each closing the one before.",
+                    "type": "boolean"
+                },
+                "variables": {
+                    "description": "defaults, overridable per instantiation",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "adapters_http.ElectorateRequest": {
+            "type": "object",
+            "properties": {
+                "voters": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/adapters_http.VoterRequest"
+                    }
+                }
+            }
+        },
+        "adapters_http.InstantiateTemplateRequest": {
+            "type": "object",
+            "properties": {
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "adapters_http.QuizOptionRequest": {
+            "type": "object",
+            "required": [
+                "text"
+            ],
+            "properties": {
+                "color": {
+                    "type": "string"
+                },
+                "correct": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 2000
+                },
+                "image_url": {
+                    "description": "http(s) URL or /media/... path; checked by the service",
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object"
+                },
+                "text": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "minLength": 1
+                }
+            }
+        },
+        "adapters_http.ReactionEmojisResponse": {
+            "type": "object",
+            "properties": {
+                "emojis": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "adapters_http.ReactionRequest": {
+            "type": "object",
+            "required": [
+                "emoji"
+            ],
+            "properties": {
+                "emoji": {
+                    "type": "string"
+                }
+            }
+        },
+        "adapters_http.RecurrenceRequest": {
+            "type": "object",
+            "required": [
+                "schedule"
+            ],
+            "properties": {
+                "schedule": {
+                    "description": "cron expression or recurrence rule, as for templates",
+                    "type": "string"
+                }
+            }
+        },
+        "adapters_http.ReorderOptionsRequest": {
+            "type": "object",
+            "required": [
+                "option_ids"
+            ],
+            "properties": {
+                "option_ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "adapters_http.SubmitSurveyRequest": {
+            "type": "object",
+            "properties": {
+                "answers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/adapters_http.SurveyAnswerRequest"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "adapters_http.SurveyAnswerRequest": {
+            "type": "object",
+            "required": [
+                "question_id"
+            ],
+            "properties": {
+                "option_id": {
+                    "type": "integer"
+                },
+                "question_id": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "adapters_http.UpdateCollectionRequest": {
+            "type": "object",
+            "properties": {
+                "access": {
+                    "type": "string",
+                    "enum": [
+                        "public",
+                        "identified"
+                    ]
+                },
+                "closes_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "minLength": 1
+                },
+                "opens_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "webhook_scope": {
+                    "type": "string",
+                    "enum": [
+                        "polls",
+                        "collection",
+                        "none"
+                    ]
+                }
+            }
+        },
+        "adapters_http.UpdateOptionRequest": {
+            "type": "object",
+            "properties": {
+                "color": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 2000
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object"
+                },
+                "text": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "minLength": 1
+                }
+            }
+        },
+        "adapters_http.UpdatePollRequest": {
+            "type": "object",
+            "properties": {
+                "challenge_difficulty": {
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "integer"
+                },
+                "threshold_basis": {
+                    "type": "string",
+                    "enum": [
+                        "votes",
+                        "weight"
+                    ]
+                },
+                "title": {
+                    "type": "string"
+                },
+                "vote_burst": {
+                    "type": "integer"
+                },
+                "vote_rate_per_minute": {
+                    "description": "0 restores the server default",
+                    "type": "integer"
+                }
+            }
+        },
+        "adapters_http.VoteRequest": {
+            "type": "object",
+            "properties": {
+                "challenge": {
+                    "description": "Challenge and Solution carry a solved proof-of-work challenge for polls that require one.",
+                    "type": "string"
+                },
+                "option_id": {
+                    "type": "integer"
+                },
+                "solution": {
+                    "type": "string"
+                },
+                "text": {
+                    "description": "text polls only",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "votes": {
+                    "description": "Votes is what a quadratic poll's voter allocates to the option, replacing their earlier\nallocation; 0 withdraws it. Defaults to 1.",
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "adapters_http.VoteResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "flagReason": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "optionID": {
+                    "type": "integer"
+                },
+                "pollID": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/domain.VoteStatus"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "userID": {
+                    "type": "string"
+                },
+                "weight": {
+                    "type": "integer"
+                }
+            }
+        },
+        "adapters_http.VoterRequest": {
+            "type": "object",
+            "required": [
+                "user_id",
+                "weight"
+            ],
+            "properties": {
+                "user_id": {
+                    "type": "string"
+                },
+                "weight": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "app.ConsistencyReport": {
+            "type": "object",
+            "properties": {
+                "orphanBallots": {
+                    "type": "integer"
+                },
+                "orphanOptions": {
+                    "type": "integer"
+                },
+                "orphanParticipation": {
+                    "type": "integer"
+                },
+                "orphanVotes": {
+                    "type": "integer"
+                },
+                "repaired": {
+                    "description": "the counted rows have been deleted",
+                    "type": "boolean"
+                }
+            }
+        },
+        "domain.AccessMode": {
+            "type": "string",
+            "enum": [
+                "public",
+                "identified"
+            ],
+            "x-enum-comments": {
+                "AccessIdentified": "only votes carrying a user_id",
+                "AccessPublic": "whoever each poll admits"
+            },
+            "x-enum-varnames": [
+                "AccessPublic",
+                "AccessIdentified"
+            ]
+        },
+        "domain.Challenge": {
+            "type": "object",
+            "properties": {
+                "difficulty": {
+                    "description": "leading zero bits of sha256(Token + solution)",
+                    "type": "integer"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "pollID": {
+                    "type": "integer"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.Collection": {
+            "type": "object",
+            "properties": {
+                "access": {
+                    "$ref": "#/definitions/domain.AccessMode"
+                },
+                "closesAt": {
+                    "description": "when an open collection closes its polls",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "opensAt": {
+                    "description": "when a scheduled collection opens its polls",
+                    "type": "string"
+                },
+                "pollIDs": {
+                    "description": "live member polls, ascending",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "status": {
+                    "$ref": "#/definitions/domain.CollectionStatus"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "webhookScope": {
+                    "$ref": "#/definitions/domain.WebhookScope"
+                }
+            }
+        },
+        "domain.CollectionStats": {
+            "type": "object",
+            "properties": {
+                "collectionID": {
+                    "type": "integer"
+                },
+                "openPolls": {
+                    "type": "integer"
+                },
+                "perPoll": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.PollParticipation"
+                    }
+                },
+                "polls": {
+                    "type": "integer"
+                },
+                "voters": {
+                    "description": "distinct user IDs across all polls; anonymous ballots and votes without user_id are not attributed",
+                    "type": "integer"
+                },
+                "votes": {
+                    "description": "counted votes, including anonymous ballots",
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.CollectionStatus": {
+            "type": "string",
+            "enum": [
+                "scheduled",
+                "open",
+                "closed"
+            ],
+            "x-enum-comments": {
+                "CollectionScheduled": "before OpensAt; its polls take no votes"
+            },
+            "x-enum-varnames": [
+                "CollectionScheduled",
+                "CollectionOpen",
+                "CollectionClosed"
+            ]
+        },
+        "domain.CreditAccount": {
+            "type": "object",
+            "properties": {
+                "budget": {
+                    "type": "integer"
+                },
+                "pollID": {
+                    "type": "integer"
+                },
+                "remaining": {
+                    "type": "integer"
+                },
+                "spent": {
+                    "type": "integer"
+                },
+                "userID": {
+                    "type": "string"
+                },
+                "votes": {
+                    "description": "votes allocated per option ID",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "domain.Leaderboard": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.LeaderboardEntry"
+                    }
+                },
+                "questions": {
+                    "type": "integer"
+                },
+                "quizID": {
+                    "type": "integer"
+                },
+                "revealed": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.LeaderboardEntry": {
+            "type": "object",
+            "properties": {
+                "answered": {
+                    "type": "integer"
+                },
+                "correct": {
+                    "type": "integer"
+                },
+                "rank": {
+                    "description": "participants with equal score and response time share a rank",
+                    "type": "integer"
+                },
+                "responseTime": {
+                    "description": "total time taken for correct answers; breaks score ties",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/time.Duration"
+                        }
+                    ]
+                },
+                "score": {
+                    "type": "integer"
+                },
+                "userID": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.LikertScore": {
+            "type": "object",
+            "properties": {
+                "mean": {
+                    "description": "0 without votes",
+                    "type": "number"
+                },
+                "scale": {
+                    "type": "integer"
+                },
+                "topBox": {
+                    "description": "percentage of votes for the highest point",
+                    "type": "number"
+                },
+                "topTwoBox": {
+                    "description": "percentage of votes for the two highest points",
+                    "type": "number"
+                }
+            }
+        },
+        "domain.Media": {
+            "type": "object",
+            "properties": {
+                "contentType": {
+                    "type": "string"
+                },
+                "deduplicated": {
+                    "description": "the content was already stored",
+                    "type": "boolean"
+                },
+                "height": {
+                    "description": "images only",
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "thumbnailURL": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "width": {
+                    "description": "images only",
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.NPSScore": {
+            "type": "object",
+            "properties": {
+                "detractors": {
+                    "type": "integer"
+                },
+                "passives": {
+                    "type": "integer"
+                },
+                "promoters": {
+                    "type": "integer"
+                },
+                "score": {
+                    "description": "percentage of promoters minus percentage of detractors, -100 to 100; 0 without votes",
+                    "type": "number"
+                }
+            }
+        },
+        "domain.Option": {
+            "type": "object",
+            "properties": {
+                "color": {
+                    "description": "#rgb or #rrggbb",
+                    "type": "string"
+                },
+                "correct": {
+                    "description": "quiz polls only; reported false until the poll is closed",
+                    "type": "boolean"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "imageURL": {
+                    "description": "external http(s) URL, or MediaPath of an uploaded image",
+                    "type": "string"
+                },
+                "metadata": {
+                    "description": "arbitrary client JSON, stored verbatim",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "pollID": {
+                    "type": "integer"
+                },
+                "position": {
+                    "description": "display order within the poll, ascending",
+                    "type": "integer"
+                },
                 "text": {
-                    "type": "string",
-                    "maxLength": 200,
-                    "minLength": 1
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.OptionResult": {
+            "type": "object",
+            "properties": {
+                "color": {
+                    "type": "string"
+                },
+                "correct": {
+                    "description": "set on a quiz poll's correct options once it is closed",
+                    "type": "boolean"
+                },
+                "credits": {
+                    "description": "quadratic polls: credits spent on the option",
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "imageURL": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "optionID": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "votes": {
+                    "type": "integer"
+                },
+                "weight": {
+                    "description": "summed voter weights of the votes; in quadratic polls, the votes allocated to the option",
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.OptionTrend": {
+            "type": "object",
+            "properties": {
+                "change": {
+                    "description": "share in the latest instance minus share in the first",
+                    "type": "number"
+                },
+                "shares": {
+                    "description": "percentage of the instance's total votes",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "text": {
+                    "type": "string"
+                },
+                "votes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "domain.Poll": {
+            "type": "object",
+            "properties": {
+                "anonymous": {
+                    "description": "ballots are stored unlinked from voters",
+                    "type": "boolean"
+                },
+                "challengeDifficulty": {
+                    "description": "proof-of-work bits required per vote; 0 disables the challenge",
+                    "type": "integer"
+                },
+                "closedByCollection": {
+                    "description": "closed by its collection closing, so opening the collection reopens it",
+                    "type": "boolean"
+                },
+                "collectionID": {
+                    "description": "set on polls grouped into a collection, which then governs their access and webhooks",
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "creditBudget": {
+                    "description": "quadratic voting: credits per voter, n votes for an option cost n²; 0 disables it",
+                    "type": "integer"
+                },
+                "deletedAt": {
+                    "description": "set while the poll is in the trash",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "imageURL": {
+                    "description": "external http(s) URL, or MediaPath of an uploaded image",
+                    "type": "string"
+                },
+                "kind": {
+                    "$ref": "#/definitions/domain.PollKind"
+                },
+                "language": {
+                    "description": "stopword language of a text poll; empty means the server default",
+                    "type": "string"
+                },
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Option"
+                    }
+                },
+                "quizID": {
+                    "description": "set on quiz question polls; closing one reveals its correct options",
+                    "type": "integer"
+                },
+                "scale": {
+                    "description": "points of an nps or likert poll, whose options run from lowest to highest",
+                    "type": "integer"
+                },
+                "seriesID": {
+                    "description": "set on instances of a recurring poll: the ID of the series template that created them",
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/domain.PollStatus"
+                },
+                "surveyID": {
+                    "description": "set on polls backing a survey question; they take votes only through the survey",
+                    "type": "integer"
+                },
+                "threshold": {
+                    "description": "optional threshold to trigger webhook",
+                    "type": "integer"
+                },
+                "thresholdBasis": {
+                    "description": "what Threshold counts; votes unless set",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.ThresholdBasis"
+                        }
+                    ]
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "incremented on every change; used for optimistic concurrency",
+                    "type": "integer"
+                },
+                "voteBurst": {
+                    "type": "integer"
+                },
+                "voteRatePerMinute": {
+                    "description": "optional per-poll vote rate limit override",
+                    "type": "integer"
+                },
+                "weighted": {
+                    "description": "only the poll's electorate may vote, each with their weight",
+                    "type": "boolean"
+                }
+            }
+        },
+        "domain.PollKind": {
+            "type": "string",
+            "enum": [
+                "choice",
+                "text",
+                "nps",
+                "likert"
+            ],
+            "x-enum-comments": {
+                "PollChoice": "votes pick an option",
+                "PollLikert": "a 5 or 7 point agreement scale is generated; results compute mean and top-box",
+                "PollNPS": "options 0 to 10 are generated; results compute the Net Promoter Score",
+                "PollText": "votes carry free text; results count terms for a word cloud"
+            },
+            "x-enum-varnames": [
+                "PollChoice",
+                "PollText",
+                "PollNPS",
+                "PollLikert"
+            ]
+        },
+        "domain.PollParticipation": {
+            "type": "object",
+            "properties": {
+                "pollID": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/domain.PollStatus"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "voters": {
+                    "type": "integer"
+                },
+                "votes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.PollStatus": {
+            "type": "string",
+            "enum": [
+                "open",
+                "closed"
+            ],
+            "x-enum-varnames": [
+                "PollOpen",
+                "PollClosed"
+            ]
+        },
+        "domain.PollTemplate": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastRunAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nextRunAt": {
+                    "description": "when the schedule fires next; nil without a schedule",
+                    "type": "string"
+                },
+                "poll": {
+                    "description": "settings and options of the polls to create; IDs, status and timestamps are ignored",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.Poll"
+                        }
+                    ]
+                },
+                "schedule": {
+                    "description": "optional cron or RRULE-like expression; a poll is created every time it fires",
+                    "type": "string"
+                },
+                "series": {
+                    "description": "polls created from the template form a series; each new one closes the previous",
+                    "type": "boolean"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "variables": {
+                    "description": "defaults for custom variables",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "domain.Question": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "$ref": "#/definitions/domain.QuestionKind"
+                },
+                "options": {
+                    "description": "options of the backing poll",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Option"
+                    }
+                },
+                "pollID": {
+                    "description": "backing poll of a choice question; 0 for text questions",
+                    "type": "integer"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "prompt": {
+                    "type": "string"
+                },
+                "required": {
+                    "description": "submissions must answer it",
+                    "type": "boolean"
+                },
+                "surveyID": {
+                    "type": "integer"
                 }
             }
         },
-        "adapters_http.CreatePollRequest": {
-            "type": "object",
-            "required": [
-                "options",
-                "title"
+        "domain.QuestionKind": {
+            "type": "string",
+            "enum": [
+                "choice",
+                "text"
             ],
+            "x-enum-comments": {
+                "QuestionChoice": "pick one option; backed by a poll",
+                "QuestionText": "free-text answer"
+            },
+            "x-enum-varnames": [
+                "QuestionChoice",
+                "QuestionText"
+            ]
+        },
+        "domain.QuestionResult": {
+            "type": "object",
             "properties": {
-                "description": {
-                    "type": "string"
+                "answered": {
+                    "type": "integer"
+                },
+                "completionRate": {
+                    "description": "Answered / Submissions",
+                    "type": "number"
+                },
+                "kind": {
+                    "$ref": "#/definitions/domain.QuestionKind"
                 },
                 "options": {
+                    "description": "choice questions",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/adapters_http.CreateOption"
+                        "$ref": "#/definitions/domain.OptionResult"
                     }
                 },
-                "threshold": {
+                "prompt": {
+                    "type": "string"
+                },
+                "questionID": {
                     "type": "integer"
                 },
-                "title": {
-                    "type": "string",
-                    "maxLength": 200,
-                    "minLength": 1
+                "required": {
+                    "type": "boolean"
+                },
+                "responses": {
+                    "description": "text questions, newest first",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "adapters_http.UpdatePollRequest": {
+        "domain.Quiz": {
             "type": "object",
             "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
                 "description": {
                     "type": "string"
                 },
-                "threshold": {
+                "id": {
                     "type": "integer"
                 },
+                "questions": {
+                    "description": "ordered by Position",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.QuizQuestion"
+                    }
+                },
                 "title": {
                     "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
                 }
             }
         },
-        "adapters_http.VoteRequest": {
+        "domain.QuizQuestion": {
             "type": "object",
-            "required": [
-                "option_id"
-            ],
             "properties": {
-                "option_id": {
+                "id": {
                     "type": "integer"
                 },
-                "user_id": {
+                "openedAt": {
+                    "description": "nil until the question is opened",
+                    "type": "string"
+                },
+                "options": {
+                    "description": "options of the poll",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Option"
+                    }
+                },
+                "points": {
+                    "description": "for an instant correct answer; half of it at the time limit",
+                    "type": "integer"
+                },
+                "pollID": {
+                    "type": "integer"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "prompt": {
+                    "description": "title of the poll",
                     "type": "string"
+                },
+                "quizID": {
+                    "type": "integer"
+                },
+                "revealed": {
+                    "description": "the poll is closed",
+                    "type": "boolean"
+                },
+                "timeLimitSeconds": {
+                    "type": "integer"
                 }
             }
         },
-        "domain.Option": {
+        "domain.ReactionBurst": {
             "type": "object",
             "properties": {
-                "createdAt": {
+                "at": {
                     "type": "string"
                 },
-                "id": {
+                "counts": {
+                    "description": "reactions per emoji in the last full second",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "perSecond": {
+                    "description": "average rate per emoji over the rolling window",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                },
+                "pollID": {
+                    "type": "integer"
+                },
+                "windowSeconds": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.Results": {
+            "type": "object",
+            "properties": {
+                "credits": {
+                    "description": "quadratic polls: credits spent by all voters",
                     "type": "integer"
                 },
+                "likert": {
+                    "description": "likert polls only",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.LikertScore"
+                        }
+                    ]
+                },
+                "nps": {
+                    "description": "nps polls only",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.NPSScore"
+                        }
+                    ]
+                },
+                "optionVotes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "optionWeights": {
+                    "description": "summed voter weights; equal to OptionVotes outside weighted polls",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "options": {
+                    "description": "in display order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.OptionResult"
+                    }
+                },
                 "pollID": {
                     "type": "integer"
                 },
-                "text": {
+                "terms": {
+                    "description": "text polls: most frequent terms of visible responses, most frequent first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.TermCount"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "totalWeight": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.SeriesInstance": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
                     "type": "string"
                 },
-                "updatedAt": {
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.OptionResult"
+                    }
+                },
+                "pollID": {
+                    "type": "integer"
+                },
+                "score": {
+                    "description": "nps polls: the NPS; likert polls: the mean",
+                    "type": "number"
+                },
+                "status": {
+                    "$ref": "#/definitions/domain.PollStatus"
+                },
+                "title": {
                     "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "totalWeight": {
+                    "type": "integer"
                 }
             }
         },
-        "domain.Poll": {
+        "domain.SeriesResults": {
+            "type": "object",
+            "properties": {
+                "instances": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.SeriesInstance"
+                    }
+                },
+                "name": {
+                    "description": "the series template's name; empty once the template is deleted",
+                    "type": "string"
+                },
+                "seriesID": {
+                    "type": "integer"
+                },
+                "trends": {
+                    "description": "one per option text seen in any instance, in order of first appearance",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.OptionTrend"
+                    }
+                }
+            }
+        },
+        "domain.Survey": {
             "type": "object",
             "properties": {
                 "createdAt": {
@@ -470,19 +4424,16 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
-                "options": {
+                "questions": {
+                    "description": "ordered by Position",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/domain.Option"
+                        "$ref": "#/definitions/domain.Question"
                     }
                 },
                 "status": {
                     "$ref": "#/definitions/domain.PollStatus"
                 },
-                "threshold": {
-                    "description": "optional threshold to trigger webhook",
-                    "type": "integer"
-                },
                 "title": {
                     "type": "string"
                 },
@@ -491,58 +4442,178 @@ const docTemplate = `{
                 }
             }
         },
-        "domain.PollStatus": {
-            "type": "string",
-            "enum": [
-                "open",
-                "closed"
-            ],
-            "x-enum-varnames": [
-                "PollOpen",
-                "PollClosed"
-            ]
+        "domain.SurveyAnswer": {
+            "type": "object",
+            "properties": {
+                "optionID": {
+                    "type": "integer"
+                },
+                "questionID": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
         },
-        "domain.Results": {
+        "domain.SurveyResults": {
             "type": "object",
             "properties": {
-                "optionVotes": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "integer"
+                "complete": {
+                    "description": "submissions answering every question, optional ones included",
+                    "type": "integer"
+                },
+                "completionRate": {
+                    "description": "Complete / Submissions",
+                    "type": "number"
+                },
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.QuestionResult"
                     }
                 },
-                "pollID": {
+                "submissions": {
                     "type": "integer"
                 },
-                "total": {
+                "surveyID": {
                     "type": "integer"
                 }
             }
         },
-        "domain.Vote": {
+        "domain.SurveySubmission": {
             "type": "object",
             "properties": {
+                "answers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.SurveyAnswer"
+                    }
+                },
                 "createdAt": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "optionID": {
+                "surveyID": {
+                    "type": "integer"
+                },
+                "userID": {
+                    "description": "optional; at most one submission per user and survey",
+                    "type": "string"
+                }
+            }
+        },
+        "domain.TermCount": {
+            "type": "object",
+            "properties": {
+                "count": {
                     "type": "integer"
                 },
+                "term": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.ThresholdBasis": {
+            "type": "string",
+            "enum": [
+                "votes",
+                "weight"
+            ],
+            "x-enum-comments": {
+                "ThresholdVotes": "counted votes, one per voter",
+                "ThresholdWeight": "the summed weights of counted votes"
+            },
+            "x-enum-varnames": [
+                "ThresholdVotes",
+                "ThresholdWeight"
+            ]
+        },
+        "domain.VoteStatus": {
+            "type": "string",
+            "enum": [
+                "counted",
+                "flagged",
+                "hidden"
+            ],
+            "x-enum-comments": {
+                "VoteFlagged": "quarantined until a moderator accepts or purges it",
+                "VoteHidden": "free-text response hidden by a moderator"
+            },
+            "x-enum-varnames": [
+                "VoteCounted",
+                "VoteFlagged",
+                "VoteHidden"
+            ]
+        },
+        "domain.Voter": {
+            "type": "object",
+            "properties": {
                 "pollID": {
                     "type": "integer"
                 },
                 "userID": {
-                    "description": "optional identifier",
                     "type": "string"
+                },
+                "weight": {
+                    "description": "votes count this many times in weighted totals; at least 1",
+                    "type": "integer"
                 }
             }
         },
+        "domain.WebhookScope": {
+            "type": "string",
+            "enum": [
+                "polls",
+                "collection",
+                "none"
+            ],
+            "x-enum-comments": {
+                "WebhookCollection": "collection events and poll lifecycle events only, no per-vote events",
+                "WebhookPolls": "every poll event, tagged with the collection, plus collection events"
+            },
+            "x-enum-varnames": [
+                "WebhookPolls",
+                "WebhookCollection",
+                "WebhookNone"
+            ]
+        },
         "gin.H": {
             "type": "object",
             "additionalProperties": {}
+        },
+        "time.Duration": {
+            "type": "integer",
+            "enum": [
+                -9223372036854775808,
+                9223372036854775807,
+                1,
+                1000,
+                1000000,
+                1000000000,
+                60000000000,
+                3600000000000
+            ],
+            "x-enum-varnames": [
+                "minDuration",
+                "maxDuration",
+                "Nanosecond",
+                "Microsecond",
+                "Millisecond",
+                "Second",
+                "Minute",
+                "Hour"
+            ]
+        }
+    },
+    "securityDefinitions": {
+        "AdminToken": {
+            "description": "\"Bearer \" followed by ADMIN_TOKEN",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
@@ -0,0 +1,84 @@
+package domain
+
+import "time"
+
+// CollectionStatus is where a collection is in its schedule.
+type CollectionStatus string
+
+const (
+    CollectionScheduled CollectionStatus = "scheduled" // before OpensAt; its polls take no votes
+    CollectionOpen      CollectionStatus = "open"
+    CollectionClosed    CollectionStatus = "closed"
+)
+
+// AccessMode says who may vote in the polls of a collection.
+type AccessMode string
+
+const (
+    AccessPublic     AccessMode = "public"     // whoever each poll admits
+    AccessIdentified AccessMode = "identified" // only votes carrying a user_id
+)
+
+// WebhookScope says which webhooks the polls of a collection send.
+type WebhookScope string
+
+const (
+    WebhookPolls      WebhookScope = "polls"      // every poll event, tagged with the collection, plus collection events
+    WebhookCollection WebhookScope = "collection" // collection events and poll lifecycle events only, no per-vote events
+    WebhookNone       WebhookScope = "none"
+)
+
+// Collection groups polls, such as the talks of a conference, under shared settings.
+type Collection struct {
+    ID           uint
+    Name         string
+    Description  string
+    Status       CollectionStatus
+    OpensAt      *time.Time // when a scheduled collection opens its polls
+    ClosesAt     *time.Time // when an open collection closes its polls
+    Access       AccessMode
+    WebhookScope WebhookScope
+    PollIDs      []uint // live member polls, ascending
+    CreatedAt    time.Time
+    UpdatedAt    time.Time
+}
+
+// CollectionPatch changes the settings of a collection it sets and leaves the nil ones alone.
+// A zero OpensAt or ClosesAt clears that time.
+type CollectionPatch struct {
+    ID           uint
+    Name         *string
+    Description  *string
+    OpensAt      *time.Time
+    ClosesAt     *time.Time
+    Access       *AccessMode
+    WebhookScope *WebhookScope
+}
+
+// CollectionUpdate is streamed to a collection's subscribers when a member poll's results or the
+// collection's status change.
+type CollectionUpdate struct {
+    CollectionID uint
+    Status       CollectionStatus // the new status; empty for results updates
+    PollID       uint // the poll whose results changed; 0 for status changes
+    Results      *Results // nil for status changes
+}
+
+// CollectionStats aggregates participation across the polls of a collection.
+type CollectionStats struct {
+    CollectionID uint
+    Polls        int
+    OpenPolls    int
+    Votes        int // counted votes, including anonymous ballots
+    Voters       int // distinct user IDs across all polls; anonymous ballots and votes without user_id are not attributed
+    PerPoll      []PollParticipation
+}
+
+// PollParticipation is one poll's share of CollectionStats.
+type PollParticipation struct {
+    PollID uint
+    Title  string
+    Status PollStatus
+    Votes  int
+    Voters int
+}
@@ -0,0 +1,11 @@
+package domain
+
+// CreditAccount is a voter's spending in a quadratic poll.
+type CreditAccount struct {
+    PollID    uint
+    UserID    string
+    Budget    int
+    Spent     int
+    Remaining int
+    Votes     map[uint]int // votes allocated per option ID
+}
@@ -0,0 +1,8 @@
+package domain
+
+// Voter is a member of a weighted poll's electorate.
+type Voter struct {
+    PollID uint
+    UserID string
+    Weight int // votes count this many times in weighted totals; at least 1
+}
@@ -0,0 +1,55 @@
+package domain
+
+import "errors"
+
+var (
+    // ErrPollNotFound is returned when a poll does not exist.
+    ErrPollNotFound = errors.New("poll not found")
+    // ErrAlreadyVoted is returned when a voter tries to vote twice in a poll that enforces one vote per voter.
+    ErrAlreadyVoted = errors.New("already voted")
+    // ErrAnonymousPoll is returned when a per-voter view is requested for an anonymous poll.
+    ErrAnonymousPoll = errors.New("poll is anonymous")
+    // ErrOptionNotFound is returned when an option does not exist in the given poll.
+    ErrOptionNotFound = errors.New("option not found in poll")
+    // ErrInvalidVote is returned when a vote does not fit its poll's kind, e.g. text for a choice poll.
+    ErrInvalidVote = errors.New("vote does not fit the poll")
+    // ErrOptionHasVotes is returned when deleting an option with votes under the reject policy.
+    ErrOptionHasVotes = errors.New("option has votes")
+    // ErrUnsupportedMedia is returned for uploads whose content is not an accepted media type.
+    ErrUnsupportedMedia = errors.New("unsupported media type")
+    // ErrMediaTooLarge is returned for uploads over the configured size limit.
+    ErrMediaTooLarge = errors.New("media too large")
+    // ErrVoteNotFound is returned when a vote does not exist in the given poll.
+    ErrVoteNotFound = errors.New("vote not found")
+    // ErrChallengeRequired is returned when a poll requires proof of work and none was supplied.
+    ErrChallengeRequired = errors.New("proof-of-work challenge required")
+    // ErrChallengeInvalid is returned for forged, expired, reused or unsolved challenges.
+    ErrChallengeInvalid = errors.New("invalid proof-of-work challenge")
+    // ErrSurveyNotFound is returned when a survey does not exist.
+    ErrSurveyNotFound = errors.New("survey not found")
+    // ErrInvalidSubmission is returned when survey answers are missing, unknown or malformed.
+    ErrInvalidSubmission = errors.New("invalid survey submission")
+    // ErrAlreadySubmitted is returned when a user submits a survey a second time.
+    ErrAlreadySubmitted = errors.New("survey already submitted")
+    // ErrQuizNotFound is returned when a quiz, or a question of it, does not exist.
+    ErrQuizNotFound = errors.New("quiz not found")
+    // ErrQuestionNotOpen is returned for answers to a quiz question that is not open yet or whose
+    // time limit has passed.
+    ErrQuestionNotOpen = errors.New("quiz question is not open for answers")
+    // ErrUnsupportedReaction is returned for reactions outside the configured emoji set.
+    ErrUnsupportedReaction = errors.New("unsupported reaction")
+    // ErrNotInElectorate is returned for votes in a weighted poll by someone outside its electorate.
+    ErrNotInElectorate = errors.New("voter is not in the poll's electorate")
+    // ErrInsufficientCredits is returned when an allocation in a quadratic poll would exceed the voter's budget.
+    ErrInsufficientCredits = errors.New("not enough credits")
+    // ErrTemplateNotFound is returned when a poll template does not exist.
+    ErrTemplateNotFound = errors.New("poll template not found")
+    // ErrSeriesNotFound is returned when a series has neither a template nor any instances.
+    ErrSeriesNotFound = errors.New("series not found")
+    // ErrCollectionNotFound is returned when a collection does not exist.
+    ErrCollectionNotFound = errors.New("collection not found")
+    // ErrCollectionNotOpen is returned for votes in a poll whose collection is scheduled or closed.
+    ErrCollectionNotOpen = errors.New("collection is not open")
+    // ErrVersionConflict is returned when a poll changed since the version the caller last saw.
+    ErrVersionConflict = errors.New("poll was modified concurrently")
+)
@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+    "encoding/json"
+    "time"
+)
 
 type PollStatus string
 
@@ -9,37 +12,210 @@ const (
     PollClosed PollStatus = "closed"
 )
 
+// ThresholdBasis says what a poll's Threshold is compared with.
+type ThresholdBasis string
+
+const (
+    ThresholdVotes  ThresholdBasis = "votes"  // counted votes, one per voter
+    ThresholdWeight ThresholdBasis = "weight" // the summed weights of counted votes
+)
+
+// PollKind says how a poll is answered.
+type PollKind string
+
+const (
+    PollChoice PollKind = "choice" // votes pick an option
+    PollText   PollKind = "text"   // votes carry free text; results count terms for a word cloud
+    PollNPS    PollKind = "nps"    // options 0 to 10 are generated; results compute the Net Promoter Score
+    PollLikert PollKind = "likert" // a 5 or 7 point agreement scale is generated; results compute mean and top-box
+)
+
 type Poll struct {
+    ID                  uint
+    Kind                PollKind
+    Language            string // stopword language of a text poll; empty means the server default
+    Scale               int // points of an nps or likert poll, whose options run from lowest to highest
+    Title               string
+    Description         string
+    ImageURL            string // external http(s) URL, or MediaPath of an uploaded image
+    Status              PollStatus
+    Threshold           int // optional threshold to trigger webhook
+    ThresholdBasis      ThresholdBasis // what Threshold counts; votes unless set
+    Weighted            bool // only the poll's electorate may vote, each with their weight
+    CreditBudget        int // quadratic voting: credits per voter, n votes for an option cost n²; 0 disables it
+    Anonymous           bool // ballots are stored unlinked from voters
+    VoteRatePerMinute   int // optional per-poll vote rate limit override
+    VoteBurst           int
+    ChallengeDifficulty int // proof-of-work bits required per vote; 0 disables the challenge
+    Version             int // incremented on every change; used for optimistic concurrency
+    SurveyID            uint // set on polls backing a survey question; they take votes only through the survey
+    QuizID              uint // set on quiz question polls; closing one reveals its correct options
+    SeriesID            uint // set on instances of a recurring poll: the ID of the series template that created them
+    CollectionID        uint // set on polls grouped into a collection, which then governs their access and webhooks
+    ClosedByCollection  bool // closed by its collection closing, so opening the collection reopens it
+    Options             []Option
+    CreatedAt           time.Time
+    UpdatedAt           time.Time
+    DeletedAt           *time.Time // set while the poll is in the trash
+}
+
+// PollPatch changes the fields of a poll it sets and leaves the nil ones alone. Zero values are
+// applied like any other: a zero VoteRatePerMinute falls back to the server default.
+type PollPatch struct {
+    ID                  uint
+    Version             int // expected version; 0 skips the check
+    Title               *string
+    Description         *string
+    ImageURL            *string
+    Threshold           *int
+    ThresholdBasis      *ThresholdBasis
+    VoteRatePerMinute   *int
+    VoteBurst           *int
+    ChallengeDifficulty *int
+}
+
+// OptionPatch changes the fields of an option it sets and leaves the nil ones alone. An empty
+// string clears Description, ImageURL or Color; Metadata of JSON null or "" clears the metadata.
+type OptionPatch struct {
+    ID          uint
+    PollID      uint
+    Text        *string
+    Description *string
+    ImageURL    *string
+    Color       *string
+    Metadata    json.RawMessage
+}
+
+type Option struct {
     ID          uint
-    Title       string
+    PollID      uint
+    Text        string
     Description string
-    Status      PollStatus
-    Threshold   int // optional threshold to trigger webhook
-    Options     []Option
+    ImageURL    string          // external http(s) URL, or MediaPath of an uploaded image
+    Color       string          // #rgb or #rrggbb
+    Metadata    json.RawMessage // arbitrary client JSON, stored verbatim
+    Position    int             // display order within the poll, ascending
+    Correct     bool            // quiz polls only; reported false until the poll is closed
     CreatedAt   time.Time
     UpdatedAt   time.Time
 }
 
-type Option struct {
-    ID        uint
-    PollID    uint
-    Text      string
-    CreatedAt time.Time
-    UpdatedAt time.Time
+// MediaPath is the URL path under which uploaded media is served.
+const MediaPath = "/media/"
+
+// Media describes an uploaded file. Keys are derived from the content, so uploading the same bytes
+// twice yields the same Media.
+type Media struct {
+    Key          string
+    URL          string
+    ContentType  string
+    Size         int
+    Width        int // images only
+    Height       int // images only
+    ThumbnailURL string
+    Deduplicated bool // the content was already stored
 }
 
+// OptionDeletePolicy decides what happens to the votes of an option being deleted.
+type OptionDeletePolicy string
+
+const (
+    OptionDeleteReject   OptionDeletePolicy = "reject"   // refuse while the option has votes
+    OptionDeleteReassign OptionDeletePolicy = "reassign" // move its votes to another option
+    OptionDeleteDiscard  OptionDeletePolicy = "discard"  // delete its votes with it
+)
+
+type VoteStatus string
+
+const (
+    VoteCounted VoteStatus = "counted"
+    VoteFlagged VoteStatus = "flagged" // quarantined until a moderator accepts or purges it
+    VoteHidden  VoteStatus = "hidden"  // free-text response hidden by a moderator
+)
+
 type Vote struct {
-    ID        uint
-    PollID    uint
-    OptionID  uint
-    UserID    string // optional identifier
-    CreatedAt time.Time
+    ID         uint
+    PollID     uint
+    OptionID   uint // 0 in text polls
+    Text       string // text polls only
+    UserID     string // optional identifier
+    Weight     int // the voter's weight when the vote was cast, or the votes allocated in a quadratic poll; 1 otherwise
+    Status     VoteStatus
+    FlagReason string
+    ClientIP   string
+    UserAgent  string
+    CreatedAt  time.Time
+}
+
+// Challenge is a proof-of-work puzzle a client must solve before voting in a poll that requires it.
+type Challenge struct {
+    PollID     uint
+    Token      string
+    Difficulty int // leading zero bits of sha256(Token + solution)
+    ExpiresAt  time.Time
+}
+
+// ChallengeSolution is a solved Challenge submitted with a vote.
+type ChallengeSolution struct {
+    Token    string
+    Solution string
+}
+
+// Tally is a poll's counted votes per option, by headcount and by summed voter weight.
+type Tally struct {
+    Votes       map[uint]int
+    Weights     map[uint]int
+    Total       int
+    TotalWeight int
 }
 
 // Results represents counts per option.
 type Results struct {
-    PollID      uint
-    OptionVotes map[uint]int
-    Total       int
+    PollID        uint
+    OptionVotes   map[uint]int
+    OptionWeights map[uint]int // summed voter weights; equal to OptionVotes outside weighted polls
+    Options       []OptionResult // in display order
+    Terms         []TermCount // text polls: most frequent terms of visible responses, most frequent first
+    NPS           *NPSScore // nps polls only
+    Likert        *LikertScore // likert polls only
+    Total         int
+    TotalWeight   int
+    Credits       int // quadratic polls: credits spent by all voters
+}
+
+// NPSScore summarizes an nps poll: 9 and 10 are promoters, 7 and 8 passives, 0 to 6 detractors.
+type NPSScore struct {
+    Promoters  int
+    Passives   int
+    Detractors int
+    Score      float64 // percentage of promoters minus percentage of detractors, -100 to 100; 0 without votes
+}
+
+// LikertScore summarizes a likert poll whose points are scored 1 to Scale.
+type LikertScore struct {
+    Scale     int
+    Mean      float64 // 0 without votes
+    TopBox    float64 // percentage of votes for the highest point
+    TopTwoBox float64 // percentage of votes for the two highest points
+}
+
+// TermCount is how many visible responses of a text poll use a normalized term.
+type TermCount struct {
+    Term  string
+    Count int
+}
+
+// OptionResult is one option's tally within Results.
+type OptionResult struct {
+    OptionID    uint
+    Text        string
+    Description string
+    ImageURL    string
+    Color       string
+    Metadata    json.RawMessage
+    Correct     bool // set on a quiz poll's correct options once it is closed
+    Votes       int
+    Weight      int // summed voter weights of the votes; in quadratic polls, the votes allocated to the option
+    Credits     int // quadratic polls: credits spent on the option
 }
 
@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+// Quiz groups polls whose options are marked correct or not. Participants answer by voting in the
+// question polls and score for correct answers, more the faster they answer.
+type Quiz struct {
+    ID          uint
+    Title       string
+    Description string
+    Questions   []QuizQuestion // ordered by Position
+    CreatedAt   time.Time
+    UpdatedAt   time.Time
+}
+
+// QuizQuestion is a quiz poll with its scoring rules. Answers are taken from OpenedAt until
+// TimeLimitSeconds have passed; closing the poll reveals the correct options.
+type QuizQuestion struct {
+    ID               uint
+    QuizID           uint
+    Position         int
+    PollID           uint
+    Prompt           string // title of the poll
+    Points           int // for an instant correct answer; half of it at the time limit
+    TimeLimitSeconds int
+    OpenedAt         *time.Time // nil until the question is opened
+    Revealed         bool // the poll is closed
+    Options          []Option // options of the poll
+}
+
+// Leaderboard ranks a quiz's participants. Scores only count revealed questions, so they do not
+// give answers away; Answered counts every question answered so far.
+type Leaderboard struct {
+    QuizID    uint
+    Questions int
+    Revealed  int
+    Entries   []LeaderboardEntry
+}
+
+type LeaderboardEntry struct {
+    Rank         int // participants with equal score and response time share a rank
+    UserID       string
+    Score        int
+    Correct      int
+    Answered     int
+    ResponseTime time.Duration // total time taken for correct answers; breaks score ties
+}
@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// ReactionBurst is a poll's recent emoji reactions, broadcast every second for on-screen
+// animations. Reactions are only counted in memory, never stored one by one.
+type ReactionBurst struct {
+    PollID        uint
+    Counts        map[string]int     // reactions per emoji in the last full second
+    PerSecond     map[string]float64 // average rate per emoji over the rolling window
+    WindowSeconds int
+    At            time.Time
+}
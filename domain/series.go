@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+// SeriesResults shows how the instances of a recurring poll compare, oldest first.
+type SeriesResults struct {
+    SeriesID  uint
+    Name      string // the series template's name; empty once the template is deleted
+    Instances []SeriesInstance
+    Trends    []OptionTrend // one per option text seen in any instance, in order of first appearance
+}
+
+// SeriesInstance is one poll of a series with its results.
+type SeriesInstance struct {
+    PollID      uint
+    Title       string
+    Status      PollStatus
+    CreatedAt   time.Time
+    Total       int
+    TotalWeight int
+    Score       *float64 // nps polls: the NPS; likert polls: the mean
+    Options     []OptionResult
+}
+
+// OptionTrend follows an option, matched by text, across the instances of a series. Its slices
+// line up with SeriesResults.Instances; instances without the option count 0.
+type OptionTrend struct {
+    Text   string
+    Votes  []int
+    Shares []float64 // percentage of the instance's total votes
+    Change float64   // share in the latest instance minus share in the first
+}
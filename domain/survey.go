@@ -0,0 +1,71 @@
+package domain
+
+import "time"
+
+// QuestionKind says how a survey question is answered.
+type QuestionKind string
+
+const (
+    QuestionChoice QuestionKind = "choice" // pick one option; backed by a poll
+    QuestionText   QuestionKind = "text"   // free-text answer
+)
+
+// Survey groups ordered questions that are answered together in one submission. Each choice
+// question is backed by a poll, so its options, votes and results behave like any poll's.
+type Survey struct {
+    ID          uint
+    Title       string
+    Description string
+    Status      PollStatus
+    Questions   []Question // ordered by Position
+    CreatedAt   time.Time
+    UpdatedAt   time.Time
+}
+
+type Question struct {
+    ID       uint
+    SurveyID uint
+    Position int
+    Kind     QuestionKind
+    Prompt   string
+    Required bool     // submissions must answer it
+    PollID   uint     // backing poll of a choice question; 0 for text questions
+    Options  []Option // options of the backing poll
+}
+
+// SurveyAnswer answers one question: OptionID for a choice question, Text for a text question.
+type SurveyAnswer struct {
+    QuestionID uint
+    OptionID   uint
+    Text       string
+}
+
+// SurveySubmission is one respondent's answers to a survey, accepted or rejected as a whole.
+type SurveySubmission struct {
+    ID        uint
+    SurveyID  uint
+    UserID    string // optional; at most one submission per user and survey
+    Answers   []SurveyAnswer
+    CreatedAt time.Time
+}
+
+// SurveyResults reports how completely a survey was answered and each question's results.
+type SurveyResults struct {
+    SurveyID       uint
+    Submissions    int
+    Complete       int     // submissions answering every question, optional ones included
+    CompletionRate float64 // Complete / Submissions
+    Questions      []QuestionResult
+}
+
+// QuestionResult is one question's share of SurveyResults.
+type QuestionResult struct {
+    QuestionID     uint
+    Prompt         string
+    Kind           QuestionKind
+    Required       bool
+    Answered       int
+    CompletionRate float64        // Answered / Submissions
+    Options        []OptionResult // choice questions
+    Responses      []string       // text questions, newest first
+}
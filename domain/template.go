@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// PollTemplate describes a poll to create again and again. Text in Poll's title, description and
+// options may use {{variables}}: the built-in date, time, weekday, week, month and year of the
+// moment of instantiation, and Variables or values given when instantiating.
+type PollTemplate struct {
+    ID        uint
+    Name      string
+    Poll      Poll // settings and options of the polls to create; IDs, status and timestamps are ignored
+    Variables map[string]string // defaults for custom variables
+    Schedule  string // optional cron or RRULE-like expression; a poll is created every time it fires
+    Series    bool // polls created from the template form a series; each new one closes the previous
+    NextRunAt *time.Time // when the schedule fires next; nil without a schedule
+    LastRunAt *time.Time
+    CreatedAt time.Time
+    UpdatedAt time.Time
+}
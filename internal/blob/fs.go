@@ -0,0 +1,62 @@
+// Package blob stores uploaded files on the local filesystem or in an S3-compatible bucket.
+package blob
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "io/fs"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+// FSStore keeps blobs as files under a root directory; keys are slash-separated relative paths.
+type FSStore struct {
+    root string
+}
+
+func NewFSStore(root string) (*FSStore, error) {
+    if err := os.MkdirAll(root, 0o755); err != nil { return nil, fmt.Errorf("create blob dir: %w", err) }
+    return &FSStore{root: root}, nil
+}
+
+// Put writes to a temporary file and renames it into place, so readers never see partial blobs.
+func (s *FSStore) Put(_ context.Context, key string, r io.Reader) error {
+    path, err := s.path(key)
+    if err != nil { return err }
+    if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { return fmt.Errorf("put %s: %w", key, err) }
+    tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+    if err != nil { return fmt.Errorf("put %s: %w", key, err) }
+    defer os.Remove(tmp.Name())
+    if _, err := io.Copy(tmp, r); err != nil { tmp.Close(); return fmt.Errorf("put %s: %w", key, err) }
+    if err := tmp.Close(); err != nil { return fmt.Errorf("put %s: %w", key, err) }
+    if err := os.Rename(tmp.Name(), path); err != nil { return fmt.Errorf("put %s: %w", key, err) }
+    return nil
+}
+
+// Open returns an error wrapping fs.ErrNotExist for unknown keys.
+func (s *FSStore) Open(_ context.Context, key string) (io.ReadCloser, error) {
+    path, err := s.path(key)
+    if err != nil { return nil, err }
+    f, err := os.Open(path)
+    if err != nil { return nil, fmt.Errorf("open %s: %w", key, err) }
+    return f, nil
+}
+
+func (s *FSStore) Exists(_ context.Context, key string) (bool, error) {
+    path, err := s.path(key)
+    if err != nil { return false, err }
+    _, err = os.Stat(path)
+    if errors.Is(err, fs.ErrNotExist) { return false, nil }
+    if err != nil { return false, fmt.Errorf("stat %s: %w", key, err) }
+    return true, nil
+}
+
+// path maps a key into the root, refusing keys that would escape it.
+func (s *FSStore) path(key string) (string, error) {
+    clean := filepath.Clean("/" + key)
+    if key == "" || strings.HasPrefix(key, "/") || clean != "/"+key { return "", fmt.Errorf("blob key %q: %w", key, os.ErrNotExist) }
+    return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
@@ -0,0 +1,134 @@
+package blob
+
+import (
+    "bytes"
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "io"
+    "io/fs"
+    "net/http"
+    "net/url"
+    "strings"
+    "time"
+)
+
+// S3Config points an S3Store at a bucket. Endpoint is the service base URL, e.g.
+// https://s3.eu-west-1.amazonaws.com or http://localhost:9000 for a MinIO stand-in.
+type S3Config struct {
+    Endpoint        string
+    Bucket          string
+    Region          string
+    AccessKeyID     string
+    SecretAccessKey string
+}
+
+// S3Store keeps blobs in an S3-compatible bucket using path-style URLs and SigV4-signed requests,
+// which AWS S3, MinIO and most other implementations accept.
+type S3Store struct {
+    cfg    S3Config
+    base   *url.URL
+    client *http.Client
+    now    func() time.Time
+}
+
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+    if cfg.Endpoint == "" || cfg.Bucket == "" { return nil, fmt.Errorf("s3 store: endpoint and bucket required") }
+    if cfg.Region == "" { cfg.Region = "us-east-1" }
+    base, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+    if err != nil || base.Host == "" { return nil, fmt.Errorf("s3 store: bad endpoint %q", cfg.Endpoint) }
+    return &S3Store{cfg: cfg, base: base, client: &http.Client{Timeout: 30 * time.Second}, now: time.Now}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader) error {
+    body, err := io.ReadAll(r)
+    if err != nil { return fmt.Errorf("put %s: %w", key, err) }
+    resp, err := s.do(ctx, http.MethodPut, key, body)
+    if err != nil { return fmt.Errorf("put %s: %w", key, err) }
+    defer resp.Body.Close()
+    if resp.StatusCode/100 != 2 { return fmt.Errorf("put %s: %s", key, s3Error(resp)) }
+    return nil
+}
+
+// Open returns an error wrapping fs.ErrNotExist for unknown keys.
+func (s *S3Store) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+    resp, err := s.do(ctx, http.MethodGet, key, nil)
+    if err != nil { return nil, fmt.Errorf("open %s: %w", key, err) }
+    if resp.StatusCode == http.StatusNotFound { resp.Body.Close(); return nil, fmt.Errorf("open %s: %w", key, fs.ErrNotExist) }
+    if resp.StatusCode/100 != 2 { defer resp.Body.Close(); return nil, fmt.Errorf("open %s: %s", key, s3Error(resp)) }
+    return resp.Body, nil
+}
+
+func (s *S3Store) Exists(ctx context.Context, key string) (bool, error) {
+    resp, err := s.do(ctx, http.MethodHead, key, nil)
+    if err != nil { return false, fmt.Errorf("stat %s: %w", key, err) }
+    resp.Body.Close()
+    switch {
+    case resp.StatusCode == http.StatusNotFound: return false, nil
+    case resp.StatusCode/100 == 2: return true, nil
+    default: return false, fmt.Errorf("stat %s: %s", key, resp.Status)
+    }
+}
+
+func (s *S3Store) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+    u := *s.base
+    u.Path = s.base.Path + "/" + s.cfg.Bucket + "/" + key
+    u.RawPath = s.base.EscapedPath() + "/" + s3Escape(s.cfg.Bucket) + "/" + s3Escape(key)
+    req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+    if err != nil { return nil, err }
+    s.sign(req, body)
+    return s.client.Do(req)
+}
+
+// sign adds AWS Signature Version 4 headers for the s3 service.
+func (s *S3Store) sign(req *http.Request, body []byte) {
+    now := s.now().UTC()
+    amzDate := now.Format("20060102T150405Z")
+    day := now.Format("20060102")
+    payloadHash := sha256Hex(body)
+    req.Header.Set("x-amz-date", amzDate)
+    req.Header.Set("x-amz-content-sha256", payloadHash)
+
+    signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+    canonicalHeaders := "host:" + req.URL.Host + "\n" + "x-amz-content-sha256:" + payloadHash + "\n" + "x-amz-date:" + amzDate + "\n"
+    canonicalRequest := strings.Join([]string{req.Method, req.URL.EscapedPath(), req.URL.RawQuery, canonicalHeaders, strings.Join(signed, ";"), payloadHash}, "\n")
+    scope := day + "/" + s.cfg.Region + "/s3/aws4_request"
+    toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+    key := hmacSHA256([]byte("AWS4"+s.cfg.SecretAccessKey), day)
+    key = hmacSHA256(key, s.cfg.Region)
+    key = hmacSHA256(key, "s3")
+    key = hmacSHA256(key, "aws4_request")
+    sig := hex.EncodeToString(hmacSHA256(key, toSign))
+    req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.cfg.AccessKeyID, scope, strings.Join(signed, ";"), sig))
+}
+
+// s3Escape percent-encodes every byte of p but the unreserved characters and '/', as SigV4
+// canonical URIs require; url.PathEscape leaves characters such as '+' and '=' alone.
+func s3Escape(p string) string {
+    var b strings.Builder
+    for i := 0; i < len(p); i++ {
+        c := p[i]
+        if c == '/' || c == '-' || c == '_' || c == '.' || c == '~' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' {
+            b.WriteByte(c)
+            continue
+        }
+        fmt.Fprintf(&b, "%%%02X", c)
+    }
+    return b.String()
+}
+
+func sha256Hex(b []byte) string { sum := sha256.Sum256(b); return hex.EncodeToString(sum[:]) }
+
+func hmacSHA256(key []byte, data string) []byte {
+    m := hmac.New(sha256.New, key)
+    m.Write([]byte(data))
+    return m.Sum(nil)
+}
+
+func s3Error(resp *http.Response) string {
+    msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+    return strings.TrimSpace(resp.Status + " " + string(msg))
+}
@@ -0,0 +1,63 @@
+package blob
+
+import (
+    "context"
+    "errors"
+    "io"
+    "io/fs"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "testing"
+)
+
+// fakeS3 keeps objects by their escaped request path and records what each request carried.
+type fakeS3 struct {
+    mu      sync.Mutex
+    objects map[string][]byte
+    paths   []string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.paths = append(f.paths, r.URL.EscapedPath())
+    body, _ := io.ReadAll(r.Body)
+    if sha256Hex(body) != r.Header.Get("x-amz-content-sha256") || !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+        http.Error(w, "bad signature", http.StatusForbidden)
+        return
+    }
+    key := r.URL.EscapedPath()
+    switch r.Method {
+    case http.MethodPut:
+        f.objects[key] = body
+    case http.MethodGet, http.MethodHead:
+        b, ok := f.objects[key]
+        if !ok { http.NotFound(w, r); return }
+        if r.Method == http.MethodGet { w.Write(b) }
+    }
+}
+
+func TestS3Store(t *testing.T) {
+    ctx := context.Background()
+    fake := &fakeS3{objects: map[string][]byte{}}
+    srv := httptest.NewServer(fake)
+    defer srv.Close()
+    s, err := NewS3Store(S3Config{Endpoint: srv.URL + "/", Bucket: "pulse", AccessKeyID: "AKID", SecretAccessKey: "secret"})
+    if err != nil { t.Fatalf("new store: %v", err) }
+
+    key := "sha256/ab/a b+c=d.png"
+    if err := s.Put(ctx, key, strings.NewReader("png")); err != nil { t.Fatalf("put: %v", err) }
+    if got, want := fake.paths[0], "/pulse/sha256/ab/a%20b%2Bc%3Dd.png"; got != want { t.Fatalf("request path %q, want %q", got, want) }
+    ok, err := s.Exists(ctx, key)
+    if err != nil || !ok { t.Fatalf("exists: %v %v", ok, err) }
+    rc, err := s.Open(ctx, key)
+    if err != nil { t.Fatalf("open: %v", err) }
+    b, _ := io.ReadAll(rc)
+    rc.Close()
+    if string(b) != "png" { t.Fatalf("open read %q", b) }
+
+    if ok, err := s.Exists(ctx, "sha256/ab/missing.png"); err != nil || ok { t.Fatalf("exists missing: %v %v", ok, err) }
+    if _, err := s.Open(ctx, "sha256/ab/missing.png"); !errors.Is(err, fs.ErrNotExist) { t.Fatalf("open missing: %v, want fs.ErrNotExist", err) }
+}
@@ -0,0 +1,83 @@
+package fraud
+
+import (
+    "fmt"
+    "net"
+    "sort"
+    "time"
+
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// IPRangeBurst triggers when Max or more recent votes came from the vote's network
+// (/24 for IPv4, /64 for IPv6).
+type IPRangeBurst struct {
+    Max    int
+    Weight float64
+}
+
+func (s IPRangeBurst) Score(v domain.Vote, recent []domain.Vote) (float64, string) {
+    if v.ClientIP == "" { return 0, "" }
+    nw := network(v.ClientIP)
+    n := 0
+    for _, r := range recent {
+        if network(r.ClientIP) == nw { n++ }
+    }
+    if n < s.Max { return 0, "" }
+    return s.Weight, fmt.Sprintf("%d recent votes from %s", n, nw)
+}
+
+// SameUserAgent triggers when Max or more recent votes share the vote's user agent.
+type SameUserAgent struct {
+    Max    int
+    Weight float64
+}
+
+func (s SameUserAgent) Score(v domain.Vote, recent []domain.Vote) (float64, string) {
+    if v.UserAgent == "" { return 0, "" }
+    n := 0
+    for _, r := range recent {
+        if r.UserAgent == v.UserAgent { n++ }
+    }
+    if n < s.Max { return 0, "" }
+    return s.Weight, fmt.Sprintf("%d recent votes with the same user agent", n)
+}
+
+// RegularTiming triggers when votes from the vote's network arrive at near-constant intervals,
+// which humans do not produce. At least MinSamples intervals must agree within MaxJitter.
+type RegularTiming struct {
+    MinSamples int
+    MaxJitter  time.Duration
+    Weight     float64
+}
+
+func (s RegularTiming) Score(v domain.Vote, recent []domain.Vote) (float64, string) {
+    if v.ClientIP == "" { return 0, "" }
+    nw := network(v.ClientIP)
+    times := []time.Time{v.CreatedAt}
+    for _, r := range recent {
+        if network(r.ClientIP) == nw { times = append(times, r.CreatedAt) }
+    }
+    if len(times) <= s.MinSamples { return 0, "" }
+    sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+    // only the most recent run matters
+    times = times[len(times)-s.MinSamples-1:]
+    lo, hi := times[1].Sub(times[0]), times[1].Sub(times[0])
+    for i := 2; i < len(times); i++ {
+        d := times[i].Sub(times[i-1])
+        if d < lo { lo = d }
+        if d > hi { hi = d }
+    }
+    if hi-lo > s.MaxJitter { return 0, "" }
+    return s.Weight, fmt.Sprintf("votes from %s every %s", nw, hi.Round(time.Millisecond))
+}
+
+// network maps an address to its /24 (IPv4) or /64 (IPv6) prefix; unparsable input is returned as-is.
+func network(addr string) string {
+    ip := net.ParseIP(addr)
+    if ip == nil { return addr }
+    if v4 := ip.To4(); v4 != nil {
+        return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+    }
+    return (&net.IPNet{IP: ip.Mask(net.CIDRMask(64, 128)), Mask: net.CIDRMask(64, 128)}).String()
+}
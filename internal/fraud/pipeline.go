@@ -0,0 +1,53 @@
+package fraud
+
+import (
+    "context"
+    "time"
+
+    "github.com/robjsliwa/pulse/app"
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// Scorer inspects one signal of a vote against recent votes in the same poll. It returns the
+// score it contributes (0 when the signal is absent) and a human-readable reason.
+type Scorer interface {
+    Score(v domain.Vote, recent []domain.Vote) (float64, string)
+}
+
+// Pipeline sums the scores of its scorers and flags the vote once the total reaches threshold.
+type Pipeline struct {
+    scorers   []Scorer
+    threshold float64
+    lookback  time.Duration
+}
+
+func NewPipeline(threshold float64, lookback time.Duration, scorers ...Scorer) *Pipeline {
+    if threshold <= 0 { threshold = 1 }
+    if lookback <= 0 { lookback = 5 * time.Minute }
+    return &Pipeline{scorers: scorers, threshold: threshold, lookback: lookback}
+}
+
+// DefaultPipeline combines the built-in heuristics so that no single signal flags a vote on its own.
+func DefaultPipeline(threshold float64, lookback time.Duration) *Pipeline {
+    return NewPipeline(threshold, lookback,
+        IPRangeBurst{Max: 20, Weight: 0.6},
+        SameUserAgent{Max: 20, Weight: 0.4},
+        RegularTiming{MinSamples: 5, MaxJitter: 50 * time.Millisecond, Weight: 0.6},
+    )
+}
+
+var _ app.VoteScreener = (*Pipeline)(nil)
+
+func (p *Pipeline) Lookback() time.Duration { return p.lookback }
+
+func (p *Pipeline) Screen(_ context.Context, v domain.Vote, recent []domain.Vote) app.Screening {
+    var sc app.Screening
+    for _, s := range p.scorers {
+        score, reason := s.Score(v, recent)
+        if score <= 0 { continue }
+        sc.Score += score
+        sc.Reasons = append(sc.Reasons, reason)
+    }
+    sc.Flagged = sc.Score >= p.threshold
+    return sc
+}
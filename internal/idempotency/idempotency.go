@@ -0,0 +1,69 @@
+package idempotency
+
+import (
+    "context"
+    "sync"
+    "time"
+)
+
+// Record is the stored outcome of a request made under an Idempotency-Key.
+type Record struct {
+    Key         string
+    RequestHash string // fingerprint of method, path and body
+    Completed   bool   // false while the original request is still in flight
+    Status      int
+    Body        []byte
+    ExpiresAt   time.Time
+}
+
+// Store persists idempotency records. A shared store (e.g. the database) lets replays land on any replica.
+type Store interface {
+    // Reserve claims key for a new request. If an unexpired record already holds the key it is
+    // returned with false and nothing is changed.
+    Reserve(ctx context.Context, rec Record) (Record, bool, error)
+    // Complete stores the response for a reserved key.
+    Complete(ctx context.Context, key string, status int, body []byte) error
+    // Release drops a reservation so the request may be retried, e.g. after a server error.
+    Release(ctx context.Context, key string) error
+}
+
+// MemoryStore is a process-local Store.
+type MemoryStore struct {
+    mu   sync.Mutex
+    recs map[string]Record
+    now  func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore { return &MemoryStore{recs: make(map[string]Record), now: time.Now} }
+
+var _ Store = (*MemoryStore)(nil)
+
+func (s *MemoryStore) Reserve(_ context.Context, rec Record) (Record, bool, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    now := s.now()
+    for k, r := range s.recs {
+        if now.After(r.ExpiresAt) { delete(s.recs, k) }
+    }
+    if existing, ok := s.recs[rec.Key]; ok { return existing, false, nil }
+    rec.Completed = false
+    s.recs[rec.Key] = rec
+    return rec, true, nil
+}
+
+func (s *MemoryStore) Complete(_ context.Context, key string, status int, body []byte) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if r, ok := s.recs[key]; ok {
+        r.Completed, r.Status, r.Body = true, status, body
+        s.recs[key] = r
+    }
+    return nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    delete(s.recs, key)
+    return nil
+}
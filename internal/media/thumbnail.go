@@ -0,0 +1,81 @@
+// Package media decodes uploaded images and renders thumbnails for them.
+package media
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "image"
+    "image/gif"
+    "image/jpeg"
+    "image/png"
+
+    "golang.org/x/image/draw"
+    "golang.org/x/image/webp"
+
+    "github.com/robjsliwa/pulse/app"
+)
+
+// MaxPixels guards against decompression bombs: small files that decode to huge images.
+const MaxPixels = 50_000_000
+
+// Thumbnailer scales images down so their longer side is at most MaxSide pixels. JPEG sources
+// produce JPEG thumbnails; everything else produces PNG to keep transparency.
+type Thumbnailer struct {
+    MaxSide int
+}
+
+func NewThumbnailer(maxSide int) *Thumbnailer {
+    if maxSide <= 0 { maxSide = 320 }
+    return &Thumbnailer{MaxSide: maxSide}
+}
+
+var _ app.Thumbnailer = (*Thumbnailer)(nil)
+
+func (t *Thumbnailer) Thumbnail(data []byte, contentType string) (app.Thumbnail, error) {
+    decode, ok := decoders[contentType]
+    if !ok { return app.Thumbnail{}, fmt.Errorf("thumbnail: no decoder for %s", contentType) }
+    cfg, err := decode.config(bytes.NewReader(data))
+    if err != nil { return app.Thumbnail{}, fmt.Errorf("thumbnail: %w", err) }
+    if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+        return app.Thumbnail{}, errors.New("thumbnail: image dimensions out of range")
+    }
+    src, err := decode.image(bytes.NewReader(data))
+    if err != nil { return app.Thumbnail{}, fmt.Errorf("thumbnail: %w", err) }
+    w, h := fit(cfg.Width, cfg.Height, t.MaxSide)
+    dst := image.NewRGBA(image.Rect(0, 0, w, h))
+    draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+    var buf bytes.Buffer
+    out := app.Thumbnail{Width: cfg.Width, Height: cfg.Height}
+    if contentType == "image/jpeg" {
+        out.ContentType = "image/jpeg"
+        err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 82})
+    } else {
+        out.ContentType = "image/png"
+        err = png.Encode(&buf, dst)
+    }
+    if err != nil { return app.Thumbnail{}, fmt.Errorf("thumbnail: encode: %w", err) }
+    out.Data = buf.Bytes()
+    return out, nil
+}
+
+// fit scales w×h to fit within max×max, keeping the aspect ratio and never upscaling.
+func fit(w, h, max int) (int, int) {
+    if w <= max && h <= max { return w, h }
+    if w >= h { return max, maxInt(1, h*max/w) }
+    return maxInt(1, w*max/h), max
+}
+
+func maxInt(a, b int) int { if a > b { return a }; return b }
+
+type decoder struct {
+    config func(r *bytes.Reader) (image.Config, error)
+    image  func(r *bytes.Reader) (image.Image, error)
+}
+
+var decoders = map[string]decoder{
+    "image/png":  {func(r *bytes.Reader) (image.Config, error) { return png.DecodeConfig(r) }, func(r *bytes.Reader) (image.Image, error) { return png.Decode(r) }},
+    "image/jpeg": {func(r *bytes.Reader) (image.Config, error) { return jpeg.DecodeConfig(r) }, func(r *bytes.Reader) (image.Image, error) { return jpeg.Decode(r) }},
+    "image/gif":  {func(r *bytes.Reader) (image.Config, error) { return gif.DecodeConfig(r) }, func(r *bytes.Reader) (image.Image, error) { return gif.Decode(r) }},
+    "image/webp": {func(r *bytes.Reader) (image.Config, error) { return webp.DecodeConfig(r) }, func(r *bytes.Reader) (image.Image, error) { return webp.Decode(r) }},
+}
@@ -0,0 +1,99 @@
+package pow
+
+import (
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "fmt"
+    "math/bits"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/robjsliwa/pulse/app"
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// Issuer hands out stateless, HMAC-signed hashcash challenges. A solution is a string s such that
+// sha256(token + s) starts with at least difficulty zero bits. The issuer remembers nothing; the
+// service records the tokens Verify accepts in the repository, which all replicas share.
+type Issuer struct {
+    secret []byte
+    ttl    time.Duration
+    now    func() time.Time
+}
+
+// NewIssuer signs challenges with secret; an empty secret gets a random per-process key,
+// which only works for a single replica.
+func NewIssuer(secret []byte, ttl time.Duration) (*Issuer, error) {
+    if len(secret) == 0 {
+        secret = make([]byte, 32)
+        if _, err := rand.Read(secret); err != nil { return nil, fmt.Errorf("challenge secret: %w", err) }
+    }
+    if ttl <= 0 { ttl = 2 * time.Minute }
+    return &Issuer{secret: secret, ttl: ttl, now: time.Now}, nil
+}
+
+var _ app.ChallengeIssuer = (*Issuer)(nil)
+
+func (i *Issuer) Issue(pollID uint, difficulty int) (domain.Challenge, error) {
+    nonce := make([]byte, 12)
+    if _, err := rand.Read(nonce); err != nil { return domain.Challenge{}, fmt.Errorf("challenge nonce: %w", err) }
+    exp := i.now().Add(i.ttl).UTC().Truncate(time.Second)
+    payload := fmt.Sprintf("%d:%d:%d:%s", pollID, difficulty, exp.Unix(), hex.EncodeToString(nonce))
+    token := base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + i.sign(payload)
+    return domain.Challenge{PollID: pollID, Token: token, Difficulty: difficulty, ExpiresAt: exp}, nil
+}
+
+// Verify returns the token's signature, which identifies it, and its expiry.
+func (i *Issuer) Verify(pollID uint, difficulty int, token, solution string) (string, time.Time, error) {
+    if token == "" || solution == "" { return "", time.Time{}, domain.ErrChallengeRequired }
+    enc, sig, ok := strings.Cut(token, ".")
+    if !ok { return invalid("malformed token") }
+    raw, err := base64.RawURLEncoding.DecodeString(enc)
+    if err != nil { return invalid("malformed token") }
+    payload := string(raw)
+    if !hmac.Equal([]byte(sig), []byte(i.sign(payload))) { return invalid("bad signature") }
+    parts := strings.Split(payload, ":")
+    if len(parts) != 4 { return invalid("malformed token") }
+    pid, _ := strconv.ParseUint(parts[0], 10, 64)
+    diff, _ := strconv.Atoi(parts[1])
+    expUnix, _ := strconv.ParseInt(parts[2], 10, 64)
+    if uint(pid) != pollID { return invalid("challenge issued for another poll") }
+    if diff < difficulty { return invalid("challenge difficulty too low") }
+    exp := time.Unix(expUnix, 0).UTC()
+    if i.now().After(exp) { return invalid("challenge expired") }
+    sum := sha256.Sum256([]byte(token + solution))
+    if leadingZeroBits(sum[:]) < diff { return invalid("solution does not meet difficulty") }
+    return sig, exp, nil
+}
+
+func (i *Issuer) sign(payload string) string {
+    mac := hmac.New(sha256.New, i.secret)
+    mac.Write([]byte(payload))
+    return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func invalid(reason string) (string, time.Time, error) {
+    return "", time.Time{}, fmt.Errorf("%w: %s", domain.ErrChallengeInvalid, reason)
+}
+
+func leadingZeroBits(b []byte) int {
+    n := 0
+    for _, x := range b {
+        if x != 0 { return n + bits.LeadingZeros8(x) }
+        n += 8
+    }
+    return n
+}
+
+// Solve brute-forces a solution; intended for clients written in Go and for tooling.
+func Solve(token string, difficulty int) string {
+    for n := uint64(0); ; n++ {
+        s := strconv.FormatUint(n, 10)
+        sum := sha256.Sum256([]byte(token + s))
+        if leadingZeroBits(sum[:]) >= difficulty { return s }
+    }
+}
@@ -0,0 +1,56 @@
+package pow
+
+import (
+    "crypto/sha256"
+    "errors"
+    "strconv"
+    "testing"
+    "time"
+
+    "github.com/robjsliwa/pulse/domain"
+)
+
+func solve(t *testing.T, ch domain.Challenge) string {
+    t.Helper()
+    for n := 0; ; n++ {
+        s := strconv.Itoa(n)
+        sum := sha256.Sum256([]byte(ch.Token + s))
+        if leadingZeroBits(sum[:]) >= ch.Difficulty { return s }
+    }
+}
+
+func TestVerifyReturnsTokenKeyAndExpiry(t *testing.T) {
+    i, err := NewIssuer([]byte("secret"), time.Minute)
+    if err != nil { t.Fatalf("new issuer: %v", err) }
+    ch, err := i.Issue(1, 4)
+    if err != nil { t.Fatalf("issue: %v", err) }
+    s := solve(t, ch)
+    if _, _, err := i.Verify(2, 4, ch.Token, s); !errors.Is(err, domain.ErrChallengeInvalid) { t.Fatalf("verify for another poll: %v, want ErrChallengeInvalid", err) }
+    if _, _, err := i.Verify(1, 5, ch.Token, s); !errors.Is(err, domain.ErrChallengeInvalid) { t.Fatalf("verify at a higher difficulty: %v, want ErrChallengeInvalid", err) }
+    if _, _, err := i.Verify(1, 4, ch.Token, ""); !errors.Is(err, domain.ErrChallengeRequired) { t.Fatalf("verify without a solution: %v, want ErrChallengeRequired", err) }
+    key, exp, err := i.Verify(1, 4, ch.Token, s)
+    if err != nil { t.Fatalf("verify: %v", err) }
+    if !exp.Equal(ch.ExpiresAt) { t.Fatalf("expiry = %v, want %v", exp, ch.ExpiresAt) }
+    // the issuer keeps no state: the same token verifies to the same key, for the caller to spend once
+    if again, _, err := i.Verify(1, 4, ch.Token, s); err != nil || again != key { t.Fatalf("verify again: %q %v, want %q", again, err, key) }
+    other, err := i.Issue(1, 4)
+    if err != nil { t.Fatalf("issue: %v", err) }
+    if k, _, _ := i.Verify(1, 4, other.Token, solve(t, other)); k == key { t.Fatal("two tokens share a key") }
+}
+
+func TestVerifyRejectsExpiredAndForgedTokens(t *testing.T) {
+    now := time.Unix(1_700_000_000, 0)
+    i, err := NewIssuer([]byte("secret"), time.Minute)
+    if err != nil { t.Fatalf("new issuer: %v", err) }
+    i.now = func() time.Time { return now }
+    ch, err := i.Issue(1, 1)
+    if err != nil { t.Fatalf("issue: %v", err) }
+    s := solve(t, ch)
+    forger, err := NewIssuer([]byte("guess"), time.Minute)
+    if err != nil { t.Fatalf("new issuer: %v", err) }
+    forged, err := forger.Issue(1, 1)
+    if err != nil { t.Fatalf("issue: %v", err) }
+    if _, _, err := i.Verify(1, 1, forged.Token, solve(t, forged)); !errors.Is(err, domain.ErrChallengeInvalid) { t.Fatalf("forged token: %v, want ErrChallengeInvalid", err) }
+    now = now.Add(2 * time.Minute)
+    if _, _, err := i.Verify(1, 1, ch.Token, s); !errors.Is(err, domain.ErrChallengeInvalid) { t.Fatalf("expired token: %v, want ErrChallengeInvalid", err) }
+}
@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+    "context"
+    "sync"
+    "time"
+)
+
+type bucket struct {
+    tokens float64
+    last   time.Time
+    limit  Limit
+}
+
+// MemoryStore is a process-local Store. Idle buckets are swept once they would be full again.
+type MemoryStore struct {
+    mu        sync.Mutex
+    buckets   map[string]*bucket
+    lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore { return &MemoryStore{buckets: make(map[string]*bucket)} }
+
+var _ Store = (*MemoryStore)(nil)
+
+func (s *MemoryStore) Take(_ context.Context, key string, l Limit, now time.Time) (bool, time.Duration, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.sweep(now)
+    b, ok := s.buckets[key]
+    if !ok {
+        b = &bucket{tokens: float64(l.Burst), last: now}
+        s.buckets[key] = b
+    }
+    b.tokens += now.Sub(b.last).Seconds() * l.Rate
+    if b.tokens > float64(l.Burst) { b.tokens = float64(l.Burst) }
+    b.last = now
+    b.limit = l
+    if b.tokens >= 1 {
+        b.tokens--
+        return true, 0, nil
+    }
+    return false, time.Duration((1 - b.tokens) / l.Rate * float64(time.Second)), nil
+}
+
+// sweep drops buckets that have refilled completely, since a fresh bucket is identical; the caller holds mu.
+func (s *MemoryStore) sweep(now time.Time) {
+    if now.Sub(s.lastSweep) < time.Minute { return }
+    s.lastSweep = now
+    for k, b := range s.buckets {
+        if b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate >= float64(b.limit.Burst) { delete(s.buckets, k) }
+    }
+}
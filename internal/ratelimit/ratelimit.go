@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+    "context"
+    "fmt"
+    "math"
+    "time"
+)
+
+// Limit describes a token bucket: Rate tokens are added per second up to Burst.
+type Limit struct {
+    Rate  float64
+    Burst int
+}
+
+// PerMinute builds a Limit from a per-minute rate.
+func PerMinute(n, burst int) Limit { return Limit{Rate: float64(n) / 60, Burst: burst} }
+
+// Store keeps bucket state. The in-memory store is the default; a shared store
+// (e.g. Redis) lets several replicas enforce the same limits.
+type Store interface {
+    // Take removes one token from the bucket identified by key. When the bucket is empty
+    // it reports false and how long until a token becomes available.
+    Take(ctx context.Context, key string, l Limit, now time.Time) (bool, time.Duration, error)
+}
+
+type Limiter struct {
+    store Store
+    def   Limit
+    now   func() time.Time
+}
+
+func NewLimiter(store Store, def Limit) *Limiter {
+    if store == nil { store = NewMemoryStore() }
+    return &Limiter{store: store, def: def, now: time.Now}
+}
+
+// Default returns the limit applied when no per-poll override is set.
+func (l *Limiter) Default() Limit { return l.def }
+
+// Allow takes a token from every key's bucket. It reports false with the longest retry delay
+// if any bucket is exhausted.
+func (l *Limiter) Allow(ctx context.Context, lim Limit, keys ...string) (bool, time.Duration, error) {
+    if lim.Rate <= 0 || lim.Burst <= 0 { return true, 0, nil } // disabled
+    now := l.now()
+    allowed := true
+    var wait time.Duration
+    for _, k := range keys {
+        ok, retry, err := l.store.Take(ctx, k, lim, now)
+        if err != nil { return false, 0, fmt.Errorf("take %s: %w", k, err) }
+        if !ok {
+            allowed = false
+            if retry > wait { wait = retry }
+        }
+    }
+    return allowed, wait, nil
+}
+
+// RetryAfterSeconds rounds a delay up to whole seconds for the Retry-After header.
+func RetryAfterSeconds(d time.Duration) int {
+    s := int(math.Ceil(d.Seconds()))
+    if s < 1 { s = 1 }
+    return s
+}
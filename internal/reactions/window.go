@@ -0,0 +1,80 @@
+// Package reactions aggregates emoji reactions in memory: each poll keeps one bucket of counts
+// per second of a rolling window, so memory stays bounded however many reactions arrive.
+package reactions
+
+import (
+    "sync"
+    "time"
+
+    "github.com/robjsliwa/pulse/app"
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// DefaultWindowSeconds is used when NewWindow gets no positive size.
+const DefaultWindowSeconds = 10
+
+type bucket struct {
+    sec    int64 // Unix second the counts belong to
+    counts map[string]int
+}
+
+// Window implements app.ReactionWindow.
+type Window struct {
+    mu      sync.Mutex
+    seconds int
+    polls   map[uint][]bucket // ring of seconds buckets, indexed by Unix second modulo seconds
+}
+
+func NewWindow(seconds int) *Window {
+    if seconds <= 0 { seconds = DefaultWindowSeconds }
+    return &Window{seconds: seconds, polls: make(map[uint][]bucket)}
+}
+
+var _ app.ReactionWindow = (*Window)(nil)
+
+func (w *Window) Add(pollID uint, emoji string, at time.Time) {
+    sec := at.Unix()
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    bs, ok := w.polls[pollID]
+    if !ok {
+        bs = make([]bucket, w.seconds)
+        w.polls[pollID] = bs
+    }
+    b := &bs[sec%int64(w.seconds)]
+    if b.sec != sec { *b = bucket{sec: sec, counts: map[string]int{}} }
+    b.counts[emoji]++
+}
+
+func (w *Window) Burst(pollID uint, now time.Time) domain.ReactionBurst {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    return w.burst(pollID, w.polls[pollID], now)
+}
+
+func (w *Window) Bursts(now time.Time) []domain.ReactionBurst {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    out := make([]domain.ReactionBurst, 0, len(w.polls))
+    for id, bs := range w.polls {
+        b := w.burst(id, bs, now)
+        if len(b.PerSecond) == 0 { delete(w.polls, id) }
+        out = append(out, b)
+    }
+    return out
+}
+
+// burst sums the buckets inside the window ending at now; the caller holds the lock.
+func (w *Window) burst(pollID uint, bs []bucket, now time.Time) domain.ReactionBurst {
+    sec := now.Unix()
+    out := domain.ReactionBurst{PollID: pollID, Counts: map[string]int{}, PerSecond: map[string]float64{}, WindowSeconds: w.seconds, At: now}
+    for _, b := range bs {
+        if b.counts == nil || b.sec > sec || b.sec <= sec-int64(w.seconds) { continue }
+        for emoji, n := range b.counts {
+            out.PerSecond[emoji] += float64(n)
+            if b.sec == sec-1 { out.Counts[emoji] += n }
+        }
+    }
+    for emoji := range out.PerSecond { out.PerSecond[emoji] /= float64(w.seconds) }
+    return out
+}
@@ -0,0 +1,38 @@
+package reactions
+
+import (
+    "testing"
+    "time"
+)
+
+func TestWindowExpiresReactions(t *testing.T) {
+    w := NewWindow(3)
+    t0 := time.Unix(1000, 0)
+    for i := 0; i < 3; i++ { w.Add(1, "👍", t0) }
+    w.Add(1, "🎉", t0.Add(time.Second))
+
+    b := w.Burst(1, t0.Add(time.Second))
+    if b.WindowSeconds != 3 || len(b.Counts) != 1 || b.Counts["👍"] != 3 { t.Fatalf("counts of the last full second = %v", b.Counts) }
+    if b.PerSecond["👍"] != 1 || b.PerSecond["🎉"] != 1.0/3 { t.Fatalf("per second = %v", b.PerSecond) }
+
+    // t0 leaves the window once it is 3 seconds old
+    b = w.Burst(1, t0.Add(3*time.Second))
+    if _, ok := b.PerSecond["👍"]; ok || b.PerSecond["🎉"] != 1.0/3 { t.Fatalf("per second after t0 expired = %v", b.PerSecond) }
+    // a new second reuses t0's slot without carrying its counts over
+    w.Add(1, "🎉", t0.Add(3*time.Second))
+    b = w.Burst(1, t0.Add(4*time.Second))
+    if _, ok := b.PerSecond["👍"]; ok || b.PerSecond["🎉"] != 1.0/3 || b.Counts["🎉"] != 1 { t.Fatalf("burst after reuse = %+v", b) }
+    if b = w.Burst(1, t0.Add(time.Minute)); len(b.PerSecond) != 0 || len(b.Counts) != 0 { t.Fatalf("burst after the window = %+v", b) }
+}
+
+func TestWindowBurstsForgetsIdlePolls(t *testing.T) {
+    w := NewWindow(0)
+    t0 := time.Unix(1000, 0)
+    w.Add(1, "👍", t0)
+    w.Add(2, "👍", t0.Add(DefaultWindowSeconds*time.Second))
+    bs := w.Bursts(t0.Add(DefaultWindowSeconds * time.Second))
+    if len(bs) != 2 { t.Fatalf("bursts = %+v, want both polls", bs) }
+    // poll 1 had nothing left in its window, so it is dropped after reporting empty once
+    bs = w.Bursts(t0.Add(DefaultWindowSeconds * time.Second))
+    if len(bs) != 1 || bs[0].PollID != 2 { t.Fatalf("bursts = %+v, want only poll 2", bs) }
+}
@@ -0,0 +1,172 @@
+// Package schedule evaluates the schedule expressions of poll templates: standard five-field cron
+// expressions (minute hour day-of-month month day-of-week) with lists, ranges, steps and the
+// @hourly, @daily, @weekly, @monthly and @yearly shorthands, or RRULE-like recurrence rules such
+// as "FREQ=WEEKLY;BYDAY=MO;BYHOUR=9" (see rruleToCron). A leading "TZ=<IANA zone>" evaluates the
+// expression in that zone instead of UTC.
+package schedule
+
+import (
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/robjsliwa/pulse/app"
+)
+
+// Cron implements app.ScheduleParser for cron expressions and recurrence rules.
+type Cron struct{}
+
+var _ app.ScheduleParser = Cron{}
+
+// Next returns the first minute later than after that expr matches; ErrNever for expressions that
+// cannot fire, such as "0 0 31 2 *".
+func (Cron) Next(expr string, after time.Time) (time.Time, error) {
+    s, err := Parse(expr)
+    if err != nil {
+        return time.Time{}, err
+    }
+    next := s.Next(after)
+    if next.IsZero() {
+        return next, fmt.Errorf("schedule %q: %w", expr, ErrNever)
+    }
+    return next, nil
+}
+
+// ErrNever is returned for expressions that never fire.
+var ErrNever = errors.New("never fires")
+
+// Spec is a parsed cron expression.
+type Spec struct {
+    minute, hour, dom, month, dow uint64 // bit sets of the matching values
+    anyDom, anyDow                bool   // the field was "*"; see Next for how they combine
+    loc                           *time.Location
+}
+
+var shorthands = map[string]string{
+    "@hourly":   "0 * * * *",
+    "@daily":    "0 0 * * *",
+    "@midnight": "0 0 * * *",
+    "@weekly":   "0 0 * * 0",
+    "@monthly":  "0 0 1 * *",
+    "@yearly":   "0 0 1 1 *",
+    "@annually": "0 0 1 1 *",
+}
+
+// Parse parses a cron expression.
+func Parse(expr string) (*Spec, error) {
+    expr = strings.TrimSpace(expr)
+    s := &Spec{loc: time.UTC}
+    if rest, ok := strings.CutPrefix(expr, "TZ="); ok {
+        zone, fields, _ := strings.Cut(rest, " ")
+        loc, err := time.LoadLocation(zone)
+        if err != nil {
+            return nil, fmt.Errorf("schedule %q: unknown time zone %q", expr, zone)
+        }
+        s.loc, expr = loc, strings.TrimSpace(fields)
+    }
+    if full, ok := shorthands[expr]; ok {
+        expr = full
+    } else if isRRule(expr) {
+        full, err := rruleToCron(expr)
+        if err != nil {
+            return nil, fmt.Errorf("schedule %q: %w", expr, err)
+        }
+        expr = full
+    }
+    fields := strings.Fields(expr)
+    if len(fields) != 5 {
+        return nil, fmt.Errorf("schedule %q: want 5 fields (minute hour day-of-month month day-of-week)", expr)
+    }
+    var err error
+    for i, f := range []struct {
+        dst      *uint64
+        min, max int
+    }{{&s.minute, 0, 59}, {&s.hour, 0, 23}, {&s.dom, 1, 31}, {&s.month, 1, 12}, {&s.dow, 0, 7}} {
+        if *f.dst, err = parseField(fields[i], f.min, f.max); err != nil {
+            return nil, fmt.Errorf("schedule %q: %w", expr, err)
+        }
+    }
+    // 7 is Sunday too
+    if s.dow&(1<<7) != 0 {
+        s.dow |= 1
+    }
+    s.anyDom, s.anyDow = fields[2] == "*", fields[4] == "*"
+    return s, nil
+}
+
+func parseField(f string, min, max int) (uint64, error) {
+    var bits uint64
+    for _, part := range strings.Split(f, ",") {
+        rng, stepStr, hasStep := strings.Cut(part, "/")
+        step := 1
+        if hasStep {
+            n, err := strconv.Atoi(stepStr)
+            if err != nil || n <= 0 {
+                return 0, fmt.Errorf("bad step in %q", part)
+            }
+            step = n
+        }
+        lo, hi := min, max
+        if rng != "*" {
+            a, b, isRange := strings.Cut(rng, "-")
+            var err error
+            if lo, err = strconv.Atoi(a); err != nil {
+                return 0, fmt.Errorf("bad value in %q", part)
+            }
+            hi = lo
+            if isRange {
+                if hi, err = strconv.Atoi(b); err != nil {
+                    return 0, fmt.Errorf("bad range in %q", part)
+                }
+            } else if hasStep {
+                hi = max
+            }
+        }
+        if lo < min || hi > max || lo > hi {
+            return 0, fmt.Errorf("%q is outside %d-%d", part, min, max)
+        }
+        for v := lo; v <= hi; v += step {
+            bits |= 1 << v
+        }
+    }
+    return bits, nil
+}
+
+// Next returns the first whole minute after t that the expression matches, in t's location, or
+// the zero time if there is none within five years.
+// Like cron, when both day fields are restricted a day matching either of them matches.
+func (s *Spec) Next(t time.Time) time.Time {
+    in := t.In(s.loc).Truncate(time.Minute).Add(time.Minute)
+    // five years covers every satisfiable expression, including February 29
+    for limit := in.AddDate(5, 0, 0); in.Before(limit); {
+        if s.month&(1<<uint(in.Month())) == 0 {
+            in = time.Date(in.Year(), in.Month()+1, 1, 0, 0, 0, 0, s.loc)
+            continue
+        }
+        if !s.dayMatches(in) {
+            in = time.Date(in.Year(), in.Month(), in.Day()+1, 0, 0, 0, 0, s.loc)
+            continue
+        }
+        if s.hour&(1<<uint(in.Hour())) == 0 {
+            in = time.Date(in.Year(), in.Month(), in.Day(), in.Hour()+1, 0, 0, 0, s.loc)
+            continue
+        }
+        if s.minute&(1<<uint(in.Minute())) == 0 {
+            in = in.Add(time.Minute)
+            continue
+        }
+        return in.In(t.Location())
+    }
+    return time.Time{}
+}
+
+func (s *Spec) dayMatches(t time.Time) bool {
+    dom := s.dom&(1<<uint(t.Day())) != 0
+    dow := s.dow&(1<<uint(t.Weekday())) != 0
+    if s.anyDom || s.anyDow {
+        return dom && dow
+    }
+    return dom || dow
+}
@@ -0,0 +1,35 @@
+package schedule
+
+import (
+    "errors"
+    "testing"
+    "time"
+)
+
+func TestCronNext(t *testing.T) {
+    after := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC) // a Tuesday
+    for _, tc := range []struct {
+        expr string
+        want time.Time
+    }{
+        {"*/15 * * * *", time.Date(2026, 3, 10, 12, 15, 0, 0, time.UTC)},
+        {"@daily", time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)},
+        {"0 9 * * 1", time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)},
+        {"FREQ=WEEKLY;BYDAY=MO;BYHOUR=9", time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)},
+        {"FREQ=YEARLY;BYMONTHDAY=15", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+        {"FREQ=YEARLY", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+        {"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+        {"TZ=Europe/Warsaw 0 9 * * *", time.Date(2026, 3, 11, 8, 0, 0, 0, time.UTC)},
+    } {
+        got, err := Cron{}.Next(tc.expr, after)
+        if err != nil { t.Errorf("%s: %v", tc.expr, err); continue }
+        if !got.Equal(tc.want) { t.Errorf("%s: next = %v, want %v", tc.expr, got.UTC(), tc.want) }
+    }
+}
+
+func TestCronNextErrors(t *testing.T) {
+    if _, err := (Cron{}).Next("0 0 31 2 *", time.Now()); !errors.Is(err, ErrNever) { t.Errorf("Feb 31st: %v, want ErrNever", err) }
+    for _, expr := range []string{"0 0 * *", "61 * * * *", "TZ=Mars/Olympus 0 9 * * *"} {
+        if _, err := (Cron{}).Next(expr, time.Now()); err == nil { t.Errorf("%s: want an error", expr) }
+    }
+}
@@ -0,0 +1,98 @@
+package schedule
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+)
+
+var weekdays = map[string]string{"SU": "0", "MO": "1", "TU": "2", "WE": "3", "TH": "4", "FR": "5", "SA": "6"}
+
+// isRRule reports whether expr is in the RRULE-like syntax rather than cron.
+func isRRule(expr string) bool {
+    u := strings.ToUpper(expr)
+    return strings.HasPrefix(u, "RRULE:") || strings.HasPrefix(u, "FREQ=")
+}
+
+// rruleToCron translates the subset of RFC 5545 recurrence rules that cron can express:
+// FREQ=HOURLY|DAILY|WEEKLY|MONTHLY|YEARLY with BYMONTH, BYMONTHDAY, BYDAY (plain weekdays),
+// BYHOUR and BYMINUTE. Without a DTSTART to take them from, unset parts default like the @
+// shorthands: minute 0, hour 0, Sunday for weekly, the 1st for monthly, January 1st for yearly.
+// A yearly rule that picks its days with BYDAY or BYMONTHDAY but names no BYMONTH applies them
+// to every month, as RFC 5545 expands it.
+// As in cron, a rule with both BYMONTHDAY and BYDAY fires on days matching either.
+func rruleToCron(expr string) (string, error) {
+    rule := strings.TrimPrefix(strings.ToUpper(expr), "RRULE:")
+    parts := map[string]string{}
+    for _, kv := range strings.Split(rule, ";") {
+        k, v, ok := strings.Cut(kv, "=")
+        if !ok || v == "" {
+            return "", fmt.Errorf("bad rule part %q", kv)
+        }
+        parts[k] = v
+    }
+    minute, hour, dom, month, dow := "0", "0", "*", "*", "*"
+    switch parts["FREQ"] {
+    case "HOURLY":
+        hour = "*"
+    case "DAILY":
+    case "WEEKLY":
+        dow = "0"
+    case "MONTHLY":
+        dom = "1"
+    case "YEARLY":
+        dom, month = "1", "1"
+    default:
+        return "", fmt.Errorf("FREQ must be HOURLY, DAILY, WEEKLY, MONTHLY or YEARLY")
+    }
+    for k, v := range parts {
+        var err error
+        switch k {
+        case "FREQ":
+        case "INTERVAL":
+            if v != "1" {
+                return "", fmt.Errorf("INTERVAL other than 1 is not supported")
+            }
+        case "BYMINUTE":
+            minute, err = numberList(v)
+        case "BYHOUR":
+            hour, err = numberList(v)
+        case "BYMONTHDAY":
+            dom, err = numberList(v)
+        case "BYMONTH":
+            month, err = numberList(v)
+        case "BYDAY":
+            var days []string
+            for _, d := range strings.Split(v, ",") {
+                n, ok := weekdays[d]
+                if !ok {
+                    return "", fmt.Errorf("BYDAY takes plain weekdays (MO, TU, ...), not %q", d)
+                }
+                days = append(days, n)
+            }
+            dow = strings.Join(days, ",")
+            if _, ok := parts["BYMONTHDAY"]; !ok {
+                // the weekdays replace the default day of the month rather than narrow it
+                dom = "*"
+            }
+        default:
+            return "", fmt.Errorf("unsupported rule part %s", k)
+        }
+        if err != nil {
+            return "", fmt.Errorf("%s: %w", k, err)
+        }
+    }
+    if parts["FREQ"] == "YEARLY" && parts["BYMONTH"] == "" && (parts["BYDAY"] != "" || parts["BYMONTHDAY"] != "") {
+        month = "*"
+    }
+    return strings.Join([]string{minute, hour, dom, month, dow}, " "), nil
+}
+
+func numberList(v string) (string, error) {
+    for _, n := range strings.Split(v, ",") {
+        if _, err := strconv.Atoi(n); err != nil {
+            return "", fmt.Errorf("bad number %q", n)
+        }
+    }
+    return v, nil
+}
@@ -0,0 +1,43 @@
+package schedule
+
+import "testing"
+
+func TestRRuleToCron(t *testing.T) {
+    for _, tc := range []struct {
+        rule, want string
+    }{
+        {"FREQ=HOURLY", "0 * * * *"},
+        {"FREQ=HOURLY;BYMINUTE=15,45", "15,45 * * * *"},
+        {"FREQ=DAILY;BYHOUR=9;BYMINUTE=30", "30 9 * * *"},
+        {"RRULE:FREQ=WEEKLY", "0 0 * * 0"},
+        {"FREQ=WEEKLY;BYDAY=MO,FR;BYHOUR=9", "0 9 * * 1,5"},
+        {"freq=weekly;byday=tu", "0 0 * * 2"},
+        {"FREQ=MONTHLY", "0 0 1 * *"},
+        {"FREQ=MONTHLY;BYMONTHDAY=15", "0 0 15 * *"},
+        {"FREQ=MONTHLY;BYDAY=MO", "0 0 * * 1"},
+        {"FREQ=YEARLY", "0 0 1 1 *"},
+        {"FREQ=YEARLY;BYMONTH=3", "0 0 1 3 *"},
+        {"FREQ=YEARLY;BYMONTH=3;BYMONTHDAY=15", "0 0 15 3 *"},
+        {"FREQ=YEARLY;BYMONTHDAY=15", "0 0 15 * *"},
+        {"FREQ=YEARLY;BYDAY=MO", "0 0 * * 1"},
+        {"FREQ=YEARLY;BYMONTH=12;BYDAY=FR", "0 0 * 12 5"},
+        {"FREQ=DAILY;INTERVAL=1", "0 0 * * *"},
+    } {
+        got, err := rruleToCron(tc.rule)
+        if err != nil { t.Errorf("%s: %v", tc.rule, err); continue }
+        if got != tc.want { t.Errorf("%s = %q, want %q", tc.rule, got, tc.want) }
+    }
+}
+
+func TestRRuleToCronRejects(t *testing.T) {
+    for _, rule := range []string{
+        "FREQ=SECONDLY",
+        "FREQ=DAILY;INTERVAL=2",
+        "FREQ=WEEKLY;BYDAY=1MO",
+        "FREQ=DAILY;BYHOUR=nine",
+        "FREQ=DAILY;COUNT=3",
+        "FREQ=DAILY;BYHOUR",
+    } {
+        if got, err := rruleToCron(rule); err == nil { t.Errorf("%s = %q, want an error", rule, got) }
+    }
+}
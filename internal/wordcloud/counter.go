@@ -0,0 +1,66 @@
+// Package wordcloud counts terms in free-text responses: text is case-folded, split on anything
+// that is not a letter or digit, and stripped of the language's stopwords.
+package wordcloud
+
+import (
+    "sort"
+    "strings"
+    "unicode"
+    "unicode/utf8"
+
+    "golang.org/x/text/cases"
+
+    "github.com/robjsliwa/pulse/app"
+    "github.com/robjsliwa/pulse/domain"
+)
+
+// DefaultLanguage is used when neither the poll nor the Counter names a language.
+const DefaultLanguage = "en"
+
+// Counter implements app.TermCounter. Terms shorter than MinRunes are dropped as noise.
+type Counter struct {
+    Language string // used for polls without a language
+    MaxTerms int    // 0 returns every term
+    MinRunes int
+}
+
+func NewCounter(language string, maxTerms int) *Counter {
+    if language == "" { language = DefaultLanguage }
+    return &Counter{Language: language, MaxTerms: maxTerms, MinRunes: 2}
+}
+
+var _ app.TermCounter = (*Counter)(nil)
+
+func (c *Counter) Supports(language string) bool {
+    if language == "" { language = c.Language }
+    _, ok := stopwords[language]
+    return ok
+}
+
+func (c *Counter) CountTerms(texts []string, language string) []domain.TermCount {
+    if language == "" { language = c.Language }
+    stop := stopwords[language]
+    fold := cases.Fold()
+    counts := map[string]int{}
+    for _, text := range texts {
+        for _, term := range Terms(fold.String(text)) {
+            if utf8.RuneCountInString(term) < c.MinRunes || stop[term] { continue }
+            counts[term]++
+        }
+    }
+    out := make([]domain.TermCount, 0, len(counts))
+    for term, n := range counts { out = append(out, domain.TermCount{Term: term, Count: n}) }
+    sort.Slice(out, func(i, j int) bool {
+        if out[i].Count != out[j].Count { return out[i].Count > out[j].Count }
+        return out[i].Term < out[j].Term
+    })
+    if c.MaxTerms > 0 && len(out) > c.MaxTerms { out = out[:c.MaxTerms] }
+    return out
+}
+
+// Terms splits already folded text into words. Apostrophes are dropped rather than split on, so
+// "don't" becomes "dont" and matches the stopword lists, which are normalized the same way.
+func Terms(text string) []string {
+    text = strings.NewReplacer("'", "", "’", "").Replace(text)
+    return strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) })
+}
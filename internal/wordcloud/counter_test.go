@@ -0,0 +1,43 @@
+package wordcloud
+
+import (
+    "reflect"
+    "testing"
+
+    "github.com/robjsliwa/pulse/domain"
+)
+
+func TestTerms(t *testing.T) {
+    for _, tc := range []struct {
+        text string
+        want []string
+    }{
+        {"ship it, now!", []string{"ship", "it", "now"}},
+        {"don't stop", []string{"dont", "stop"}},
+        {"it’s v2.0", []string{"its", "v2", "0"}},
+        {"  ", []string{}},
+    } {
+        if got := Terms(tc.text); !reflect.DeepEqual(got, tc.want) { t.Errorf("Terms(%q) = %q, want %q", tc.text, got, tc.want) }
+    }
+}
+
+func TestCountTerms(t *testing.T) {
+    c := NewCounter("", 0)
+    got := c.CountTerms([]string{"Ship it NOW", "ship, don't wait", "Now is the time to ship", "a b"}, "")
+    want := []domain.TermCount{{Term: "ship", Count: 3}, {Term: "now", Count: 2}, {Term: "time", Count: 1}, {Term: "wait", Count: 1}}
+    if !reflect.DeepEqual(got, want) { t.Fatalf("CountTerms = %+v, want %+v", got, want) }
+
+    c.MaxTerms = 2
+    if got := c.CountTerms([]string{"Ship it NOW", "ship now", "later"}, ""); len(got) != 2 || got[0].Term != "now" || got[1].Term != "ship" { t.Fatalf("top 2 = %+v", got) }
+}
+
+func TestCountTermsUsesThePollLanguage(t *testing.T) {
+    c := NewCounter("en", 0)
+    texts := []string{"Über den Fluss und die Brücke"}
+    // German stopwords are not English ones: "die" and "den" count in English
+    got := c.CountTerms(texts, "de")
+    want := []domain.TermCount{{Term: "brücke", Count: 1}, {Term: "fluss", Count: 1}}
+    if !reflect.DeepEqual(got, want) { t.Fatalf("de: CountTerms = %+v, want %+v", got, want) }
+    if got := c.CountTerms(texts, ""); len(got) <= len(want) { t.Fatalf("en: CountTerms = %+v, want German stopwords kept", got) }
+    if !c.Supports("de") || !c.Supports("") || c.Supports("xx") { t.Fatalf("Supports: de %v, default %v, xx %v", c.Supports("de"), c.Supports(""), c.Supports("xx")) }
+}
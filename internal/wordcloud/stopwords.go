@@ -0,0 +1,55 @@
+package wordcloud
+
+import (
+    "strings"
+
+    "golang.org/x/text/cases"
+)
+
+// stopwords maps a language code to words too common to say anything in a word cloud. The lists
+// are normalized like response text (folded, apostrophes dropped) when the package loads.
+var stopwords = map[string]map[string]bool{}
+
+var stopwordLists = map[string]string{
+    "en": `a about above after again against all am an and any are aren't as at be because been before
+        being below between both but by can can't cannot could couldn't did didn't do does doesn't doing
+        don't down during each few for from further had hadn't has hasn't have haven't having he he'd
+        he'll he's her here here's hers herself him himself his how how's i i'd i'll i'm i've if in into
+        is isn't it it's its itself just let's me more most mustn't my myself no nor not of off on once
+        only or other ought our ours ourselves out over own same shan't she she'd she'll she's should
+        shouldn't so some such than that that's the their theirs them themselves then there there's
+        these they they'd they'll they're they've this those through to too under until up very was
+        wasn't we we'd we'll we're we've were weren't what what's when when's where where's which while
+        who who's whom why why's will with won't would wouldn't you you'd you'll you're you've your
+        yours yourself yourselves`,
+    "de": `aber alle allem allen aller alles als also am an ander andere anderem anderen anderer anderes
+        auch auf aus bei bin bis bist da damit dann das dass dein deine dem den der des dessen dich die
+        dies diese diesem diesen dieser dieses dir doch dort du durch ein eine einem einen einer eines
+        er es etwas euch euer eure für gegen gewesen hab habe haben hat hatte hatten hier hin hinter ich
+        ihm ihn ihnen ihr ihre im in indem ins ist jede jedem jeden jeder jedes jetzt kann kein keine
+        können man manche mein meine mich mir mit muss nach nicht nichts noch nun nur ob oder ohne sehr
+        sein seine sich sie sind so solche soll sondern sonst über um und uns unser unter viel vom von
+        vor war waren warst was weil weiter welche wenn wer werde werden wie wieder will wir wird wo
+        wollen würde zu zum zur zwar zwischen`,
+    "es": `a al algo algunas algunos ante antes como con contra cual cuando de del desde donde durante
+        e el ella ellas ellos en entre era eran es esa esas ese eso esos esta estaba estado estamos
+        estar este esto estos fue fueron fui ha había han has hay la las le les lo los más me mi mis
+        mucho muy nada ni no nos nosotros o os otra otras otro otros para pero poco por porque que
+        quien se sea ser si sido sin sobre son su sus también tanto te tiene tienen todo todos tu tus
+        un una unas uno unos vosotros y ya yo`,
+    "fr": `à ai aie aient ait as au aux avec avons avez c'est ce ceci cela ces cet cette d'un d'une dans
+        de des du elle elles en es est et étaient était être eu eux il ils j'ai je l'on la le les leur
+        leurs lui ma mais me même mes moi mon n'est ne nos notre nous on ont ou où par pas pour qu'il
+        que qui sa se ses si son sont sur ta te tes toi ton tu un une vos votre vous y`,
+}
+
+func init() {
+    fold := cases.Fold()
+    for lang, list := range stopwordLists {
+        set := map[string]bool{}
+        for _, w := range strings.Fields(list) {
+            for _, term := range Terms(fold.String(w)) { set[term] = true }
+        }
+        stopwords[lang] = set
+    }
+}